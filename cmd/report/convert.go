@@ -0,0 +1,156 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec report convert` command
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/input"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// writer is implemented by both the image and the input validation reports
+type writer interface {
+	WriteAll(targets []string, p format.TargetParser) error
+}
+
+func convertCmd() *cobra.Command {
+	var data = struct {
+		input      string
+		output     []string
+		noColor    bool
+		forceColor bool
+	}{}
+
+	cmd := &cobra.Command{
+		Use:   "convert --input <report> --output <format>=<path>",
+		Short: "Convert a saved validation report to other formats",
+
+		Long: hd.Doc(`
+			Convert a saved validation report to other formats
+
+			The report must have been saved in the JSON or YAML format by the
+			"ec validate image" or the "ec validate input" command. It is rendered
+			to the requested output formats as if the validation was performed
+			again, without performing the validation.
+
+			Information that is not part of the JSON and YAML formats is not
+			available when converting. For example the "policy-input" format
+			produces no output, and successes are included only if the report was
+			saved with successes shown. The descriptions of the rules, used for
+			example by the "sarif" and "html" formats, are available only if the
+			report was saved with the --info flag or with the include-rule-info
+			option, e.g. --output json=report.json?include-rule-info=true.
+
+			The time of the validation is taken from the "created" field of the
+			report. The report also includes the number of successes in the
+			"success-count" field of each component, so that it is known even
+			when the successes were not shown. Reports saved by older versions
+			without those fields are converted as if they were created at the
+			time of the conversion.
+		`),
+
+		Example: hd.Doc(`
+			Convert a report of image validation to JUnit XML:
+
+			  ec report convert --input report.json --output junit=report.xml
+
+			Render a report as text on the standard output and as markdown to a file:
+
+			  ec report convert --input report.json --output text --output summary-markdown=report.md
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := utils.FS(cmd.Context())
+
+			report, err := readReport(fs, data.input)
+			if err != nil {
+				return err
+			}
+
+			utils.SetColorEnabled(data.noColor, data.forceColor)
+			// The successes are present only if the report was saved with them
+			opts := format.DefaultOptions()
+			opts.ShowSuccesses = true
+			p := format.NewTargetParser(applicationsnapshot.JSON, opts, cmd.OutOrStdout(), fs)
+
+			return report.WriteAll(data.output, p)
+		},
+	}
+
+	cmd.Flags().StringVarP(&data.input, "input", "i", data.input, "path to the saved JSON or YAML report (required)")
+
+	cmd.Flags().StringSliceVarP(&data.output, "output", "o", data.output, hd.Doc(`
+		write output to a file in a specific format. Use empty string path for stdout.
+		May be used multiple times. Possible formats are:
+		`+strings.Join(applicationsnapshot.OutputFormats, ", ")+`. In following format and file path
+		additional options can be provided in key=value form following the question
+		mark (?) sign, for example: --output text=output.txt?show-successes=false.
+		The options are show-successes, show-warnings, include-signatures,
+		include-attestations and include-rule-info (true or false), severity>=
+		(success, warning or failure), component and rules (globs matching the
+		component name, image or file path, and the rule code) and
+		max-message-length.
+	`))
+
+	cmd.Flags().BoolVar(&data.noColor, "no-color", data.noColor, hd.Doc(`
+		Disable color when using text output even when the current terminal supports it`))
+
+	cmd.Flags().BoolVar(&data.forceColor, "color", data.forceColor, hd.Doc(`
+		Enable color when using text output even when the current terminal does not support it`))
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// readReport reads the image or input validation report from the given path.
+// The kind of the report is determined from its contents.
+func readReport(fs afero.Fs, path string) (writer, error) {
+	contents, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal(contents, &fields); err != nil {
+		return nil, fmt.Errorf("unable to parse report %q: %w", path, err)
+	}
+
+	if _, ok := fields["components"]; ok {
+		return applicationsnapshot.ReadReport(contents)
+	}
+
+	if _, ok := fields["filepaths"]; ok {
+		return input.ReadReport(contents)
+	}
+
+	return nil, errors.New("unrecognized report, expecting a report from either the validate image or the validate input command")
+}
@@ -0,0 +1,167 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/cmd/root"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+const imageReport = `{
+  "success": false,
+  "components": [
+    {
+      "name": "spam",
+      "containerImage": "registry.io/repository/image@sha256:abc",
+      "source": {},
+      "violations": [
+        {"msg": "violation", "metadata": {"code": "a.b"}}
+      ],
+      "successes": [
+        {"msg": "Pass", "metadata": {"code": "c.d"}}
+      ],
+      "success": false
+    }
+  ],
+  "key": "",
+  "policy": {},
+  "ec-version": "v1.2.3",
+  "effective-time": "2024-01-02T03:04:05Z"
+}`
+
+// imageReportWithoutSuccesses is a report written without the --show-successes flag
+const imageReportWithoutSuccesses = `{
+  "success": true,
+  "components": [
+    {
+      "name": "spam",
+      "containerImage": "registry.io/repository/image@sha256:abc",
+      "source": {},
+      "success": true,
+      "success-count": 3
+    }
+  ],
+  "key": "",
+  "policy": {},
+  "ec-version": "v1.2.3",
+  "effective-time": "2024-01-02T03:04:05Z"
+}`
+
+const inputReport = `{
+  "success": true,
+  "filepaths": [
+    {"filepath": "input.yaml", "violations": [], "warnings": [], "successes": [], "success": true, "success-count": 1}
+  ],
+  "policy": {},
+  "ec-version": "v1.2.3",
+  "effective-time": "2024-01-02T03:04:05Z"
+}`
+
+func setUpCobra(command *cobra.Command) *cobra.Command {
+	reportCmd := NewReportCmd()
+	reportCmd.AddCommand(command)
+	cmd := root.NewRootCmd()
+	cmd.AddCommand(reportCmd)
+	return cmd
+}
+
+func TestConvertImageReport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.json", []byte(imageReport), 0400))
+
+	cmd := setUpCobra(convertCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), fs))
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"report", "convert", "--input", "report.json", "--output", "summary", "--output", "junit=report.xml"})
+
+	require.NoError(t, cmd.Execute())
+
+	assert.JSONEq(t, `{
+		"components": [
+			{
+				"name": "spam",
+				"success": false,
+				"violations": {"a.b": ["violation"]},
+				"warnings": {},
+				"successes": {"c.d": ["Pass"]},
+				"total_violations": 1,
+				"total_warnings": 0,
+				"total_successes": 1
+			}
+		],
+		"success": false,
+		"key": ""
+	}`, out.String())
+
+	junit, err := afero.ReadFile(fs, "report.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(junit), `<failure message="violation">`)
+}
+
+func TestConvertImageReportWithoutSuccesses(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.json", []byte(imageReportWithoutSuccesses), 0400))
+
+	cmd := setUpCobra(convertCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), fs))
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"report", "convert", "--input", "report.json", "--output", "summary"})
+
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), `"total_successes":3`)
+}
+
+func TestConvertInputReport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.yaml", []byte(inputReport), 0400))
+
+	cmd := setUpCobra(convertCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), fs))
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"report", "convert", "--input", "report.yaml", "--output", "yaml"})
+
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "filepath: input.yaml")
+}
+
+func TestConvertUnrecognizedReport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.json", []byte(`{"spam": true}`), 0400))
+
+	cmd := setUpCobra(convertCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), fs))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"report", "convert", "--input", "report.json"})
+
+	assert.EqualError(t, cmd.Execute(), "unrecognized report, expecting a report from either the validate image or the validate input command")
+}
@@ -0,0 +1,138 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec report diff` command
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func diffCmd() *cobra.Command {
+	var data = struct {
+		output     []string
+		strict     bool
+		noColor    bool
+		forceColor bool
+	}{
+		strict: true,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff <old report> <new report>",
+		Short: "Show the differences between two saved image validation reports",
+
+		Long: hd.Doc(`
+			Show the differences between two saved image validation reports
+
+			The reports must have been saved in the JSON or YAML format by the
+			"ec validate image" command. Components are matched by their name, or by
+			the repository of their image when they have no name. Violations,
+			warnings and successes are matched by the code and the term of the rule
+			that produced them, both are included in the reports even when saved
+			without the --info flag.
+
+			For each component the new, resolved and changed violations, warnings
+			and successes are listed. Successes are included only if both reports
+			were saved with successes shown.
+
+			The results are considered to have got worse if there are new
+			violations, or if a component, or the overall result, changed from
+			success to failure. In that case a non-zero status is returned, unless
+			--strict=false is used.
+		`),
+
+		Example: hd.Doc(`
+			Show the differences between the reports before and after a policy upgrade:
+
+			  ec report diff before.json after.json
+
+			Write the differences in markdown format to a file:
+
+			  ec report diff before.json after.json --output markdown=diff.md
+		`),
+
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := utils.FS(cmd.Context())
+
+			old, err := readImageReport(fs, args[0])
+			if err != nil {
+				return err
+			}
+
+			new, err := readImageReport(fs, args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := applicationsnapshot.Diff(old, new)
+
+			utils.SetColorEnabled(data.noColor, data.forceColor)
+			p := format.NewTargetParser(applicationsnapshot.DiffText, format.DefaultOptions(), cmd.OutOrStdout(), fs)
+			if err := diff.WriteAll(data.output, p); err != nil {
+				return err
+			}
+
+			if data.strict && diff.Worse {
+				return errors.New("the results got worse")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&data.output, "output", "o", data.output, hd.Doc(`
+		write output to a file in a specific format. Use empty string path for stdout.
+		May be used multiple times. Possible formats are:
+		`+strings.Join(applicationsnapshot.DiffOutputFormats, ", ")+`.
+	`))
+
+	cmd.Flags().BoolVarP(&data.strict, "strict", "s", data.strict,
+		"Return non-zero status when the results got worse. Defaults to true. Use --strict=false to return a zero status code.")
+
+	cmd.Flags().BoolVar(&data.noColor, "no-color", data.noColor, hd.Doc(`
+		Disable color when using text output even when the current terminal supports it`))
+
+	cmd.Flags().BoolVar(&data.forceColor, "color", data.forceColor, hd.Doc(`
+		Enable color when using text output even when the current terminal does not support it`))
+
+	return cmd
+}
+
+func readImageReport(fs afero.Fs, path string) (applicationsnapshot.Report, error) {
+	contents, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return applicationsnapshot.Report{}, err
+	}
+
+	report, err := applicationsnapshot.ReadReport(contents)
+	if err != nil {
+		return applicationsnapshot.Report{}, fmt.Errorf("unable to read report %q: %w", path, err)
+	}
+
+	return report, nil
+}
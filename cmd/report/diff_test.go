@@ -0,0 +1,130 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+const passingReport = `{
+  "success": true,
+  "components": [
+    {"name": "spam", "containerImage": "registry.io/repository/image@sha256:abc", "success": true}
+  ]
+}`
+
+// termReport returns a report as saved by "ec validate image" without the
+// --info flag, holding a violation of the same rule for each of the terms.
+func termReport(terms ...string) string {
+	violations := make([]string, 0, len(terms))
+	for _, term := range terms {
+		violations = append(violations, fmt.Sprintf(`{"msg": "Fails for %[1]s", "metadata": {"code": "a.b", "term": %[1]q}}`, term))
+	}
+
+	return fmt.Sprintf(`{
+  "success": false,
+  "components": [
+    {"name": "spam", "containerImage": "registry.io/repository/image@sha256:abc", "violations": [%s], "success": false}
+  ]
+}`, strings.Join(violations, ","))
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "worse",
+			args: []string{"passing.json", "failing.json"},
+			err:  "the results got worse",
+		},
+		{
+			name: "worse not strict",
+			args: []string{"passing.json", "failing.json", "--strict=false"},
+		},
+		{
+			name: "better",
+			args: []string{"failing.json", "passing.json"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "passing.json", []byte(passingReport), 0400))
+			require.NoError(t, afero.WriteFile(fs, "failing.json", []byte(imageReport), 0400))
+
+			cmd := setUpCobra(diffCmd())
+			cmd.SetContext(utils.WithFS(context.Background(), fs))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"report", "diff", "--output", "json=diff.json"}, c.args...))
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			out, err := afero.ReadFile(fs, "diff.json")
+			require.NoError(t, err)
+			assert.True(t, json.Valid(out))
+		})
+	}
+}
+
+func TestDiffMatchesTerms(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "old.json", []byte(termReport("t1", "t2")), 0400))
+	require.NoError(t, afero.WriteFile(fs, "new.json", []byte(termReport("t2", "t3")), 0400))
+
+	cmd := setUpCobra(diffCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), fs))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"report", "diff", "--output", "json=diff.json", "old.json", "new.json"})
+
+	assert.EqualError(t, cmd.Execute(), "the results got worse")
+
+	out, err := afero.ReadFile(fs, "diff.json")
+	require.NoError(t, err)
+
+	var diff applicationsnapshot.ReportDiff
+	require.NoError(t, json.Unmarshal(out, &diff))
+	require.Len(t, diff.Components, 1)
+	assert.Equal(t, applicationsnapshot.ResultsDiff{
+		New:      []applicationsnapshot.ResultChange{{Code: "a.b", Term: "t3", Message: "Fails for t3"}},
+		Resolved: []applicationsnapshot.ResultChange{{Code: "a.b", Term: "t1", Message: "Fails for t1"}},
+	}, diff.Components[0].Violations)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/enterprise-contract/ec-cli/cmd/initialize"
 	"github.com/enterprise-contract/ec-cli/cmd/inspect"
 	"github.com/enterprise-contract/ec-cli/cmd/opa"
+	"github.com/enterprise-contract/ec-cli/cmd/report"
 	"github.com/enterprise-contract/ec-cli/cmd/root"
 	"github.com/enterprise-contract/ec-cli/cmd/sigstore"
 	"github.com/enterprise-contract/ec-cli/cmd/test"
@@ -63,6 +64,7 @@ func AddCommandsTo(cmd *cobra.Command) {
 	cmd.AddCommand(validate.ValidateCmd)
 	cmd.AddCommand(version.VersionCmd)
 	cmd.AddCommand(opa.OPACmd)
+	cmd.AddCommand(report.ReportCmd)
 	cmd.AddCommand(sigstore.SigstoreCmd)
 	if utils.Experimental() {
 		cmd.AddCommand(test.TestCmd)
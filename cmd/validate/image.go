@@ -24,11 +24,13 @@ import (
 	"runtime/trace"
 	"sort"
 	"strings"
+	"time"
 
 	hd "github.com/MakeNowJust/heredoc"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
@@ -38,6 +40,8 @@ import (
 	"github.com/enterprise-contract/ec-cli/internal/output"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	regooci "github.com/enterprise-contract/ec-cli/internal/rego/oci"
+	"github.com/enterprise-contract/ec-cli/internal/replay"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 	validate_utils "github.com/enterprise-contract/ec-cli/internal/validate"
 )
@@ -49,33 +53,47 @@ var newOPAEvaluator = evaluator.NewOPAEvaluator
 
 func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	data := struct {
+		baseline                    string
 		certificateIdentity         string
 		certificateIdentityRegExp   string
 		certificateOIDCIssuer       string
 		certificateOIDCIssuerRegExp string
+		components                  []string
 		effectiveTime               string
 		extraRuleData               []string
 		filePath                    string // Deprecated: images replaced this
+		groupPlatforms              bool
 		imageRef                    string
 		info                        bool
 		input                       string // Deprecated: images replaced this
 		ignoreRekor                 bool
 		output                      []string
 		outputFile                  string
+		platforms                   []string
 		policy                      policy.Policy
 		policyConfiguration         string
 		publicKey                   string
 		rekorURL                    string
-		snapshot                    string
-		spec                        *app.SnapshotSpec
+		requirePlatforms            []string
+		rerunFailed                 string
+		skipComponents              []string
+		skipped                     []applicationsnapshot.SkippedComponent
+		snapshots                   []string
+		specs                       []applicationsnapshot.NamedSnapshot
 		strict                      bool
-		images                      string
+		images                      []string
+		suggestExclusions           string
+		suggestExclusionsType       string
+		suggestExclusionsUntil      string
+		suggestExclusionsPerImage   bool
 		noColor                     bool
 		forceColor                  bool
 		workers                     int
 	}{
-		strict:  true,
-		workers: 5,
+		strict:                    true,
+		workers:                   5,
+		suggestExclusionsType:     applicationsnapshot.JSONPatch,
+		suggestExclusionsPerImage: true,
 	}
 
 	validOutputFormats := applicationsnapshot.OutputFormats
@@ -170,6 +188,46 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 			  ec validate image --image registry/name:tag --output yaml --output appstudio=<path>
 
+			Render output using a custom Go template and write it to a file
+
+			  ec validate image --image registry/name:tag --output template=<template path>?file=<path>
+
+			Fail only on violations that are not present in a baseline, and write a
+			new baseline from the current run
+
+			  ec validate image --images my-app.yaml --baseline baseline.json --output baseline=baseline.json
+
+			Stream a line for each component as soon as it is validated
+
+			  ec validate image --images my-app.yaml --output ndjson
+
+			Write metrics for the node-exporter textfile collector
+
+			  ec validate image --images my-app.yaml --output openmetrics=/var/lib/node-exporter/ec.prom
+
+			Write a JSON Patch of the policy configuration excluding the current violations
+			for the next 30 days
+
+			  ec validate image --images my-app.yaml --suggest-exclusions exclusions.json --suggest-exclusions-until 720h
+
+			Validate the images of several snapshots at once, reporting the result of each
+
+			  ec validate image --snapshot my-namespace/app-one --snapshot my-namespace/app-two --images other-app.yaml
+
+			Validate only the components that failed in a previous report, except the ones
+			named with a "legacy-" prefix
+
+			  ec validate image --images my-app.yaml --rerun-failed report.json --skip-component 'legacy-*'
+
+			Validate the linux images of a multi-platform image, requiring images with
+			signed attestations for the amd64 and arm64 architectures
+
+			  ec validate image --image registry/name:tag --platform 'linux/*' \
+			    --require-platform linux/amd64 --require-platform linux/arm64
+
+			Write a bundle to evaluate the policy again later, without network access
+
+			  ec validate image --images my-app.yaml --output replay=bundle/
 
 			Validate a single image with keyless workflow.
 
@@ -195,46 +253,35 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				cmd.SetContext(ctx)
 			}
 
-			if s, err := applicationsnapshot.DetermineInputSpec(ctx, applicationsnapshot.Input{
-				File:     data.filePath,
-				JSON:     data.input,
-				Image:    data.imageRef,
-				Snapshot: data.snapshot,
-				Images:   data.images,
-			}); err != nil {
-				allErrors = errors.Join(allErrors, err)
-			} else {
-				data.spec = s
-			}
+			// The policy is loaded first, it may require platforms, but its errors
+			// are reported after the errors of the input
+			var p policy.Policy
+			policyConfiguration, policyErr := validate_utils.GetPolicyConfig(ctx, data.policyConfiguration)
+			if policyErr == nil {
+				data.policyConfiguration = policyConfiguration
+
+				policyOptions := policy.Options{
+					EffectiveTime: data.effectiveTime,
+					Identity: cosign.Identity{
+						Issuer:        data.certificateOIDCIssuer,
+						IssuerRegExp:  data.certificateOIDCIssuerRegExp,
+						Subject:       data.certificateIdentity,
+						SubjectRegExp: data.certificateIdentityRegExp,
+					},
+					IgnoreRekor: data.ignoreRekor,
+					PolicyRef:   data.policyConfiguration,
+					PublicKey:   data.publicKey,
+					RekorURL:    data.rekorURL,
+				}
 
-			policyConfiguration, err := validate_utils.GetPolicyConfig(ctx, data.policyConfiguration)
-			if err != nil {
-				allErrors = errors.Join(allErrors, err)
-				return
-			}
-			data.policyConfiguration = policyConfiguration
-
-			policyOptions := policy.Options{
-				EffectiveTime: data.effectiveTime,
-				Identity: cosign.Identity{
-					Issuer:        data.certificateOIDCIssuer,
-					IssuerRegExp:  data.certificateOIDCIssuerRegExp,
-					Subject:       data.certificateIdentity,
-					SubjectRegExp: data.certificateIdentityRegExp,
-				},
-				IgnoreRekor: data.ignoreRekor,
-				PolicyRef:   data.policyConfiguration,
-				PublicKey:   data.publicKey,
-				RekorURL:    data.rekorURL,
+				// We're not currently using the policyCache returned from PreProcessPolicy, but we could
+				// use it to cache the policy for future use.
+				p, _, policyErr = policy.PreProcessPolicy(ctx, policyOptions)
 			}
-
-			// We're not currently using the policyCache returned from PreProcessPolicy, but we could
-			// use it to cache the policy for future use.
-			if p, _, err := policy.PreProcessPolicy(ctx, policyOptions); err != nil {
-				allErrors = errors.Join(allErrors, err)
-			} else {
+			if policyErr == nil {
 				// inject extra variables into rule data per source
 				if len(data.extraRuleData) > 0 {
+					var err error
 					policySpec := p.Spec()
 					sources := policySpec.Sources
 					for i := range sources {
@@ -284,8 +331,59 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 					p = p.WithSpec(policySpec)
 				}
 				data.policy = p
+
+				// The --require-platform flag overrides the platforms required
+				// by the policy
+				if !cmd.Flags().Changed("require-platform") {
+					data.requirePlatforms, policyErr = applicationsnapshot.PolicyRequiredPlatforms(p.Spec())
+				}
 			}
 
+			// Filtering or requiring platforms needs the platforms to be
+			// validated as part of the image index
+			if len(data.platforms)+len(data.requirePlatforms) > 0 {
+				data.groupPlatforms = true
+			}
+			if err := applicationsnapshot.ValidatePlatformPatterns(data.platforms); err != nil {
+				allErrors = errors.Join(allErrors, err)
+			}
+
+			if _, err := exclusionsUntil(data.suggestExclusionsUntil); err != nil {
+				allErrors = errors.Join(allErrors, err)
+			}
+
+			// A single --snapshot and --images are merged into one snapshot, as
+			// they always were. Each of several snapshots is validated and
+			// reported separately.
+			if len(data.snapshots) > 1 || len(data.images) > 1 {
+				if data.filePath != "" || data.input != "" || data.imageRef != "" {
+					allErrors = errors.Join(allErrors, errors.New("--image, --file-path and --json-input cannot be used with several --snapshot or --images"))
+				} else if s, err := applicationsnapshot.DetermineInputSpecs(ctx, data.snapshots, data.images, data.groupPlatforms); err != nil {
+					allErrors = errors.Join(allErrors, err)
+				} else {
+					data.specs = s
+				}
+			} else if s, err := applicationsnapshot.DetermineInputSpec(ctx, applicationsnapshot.Input{
+				File:           data.filePath,
+				JSON:           data.input,
+				Image:          data.imageRef,
+				Snapshot:       firstOf(data.snapshots),
+				Images:         firstOf(data.images),
+				GroupPlatforms: data.groupPlatforms,
+			}); err != nil {
+				allErrors = errors.Join(allErrors, err)
+			} else {
+				data.specs = []applicationsnapshot.NamedSnapshot{{Spec: s}}
+			}
+
+			if skipped, err := selectComponents(ctx, data.specs, data.components, data.skipComponents, data.rerunFailed); err != nil {
+				allErrors = errors.Join(allErrors, err)
+			} else {
+				data.skipped = skipped
+			}
+
+			allErrors = errors.Join(allErrors, policyErr)
+
 			return
 		},
 
@@ -296,13 +394,24 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				defer task.End()
 			}
 
+			type job struct {
+				component app.SnapshotComponent
+				snapshot  int
+			}
+
 			type result struct {
 				err         error
 				component   applicationsnapshot.Component
 				policyInput []byte
+				snapshot    int
 			}
 
-			appComponents := data.spec.Components
+			var jobList []job
+			for i, s := range data.specs {
+				for _, c := range s.Spec.Components {
+					jobList = append(jobList, job{component: c, snapshot: i})
+				}
+			}
 			evaluators := []evaluator.Evaluator{}
 
 			// Return an evaluator for each of these
@@ -334,12 +443,66 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 			showSuccesses, _ := cmd.Flags().GetBool("show-successes")
 
+			// The attestation statements are only kept for the output formats that
+			// render them, or derive data from them.
+			keepStatements := containsOutput(data.output, applicationsnapshot.Attestation) ||
+				containsOutput(data.output, applicationsnapshot.VSA) ||
+				containsOutput(data.output, applicationsnapshot.SLSAVSA)
+
+			if len(data.outputFile) > 0 {
+				data.output = append(data.output, fmt.Sprintf("%s=%s", applicationsnapshot.JSON, data.outputFile))
+			}
+
+			var baseline *applicationsnapshot.Report
+			if data.baseline != "" {
+				contents, err := afero.ReadFile(utils.FS(cmd.Context()), data.baseline)
+				if err != nil {
+					return err
+				}
+				b, err := applicationsnapshot.ReadReport(contents)
+				if err != nil {
+					return fmt.Errorf("unable to read baseline %q: %w", data.baseline, err)
+				}
+				baseline = &b
+			}
+
+			opts := format.DefaultOptions()
+			opts.ShowSuccesses = showSuccesses
+			p := format.NewTargetParser(applicationsnapshot.JSON, opts, cmd.OutOrStdout(), utils.FS(cmd.Context()))
+			utils.SetColorEnabled(data.noColor, data.forceColor)
+
+			// The NDJSON outputs are written progressively, as each component is
+			// validated, all other outputs once all components are validated.
+			streamed, outputs := splitOutput(data.output, applicationsnapshot.NDJSON)
+			stream, err := applicationsnapshot.NewStream(streamed, p)
+			if err != nil {
+				return err
+			}
+
+			// The replay outputs are bundles written to a directory
+			replays, outputs := splitOutput(outputs, applicationsnapshot.Replay)
+			var replayDirs []string
+			for _, r := range replays {
+				target, err := p.Parse(r)
+				if err != nil {
+					return err
+				}
+				if target.Path == "" {
+					return errors.New("the replay format requires a path to the bundle directory, e.g. replay=<dir>")
+				}
+				replayDirs = append(replayDirs, target.Path)
+			}
+
 			// worker is responsible for processing one component at a time from the jobs channel,
 			// and for emitting a corresponding result for the component on the results channel.
-			worker := func(id int, jobs <-chan app.SnapshotComponent, results chan<- result) {
+			worker := func(id int, jobs <-chan job, results chan<- result) {
 				log.Debugf("Starting worker %d", id)
-				for comp := range jobs {
-					ctx := cmd.Context()
+				for j := range jobs {
+					comp := j.component
+					snapshot := data.specs[j.snapshot]
+					// The content fetched by the rego functions is limited for each component,
+					// including the images of its platforms
+					ctx := regooci.WithBudget(cmd.Context())
 					var task *trace.Task
 					if trace.IsEnabled() {
 						ctx, task = trace.NewTask(ctx, "ec:validate-component")
@@ -347,13 +510,17 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 					}
 
 					log.Debugf("Worker %d got a component %q", id, comp.ContainerImage)
-					out, err := validate(ctx, comp, data.spec, data.policy, evaluators, data.info)
+					start := time.Now()
+					out, err := validate(ctx, comp, snapshot.Spec, data.policy, evaluators, data.info)
 					res := result{
 						err: err,
 						component: applicationsnapshot.Component{
 							SnapshotComponent: comp,
+							Snapshot:          snapshot.Name,
 							Success:           err == nil,
+							Duration:          time.Since(start),
 						},
+						snapshot: j.snapshot,
 					}
 
 					// Skip on err to not panic. Error is return on routine completion.
@@ -372,15 +539,32 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 						// For example, the Statement is only needed when the full attestation is printed.
 						for _, att := range out.Attestations {
 							attResult := applicationsnapshot.NewAttestationResult(att)
-							if containsOutput(data.output, "attestation") {
+							if keepStatements {
 								attResult.Statement = att.Statement()
 							}
 							res.component.Attestations = append(res.component.Attestations, attResult)
 						}
 						res.component.ContainerImage = out.ImageURL
+						res.component.RuleInfo = out.RuleInfo
 						res.policyInput = out.PolicyInput
 					}
+
+					if err == nil && data.groupPlatforms {
+						if platforms := indexPlatforms(ctx, comp); len(platforms) > 0 {
+							res.component.Platforms, err = validatePlatforms(ctx, validate, comp, applicationsnapshot.SelectPlatforms(platforms, data.platforms, data.requirePlatforms),
+								snapshot.Spec, data.policy, evaluators, data.info, showSuccesses, keepStatements)
+							res.err = err
+							// Only image indexes are required to provide the platforms
+							if err == nil && len(data.requirePlatforms) > 0 {
+								res.component.Violations = append(res.component.Violations,
+									applicationsnapshot.RequiredPlatformViolations(data.requirePlatforms, res.component.Platforms)...)
+							}
+						}
+					}
 					res.component.Success = err == nil && len(res.component.Violations) == 0
+					for _, p := range res.component.Platforms {
+						res.component.Success = res.component.Success && p.Success
+					}
 
 					if task != nil {
 						task.End()
@@ -390,12 +574,12 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				log.Debugf("Done with worker %d", id)
 			}
 
-			numComponents := len(appComponents)
+			numComponents := len(jobList)
 
 			// Set numWorkers to the value from our flag. The default is 5.
 			numWorkers := data.workers
 
-			jobs := make(chan app.SnapshotComponent, numComponents)
+			jobs := make(chan job, numComponents)
 			results := make(chan result, numComponents)
 			// Initialize each worker. They will wait patiently until a job is sent to the jobs
 			// channel, or the jobs channel is closed.
@@ -404,13 +588,12 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 			}
 			// Initialize all the jobs. Each worker will pick a job from the channel when the worker
 			// is ready to consume a new job.
-			for _, c := range appComponents {
-				jobs <- c
+			for _, j := range jobList {
+				jobs <- j
 			}
 			close(jobs)
 
-			var components []applicationsnapshot.Component
-			var manyPolicyInput [][]byte
+			var validated []result
 			var allErrors error = nil
 			for i := 0; i < numComponents; i++ {
 				r := <-results
@@ -418,8 +601,15 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 					e := fmt.Errorf("error validating image %s of component %s: %w", r.component.ContainerImage, r.component.Name, r.err)
 					allErrors = errors.Join(allErrors, e)
 				} else {
-					components = append(components, r.component)
-					manyPolicyInput = append(manyPolicyInput, r.policyInput)
+					if baseline != nil {
+						baselined := []applicationsnapshot.Component{r.component}
+						applicationsnapshot.ApplyBaseline(baselined, *baseline)
+						r.component = baselined[0]
+					}
+					if err := stream.WriteComponent(r.component); err != nil {
+						allErrors = errors.Join(allErrors, err)
+					}
+					validated = append(validated, r)
 				}
 			}
 			close(results)
@@ -427,25 +617,60 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				return allErrors
 			}
 
-			// Ensure some consistency in output.
-			sort.Slice(components, func(i, j int) bool {
-				return components[i].ContainerImage > components[j].ContainerImage
+			// Ensure some consistency in output. The components are kept in the
+			// order of the snapshots, and the policy input is sorted along with
+			// the components so that they remain aligned.
+			sort.Slice(validated, func(i, j int) bool {
+				if validated[i].snapshot != validated[j].snapshot {
+					return validated[i].snapshot < validated[j].snapshot
+				}
+				return validated[i].component.ContainerImage > validated[j].component.ContainerImage
 			})
 
-			if len(data.outputFile) > 0 {
-				data.output = append(data.output, fmt.Sprintf("%s=%s", applicationsnapshot.JSON, data.outputFile))
+			components := make([]applicationsnapshot.Component, 0, len(validated))
+			manyPolicyInput := make([][]byte, 0, len(validated))
+			for _, r := range validated {
+				components = append(components, r.component)
+				manyPolicyInput = append(manyPolicyInput, r.policyInput)
+			}
+
+			// Several snapshots are listed in the report by name
+			var snapshotRef string
+			if len(data.specs) == 1 {
+				snapshotRef = firstOf(data.snapshots)
 			}
 
-			report, err := applicationsnapshot.NewReport(data.snapshot, components, data.policy, manyPolicyInput, showSuccesses)
+			report, err := applicationsnapshot.NewReport(snapshotRef, components, data.policy, manyPolicyInput, showSuccesses)
 			if err != nil {
 				return err
 			}
-			p := format.NewTargetParser(applicationsnapshot.JSON, format.Options{ShowSuccesses: showSuccesses}, cmd.OutOrStdout(), utils.FS(cmd.Context()))
-			utils.SetColorEnabled(data.noColor, data.forceColor)
-			if err := report.WriteAll(data.output, p); err != nil {
+			report.Skipped = data.skipped
+
+			if err := stream.WriteSummary(report); err != nil {
 				return err
 			}
 
+			for _, dir := range replayDirs {
+				if err := replay.Write(cmd.Context(), dir, report); err != nil {
+					return fmt.Errorf("unable to write the replay bundle to %q: %w", dir, err)
+				}
+			}
+
+			// Without any other outputs the default output would be written
+			if len(outputs) > 0 || len(streamed)+len(replays) == 0 {
+				if err := report.WriteAll(outputs, p); err != nil {
+					return err
+				}
+			}
+
+			// The exclusions are suggested once the report is written so that
+			// the report is available even when they cannot be suggested
+			if data.suggestExclusions != "" {
+				if err := writeExclusions(cmd, report, data.policyConfiguration, data.suggestExclusions, data.suggestExclusionsType, data.suggestExclusionsUntil, data.suggestExclusionsPerImage); err != nil {
+					return err
+				}
+			}
+
 			if data.strict && !report.Success {
 				return errors.New("success criteria not met")
 			}
@@ -492,15 +717,31 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	cmd.Flags().StringVarP(&data.input, "json-input", "j", data.input,
 		"DEPRECATED - use --images: JSON representation of an ApplicationSnapshot Spec")
 
-	cmd.Flags().StringVar(&data.images, "images", data.images,
-		"path to ApplicationSnapshot Spec JSON file or JSON representation of an ApplicationSnapshot Spec")
+	cmd.Flags().StringArrayVar(&data.images, "images", data.images, hd.Doc(`
+		path to ApplicationSnapshot Spec JSON file or JSON representation of an ApplicationSnapshot Spec.
+		May be used multiple times, see --snapshot`))
 
 	cmd.Flags().StringSliceVar(&data.output, "output", data.output, hd.Doc(`
 		write output to a file in a specific format. Use empty string path for stdout.
 		May be used multiple times. Possible formats are:
 		`+strings.Join(validOutputFormats, ", ")+`. In following format and file path
 		additional options can be provided in key=value form following the question
-		mark (?) sign, for example: --output text=output.txt?show-successes=false
+		mark (?) sign, for example: --output text=output.txt?show-successes=false.
+		The options are show-successes, show-warnings, include-signatures,
+		include-attestations and include-rule-info (true or false), severity>=
+		(success, warning or failure), component and rules (globs matching the
+		component name or image, and the rule code) and max-message-length, for
+		example:
+		--output text?severity>=failure&rules=tasks.*&max-message-length=80
+		The template format renders the report using the given Go template, with the
+		output file provided in the file option, for example:
+		--output template=report.tmpl?file=report.txt
+		The ndjson format is written progressively, a line for each component as
+		soon as it is validated followed by a summary line.
+		The replay format writes a bundle to the given directory, holding the policy
+		input of each component, the policy and data sources, the effective time and
+		the resolved policy configuration, for example: --output replay=<dir>. The
+		bundle can be evaluated again with "ec validate replay <dir>".
 	`))
 
 	cmd.Flags().StringVarP(&data.outputFile, "output-file", "o", data.outputFile,
@@ -520,14 +761,21 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 		Extra data to be provided to the Rego policy evaluator. Use format 'key=value'. May be used multiple times.
 	`))
 
-	cmd.Flags().StringVar(&data.snapshot, "snapshot", "", hd.Doc(`
+	cmd.Flags().StringArrayVar(&data.snapshots, "snapshot", data.snapshots, hd.Doc(`
 		Provide the AppStudio Snapshot as a source of the images to validate, as inline
-		JSON of the "spec" or a reference to a Kubernetes object [<namespace>/]<name>`))
+		JSON of the "spec" or a reference to a Kubernetes object [<namespace>/]<name>.
+		May be used multiple times, together with --images, to validate several
+		snapshots at once. The components of each snapshot are then reported along
+		with the name of the snapshot, i.e. the reference, the path of the images
+		file or the application, and the report includes the result of each snapshot.
+		A single --snapshot and --images are validated as one snapshot.`))
 
 	cmd.Flags().BoolVar(&data.info, "info", data.info, hd.Doc(`
 		Include additional information on the failures. For instance for policy
 		violations, include the title and the description of the failed policy
-		rule.`))
+		rule. Without it, the metadata of the results is reduced to the code, the
+		term and the effective on date of the rule, which identify the result for
+		baselines and suggested exclusions.`))
 
 	cmd.Flags().BoolVar(&data.noColor, "no-color", data.info, hd.Doc(`
 		Disable color when using text output even when the current terminal supports it`))
@@ -538,6 +786,71 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	cmd.Flags().IntVar(&data.workers, "workers", data.workers, hd.Doc(`
 		Number of workers to use for validation. Defaults to 5.`))
 
+	cmd.Flags().StringVar(&data.baseline, "baseline", data.baseline, hd.Doc(`
+		Path to a previously saved JSON or YAML report, e.g. one written with
+		--output baseline=<path>. Violations also present in the baseline, matched by
+		component, platform of an image index, rule code and term, are reported as
+		warnings marked as baselined and do not cause the validation to fail.`))
+
+	cmd.Flags().StringVar(&data.suggestExclusions, "suggest-exclusions", data.suggestExclusions, hd.Doc(`
+		Write a patch of the policy configuration to the given path, adding an
+		exclusion to the volatileConfig of each policy source for every violation.
+		The exclusions use the rule code and term, e.g. "<code>:<term>", so only
+		the reported violations are excluded.`))
+
+	cmd.Flags().StringVar(&data.suggestExclusionsType, "suggest-exclusions-type", data.suggestExclusionsType, hd.Doc(`
+		Type of the patch written with --suggest-exclusions. Possible types are:
+		`+strings.Join(applicationsnapshot.ExclusionPatchTypes, ", ")+`. The
+		json-patch appends to the existing exclusions, the merge-patch replaces
+		the sources with the sources of the --policy including the exclusions.`))
+
+	cmd.Flags().StringVar(&data.suggestExclusionsUntil, "suggest-exclusions-until", data.suggestExclusionsUntil, hd.Doc(`
+		Limit the exclusions written with --suggest-exclusions in time. The value
+		can be a RFC3339 formatted value, e.g. 2022-11-18T00:00:00Z, or a duration
+		from now, e.g. 720h.`))
+
+	cmd.Flags().BoolVar(&data.suggestExclusionsPerImage, "suggest-exclusions-per-image", data.suggestExclusionsPerImage, hd.Doc(`
+		Scope the exclusions written with --suggest-exclusions to the digest of the
+		image the violation was reported for. No exclusions are suggested for the
+		images not referenced by digest. Defaults to true.`))
+
+	cmd.Flags().StringSliceVar(&data.components, "component", data.components, hd.Doc(`
+		Validate only the components with a name, or image, matching the given glob,
+		e.g. "spam-*". May be used multiple times. The components not validated are
+		listed as skipped in the report.`))
+
+	cmd.Flags().StringSliceVar(&data.skipComponents, "skip-component", data.skipComponents, hd.Doc(`
+		Do not validate the components with a name, or image, matching the given glob.
+		May be used multiple times. The components not validated are listed as skipped
+		in the report.`))
+
+	cmd.Flags().StringVar(&data.rerunFailed, "rerun-failed", data.rerunFailed, hd.Doc(`
+		Path to a previously saved JSON or YAML report. Only the components that failed
+		in that report, matched by name or the repository of their image, are
+		validated. Can be combined with --component and --skip-component. The
+		validation fails when none of the components are selected.`))
+
+	cmd.Flags().BoolVar(&data.groupPlatforms, "group-platforms", data.groupPlatforms, hd.Doc(`
+		Validate an image index as a single component, along with the image of each
+		of its platforms, instead of adding a component for each of the platform
+		images. The outcome for each platform is reported under the index component,
+		which fails when any of the platform images fails.`))
+
+	cmd.Flags().StringSliceVar(&data.platforms, "platform", data.platforms, hd.Doc(`
+		Validate only the images of the platforms of an image index matching the given
+		glob, e.g. "linux/amd64" or "linux/*". May be used multiple times. Implies
+		--group-platforms.`))
+
+	cmd.Flags().StringSliceVar(&data.requirePlatforms, "require-platform", data.requirePlatforms, hd.Doc(`
+		Require the image index to provide an image for the given platform, e.g.
+		"linux/arm64", with signed attestations. A violation is reported for each of
+		the required platforms that is missing, or whose image has no attestations
+		with a valid signature. The images of the required platforms are validated
+		even when not matching --platform. Images that are not image indexes are not
+		affected. May be used multiple times. Implies --group-platforms. Overrides
+		the platforms required by the policy, listed in the "required_platforms" rule
+		data of its sources.`))
+
 	if len(data.input) > 0 || len(data.filePath) > 0 || len(data.images) > 0 {
 		if err := cmd.MarkFlagRequired("image"); err != nil {
 			panic(err)
@@ -547,6 +860,168 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	return cmd
 }
 
+// selectComponents removes the components that are not to be validated from
+// the snapshots and returns them.
+func selectComponents(ctx context.Context, specs []applicationsnapshot.NamedSnapshot, components, skipComponents []string, rerunFailed string) ([]applicationsnapshot.SkippedComponent, error) {
+	selection := applicationsnapshot.ComponentSelection{
+		Components:     components,
+		SkipComponents: skipComponents,
+	}
+	if err := selection.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rerunFailed != "" {
+		contents, err := afero.ReadFile(utils.FS(ctx), rerunFailed)
+		if err != nil {
+			return nil, err
+		}
+		failed, err := applicationsnapshot.ReadReport(contents)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read report %q: %w", rerunFailed, err)
+		}
+		selection.Failed = &failed
+	}
+
+	var skipped []applicationsnapshot.SkippedComponent
+	numSelected := 0
+	for _, s := range specs {
+		selected, notSelected := selection.Select(s.Name, s.Spec.Components)
+		s.Spec.Components = selected
+		skipped = append(skipped, notSelected...)
+		numSelected += len(selected)
+	}
+
+	// Validating none of the components would be reported as a success
+	if numSelected == 0 && len(skipped) > 0 {
+		return nil, errors.New("none of the components were selected for validation, check the --component, --skip-component and --rerun-failed flags")
+	}
+
+	return skipped, nil
+}
+
+// indexPlatforms returns the platform images of the component when it is an
+// image index, and nothing otherwise. Images that cannot be accessed are
+// reported when they are validated.
+func indexPlatforms(ctx context.Context, comp app.SnapshotComponent) []applicationsnapshot.PlatformImage {
+	platforms, err := applicationsnapshot.IndexPlatforms(ctx, comp)
+	if err != nil {
+		log.Debugf("Unable to determine the platforms of image %s: %v", comp.ContainerImage, err)
+	}
+
+	return platforms
+}
+
+// validatePlatforms validates the image of each of the platforms of an image
+// index component.
+func validatePlatforms(ctx context.Context, validate imageValidationFunc, comp app.SnapshotComponent, platforms []applicationsnapshot.PlatformImage,
+	snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed, showSuccesses, keepStatements bool) ([]applicationsnapshot.PlatformResult, error) {
+	results := make([]applicationsnapshot.PlatformResult, 0, len(platforms))
+	for _, platform := range platforms {
+		platformComp := comp
+		platformComp.ContainerImage = platform.ContainerImage
+		out, err := validate(ctx, platformComp, snap, p, evaluators, detailed)
+		if err != nil {
+			return nil, fmt.Errorf("error validating platform %s: %w", platform.Platform, err)
+		}
+
+		r := applicationsnapshot.PlatformResult{
+			Platform:       platform.Platform,
+			ContainerImage: out.ImageURL,
+			Violations:     out.Violations(),
+			Warnings:       out.Warnings(),
+			Signatures:     out.Signatures,
+		}
+		successes := out.Successes()
+		r.SuccessCount = len(successes)
+		if showSuccesses {
+			r.Successes = successes
+		}
+		for _, att := range out.Attestations {
+			attResult := applicationsnapshot.NewAttestationResult(att)
+			if keepStatements {
+				attResult.Statement = att.Statement()
+			}
+			r.Attestations = append(r.Attestations, attResult)
+		}
+		r.Success = len(r.Violations) == 0
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// writeExclusions writes the patch suggesting the exclusions of the
+// violations in the report to the given path. The patch applies to the policy
+// configuration as provided, not to the resolved policy of the report.
+func writeExclusions(cmd *cobra.Command, report applicationsnapshot.Report, policyConfiguration, path, patchType, until string, perImage bool) error {
+	opts := applicationsnapshot.ExclusionOptions{
+		PatchType: patchType,
+		PerImage:  perImage,
+	}
+
+	effectiveUntil, err := exclusionsUntil(until)
+	if err != nil {
+		return err
+	}
+	opts.EffectiveUntil = effectiveUntil
+
+	p, err := policy.NewInertPolicy(cmd.Context(), policyConfiguration)
+	if err != nil {
+		return err
+	}
+
+	patch, err := applicationsnapshot.SuggestExclusions(report, p.Spec(), opts)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(utils.FS(cmd.Context()), path, append(patch, '\n'), 0644)
+}
+
+// exclusionsUntil parses the value of the --suggest-exclusions-until flag,
+// either a RFC3339 time or a duration from now. The zero time is returned
+// when no value is set.
+func exclusionsUntil(until string) (time.Time, error) {
+	if until == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(until); err == nil {
+		return time.Now().Add(d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid value for --suggest-exclusions-until %q, expected a RFC3339 time or a duration", until)
+}
+
+// splitOutput separates the outputs in the given format from the other
+// outputs.
+func splitOutput(data []string, value string) (matching []string, others []string) {
+	for _, item := range data {
+		formatAndPath, _, _ := strings.Cut(item, "?")
+		if f, _, _ := strings.Cut(formatAndPath, "="); f == value {
+			matching = append(matching, item)
+		} else {
+			others = append(others, item)
+		}
+	}
+	return
+}
+
+// firstOf returns the first of the values, or an empty string if there are
+// none.
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
 // find if the slice contains "value" output
 func containsOutput(data []string, value string) bool {
 	for _, item := range data {
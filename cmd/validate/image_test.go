@@ -24,13 +24,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	hd "github.com/MakeNowJust/heredoc"
 	ociMetadata "github.com/conforma/go-gather/gather/oci"
+	"github.com/gkampitakis/go-snaps/match"
 	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	gcrfake "github.com/google/go-containerregistry/pkg/v1/fake"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
 	log "github.com/sirupsen/logrus"
@@ -38,12 +45,15 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/kubernetes"
 	"github.com/enterprise-contract/ec-cli/internal/output"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/replay"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci/fake"
@@ -335,13 +345,14 @@ func Test_ValidateImageCommand(t *testing.T) {
 			"name": "Unnamed",
 			"containerImage": "registry/image:tag",
 			"source": {},
-			"success": true
+			"success": true,
+			"success-count": 1
 		  }
 		],
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func Test_ValidateImageCommandImages(t *testing.T) {
@@ -412,7 +423,8 @@ func Test_ValidateImageCommandImages(t *testing.T) {
 						"revision": "ded982e702e07bb7b6effafdc353db3fe172c83f"
 					}
 				},
-				"success": true
+				"success": true,
+				"success-count": 1
 			},
 			{
 				"name": "bacon",
@@ -423,13 +435,14 @@ func Test_ValidateImageCommandImages(t *testing.T) {
 						"revision": "8abf15bef376e0e21f1f9e9c3d74483d5018f3d5"
 					}
 				},
-				"success": true
+				"success": true,
+				"success-count": 1
 			}
 		],
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func Test_ValidateImageCommandKeyless(t *testing.T) {
@@ -563,7 +576,7 @@ spec:
 			err = cmd.Execute()
 			assert.NoError(t, err)
 
-			snaps.MatchJSON(t, out.String())
+			snaps.MatchJSON(t, out.String(), match.Any("created"))
 		})
 	}
 }
@@ -978,7 +991,7 @@ func Test_FailureImageAccessibility(t *testing.T) {
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func Test_FailureOutput(t *testing.T) {
@@ -1047,7 +1060,396 @@ func Test_FailureOutput(t *testing.T) {
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
+}
+
+func Test_BaselineOutput(t *testing.T) {
+	validate := func(_ context.Context, component app.SnapshotComponent, _ *app.SnapshotSpec, _ policy.Policy, _ []evaluator.Evaluator, _ bool) (*output.Output, error) {
+		return &output.Output{
+			ImageSignatureCheck: output.VerificationStatus{
+				Passed: false,
+				Result: &evaluator.Result{Message: "failed image signature check", Metadata: map[string]any{"code": "builtin.image.signature_check"}},
+			},
+			ImageAccessibleCheck: output.VerificationStatus{
+				Passed: true,
+			},
+			AttestationSignatureCheck: output.VerificationStatus{
+				Passed: false,
+				Result: &evaluator.Result{Message: "failed attestation signature check", Metadata: map[string]any{"code": "builtin.attestation.signature_check"}},
+			},
+			ImageURL: component.ContainerImage,
+		}, nil
+	}
+
+	cases := []struct {
+		name     string
+		baseline string
+		err      string
+	}{
+		{
+			name: "partial baseline",
+			baseline: `{"components": [{"name": "Unnamed", "containerImage": "registry/image:tag", "violations": [
+				{"msg": "failed image signature check", "metadata": {"code": "builtin.image.signature_check"}}
+			]}]}`,
+			err: "success criteria not met",
+		},
+		{
+			name: "full baseline",
+			baseline: `{"components": [{"name": "Unnamed", "containerImage": "registry/image:tag", "violations": [
+				{"msg": "failed image signature check", "metadata": {"code": "builtin.image.signature_check"}},
+				{"msg": "failed attestation signature check", "metadata": {"code": "builtin.attestation.signature_check"}}
+			]}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validateImageCmd := validateImageCmd(validate)
+			cmd := setUpCobra(validateImageCmd)
+			cmd.SilenceUsage = true
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "baseline.json", []byte(c.baseline), 0400))
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append(rootArgs, []string{
+				"--image",
+				"registry/image:tag",
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+				"--baseline",
+				"baseline.json",
+				"--output",
+				"baseline=new-baseline.json",
+			}...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// The new baseline includes all violations, baselined or not
+			written, err := afero.ReadFile(fs, "new-baseline.json")
+			require.NoError(t, err)
+			baseline, err := applicationsnapshot.ReadReport(written)
+			require.NoError(t, err)
+			require.Len(t, baseline.Components, 1)
+			assert.Len(t, baseline.Components[0].Violations, 2)
+		})
+	}
+}
+
+func Test_SARIFOutput(t *testing.T) {
+	validate := func(_ context.Context, component app.SnapshotComponent, _ *app.SnapshotSpec, _ policy.Policy, _ []evaluator.Evaluator, detailed bool) (*output.Output, error) {
+		out := &output.Output{
+			ImageSignatureCheck:       output.VerificationStatus{Passed: true},
+			ImageAccessibleCheck:      output.VerificationStatus{Passed: true},
+			AttestationSignatureCheck: output.VerificationStatus{Passed: true},
+			ImageURL:                  component.ContainerImage,
+			Detailed:                  detailed,
+		}
+		out.SetPolicyCheck([]evaluator.Outcome{{
+			Failures: []evaluator.Result{{
+				Message: "violation",
+				Metadata: map[string]any{
+					"code":              "a.b",
+					"term":              "c",
+					"title":             "Title",
+					"description":       "Description",
+					"solution":          "Solution",
+					"documentation_url": "https://docs.example.com/a#b",
+				},
+			}},
+		}})
+		return out, nil
+	}
+
+	validateImageCmd := validateImageCmd(validate)
+	cmd := setUpCobra(validateImageCmd)
+	cmd.SilenceUsage = true
+
+	client := fake.FakeClient{}
+	commonMockClient(&client)
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+	ctx = oci.WithClient(ctx, &client)
+	cmd.SetContext(ctx)
+
+	// --info is not set, so the metadata of the results is reduced
+	cmd.SetArgs(append(rootArgs, []string{
+		"--image",
+		"registry/image:tag",
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--output",
+		"sarif=report.sarif",
+	}...))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "success criteria not met")
+
+	written, err := afero.ReadFile(fs, "report.sarif")
+	require.NoError(t, err)
+
+	var sarif struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []map[string]any `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(written, &sarif))
+	require.Len(t, sarif.Runs, 1)
+	assert.Equal(t, []map[string]any{
+		{
+			"id":               "a.b",
+			"shortDescription": map[string]any{"text": "Title"},
+			"fullDescription":  map[string]any{"text": "Description"},
+			"help":             map[string]any{"text": "Solution"},
+			"helpUri":          "https://docs.example.com/a#b",
+		},
+	}, sarif.Runs[0].Tool.Driver.Rules)
+}
+
+func Test_NDJSONOutput(t *testing.T) {
+	images := `{"components": [
+		{"name": "bacon", "containerImage": "registry.localhost/bacon:v2.0"},
+		{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"}
+	]}`
+
+	cases := []struct {
+		name   string
+		output []string
+		file   bool
+	}{
+		{
+			name:   "stdout",
+			output: []string{"--output", "ndjson"},
+		},
+		{
+			name:   "file with another output",
+			output: []string{"--output", "ndjson=report.ndjson", "--output", "summary"},
+			file:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validateImageCmd := validateImageCmd(happyValidator())
+			cmd := setUpCobra(validateImageCmd)
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append([]string{
+				"validate",
+				"image",
+				"--images",
+				images,
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+			}, c.output...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			require.NoError(t, err)
+
+			data := out.Bytes()
+			if c.file {
+				// The other output is written to stdout
+				assert.Contains(t, out.String(), `"components":[`)
+				assert.NotContains(t, out.String(), `"kind"`)
+				data, err = afero.ReadFile(fs, "report.ndjson")
+				require.NoError(t, err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			require.Len(t, lines, 3)
+
+			names := []string{}
+			for _, line := range lines[:2] {
+				var component map[string]any
+				require.NoError(t, json.Unmarshal([]byte(line), &component))
+				assert.Equal(t, "component", component["kind"])
+				names = append(names, component["name"].(string))
+			}
+			// The components are written in the order they are validated in
+			assert.ElementsMatch(t, []string{"bacon", "spam"}, names)
+
+			var summary map[string]any
+			require.NoError(t, json.Unmarshal([]byte(lines[2]), &summary))
+			assert.Equal(t, "summary", summary["kind"])
+			assert.Equal(t, true, summary["success"])
+			assert.Equal(t, float64(2), summary["components"])
+		})
+	}
+}
+
+func Test_SuggestExclusions(t *testing.T) {
+	validate := func(_ context.Context, component app.SnapshotComponent, _ *app.SnapshotSpec, _ policy.Policy, _ []evaluator.Evaluator, _ bool) (*output.Output, error) {
+		return &output.Output{
+			ImageSignatureCheck: output.VerificationStatus{
+				Passed: false,
+				Result: &evaluator.Result{Message: "failed image signature check", Metadata: map[string]any{"code": "builtin.image.signature_check"}},
+			},
+			ImageAccessibleCheck:      output.VerificationStatus{Passed: true},
+			AttestationSignatureCheck: output.VerificationStatus{Passed: true},
+			AttestationSyntaxCheck:    output.VerificationStatus{Passed: true},
+			PolicyCheck: []evaluator.Outcome{
+				{
+					Failures: []evaluator.Result{
+						{Message: "failure", Metadata: map[string]any{"code": "a.b", "term": "c"}},
+					},
+				},
+			},
+			ImageURL: component.ContainerImage,
+		}, nil
+	}
+
+	cases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "not per image",
+			args: []string{
+				"--suggest-exclusions-per-image=false",
+				"--suggest-exclusions-until",
+				"2030-01-01T00:00:00Z",
+			},
+			expected: `[{
+				"op": "add",
+				"path": "/sources/0/volatileConfig",
+				"value": {"exclude": [{"value": "a.b:c", "effectiveUntil": "2030-01-01T00:00:00Z"}]}
+			}]`,
+		},
+		{
+			// The image is not referenced by digest, the exclusions cannot be
+			// scoped to it
+			name:     "per image",
+			expected: `[]`,
+		},
+		{
+			// The patch applies to the --policy, not to the resolved policy
+			// with the pinned sources
+			name: "merge patch",
+			args: []string{
+				"--suggest-exclusions-per-image=false",
+				"--suggest-exclusions-type",
+				"merge-patch",
+			},
+			expected: `{"sources": [{
+				"policy": ["registry/policy:latest"],
+				"volatileConfig": {"exclude": [{"value": "a.b:c"}]}
+			}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validateImageCmd := validateImageCmd(validate)
+			cmd := setUpCobra(validateImageCmd)
+			cmd.SilenceUsage = true
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+
+			mdl := MockDownloader{}
+			mdl.On("Download", mock.Anything, "registry/policy:latest", false).Return(&ociMetadata.OCIMetadata{Digest: "sha256:da54bca5477bf4e3449bc37de1822888fa0fbb8d89c640218cb31b987374d357"}, nil)
+			ctx = context.WithValue(ctx, source.DownloaderFuncKey, &mdl)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append(append(rootArgs, []string{
+				"--image",
+				"registry/image:tag",
+				"--public-key",
+				utils.TestPublicKey,
+				"--policy",
+				`{"sources": [{"policy": ["registry/policy:latest"]}]}`,
+				"--output",
+				"json=report.json",
+				"--suggest-exclusions",
+				"exclusions.json",
+			}...), c.args...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			assert.EqualError(t, err, "success criteria not met")
+
+			report, err := afero.ReadFile(fs, "report.json")
+			require.NoError(t, err)
+			assert.Contains(t, string(report), `"code":"a.b"`)
+
+			patch, err := afero.ReadFile(fs, "exclusions.json")
+			require.NoError(t, err)
+			assert.JSONEq(t, c.expected, string(patch))
+		})
+	}
+}
+
+func Test_SuggestExclusionsUntilInvalid(t *testing.T) {
+	validateImageCmd := validateImageCmd(happyValidator())
+	cmd := setUpCobra(validateImageCmd)
+	cmd.SilenceUsage = true
+
+	client := fake.FakeClient{}
+	commonMockClient(&client)
+	ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+	ctx = oci.WithClient(ctx, &client)
+	cmd.SetContext(ctx)
+
+	cmd.SetArgs(append(rootArgs, []string{
+		"--image",
+		"registry/image:tag",
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--suggest-exclusions",
+		"exclusions.json",
+		"--suggest-exclusions-until",
+		"tomorrow",
+	}...))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, `invalid value for --suggest-exclusions-until "tomorrow"`)
 }
 
 func Test_WarningOutput(t *testing.T) {
@@ -1121,7 +1523,7 @@ func Test_WarningOutput(t *testing.T) {
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func Test_FailureImageAccessibilityNonStrict(t *testing.T) {
@@ -1189,7 +1591,7 @@ func Test_FailureImageAccessibilityNonStrict(t *testing.T) {
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func TestValidateImageCommand_RunE(t *testing.T) {
@@ -1230,13 +1632,14 @@ func TestValidateImageCommand_RunE(t *testing.T) {
 			"name": "Unnamed",
 			"containerImage": "registry/image:tag",
 			"source": {},
-			"success": true
+			"success": true,
+			"success-count": 1
 		  }
 		],
 		"policy": {
 			"publicKey": %s
 		}
-	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), out.String())
+	  }`, effectiveTimeTest, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON), withoutCreated(t, out.String()))
 }
 
 func TestValidateImageDefaultOutput(t *testing.T) {
@@ -1349,3 +1752,641 @@ func TestContainsAttestation(t *testing.T) {
 		assert.Equal(t, test.expected, result, test.name)
 	}
 }
+
+func Test_ReplayOutput(t *testing.T) {
+	images := `{"components": [
+		{"name": "bacon", "containerImage": "registry.localhost/bacon:v2.0"},
+		{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"},
+		{"name": "eggs", "containerImage": "registry.localhost/eggs:v3.0"}
+	]}`
+
+	validator := func(ctx context.Context, component app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool) (*output.Output, error) {
+		out, err := happyValidator()(ctx, component, snap, p, evaluators, detailed)
+		if err != nil {
+			return nil, err
+		}
+		out.PolicyInput = []byte(fmt.Sprintf(`{"image": {"ref": %q}}`, component.ContainerImage))
+		return out, nil
+	}
+
+	cases := []struct {
+		name   string
+		output string
+		err    string
+	}{
+		{name: "bundle", output: "replay=bundle"},
+		{name: "no directory", output: "replay", err: "the replay format requires a path to the bundle directory, e.g. replay=<dir>"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := setUpCobra(validateImageCmd(validator))
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs([]string{
+				"validate",
+				"image",
+				"--images",
+				images,
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+				"--output",
+				c.output,
+			})
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Without any other outputs nothing is written to stdout
+			assert.Empty(t, out.String())
+
+			m, err := replay.Read(ctx, "bundle")
+			require.NoError(t, err)
+			require.Len(t, m.Components, 3)
+
+			// The policy input of each component is stored along with it
+			for _, component := range m.Components {
+				input, err := afero.ReadFile(fs, filepath.Join("bundle", component.Input))
+				require.NoError(t, err)
+				assert.Contains(t, string(input), component.ContainerImage)
+			}
+		})
+	}
+}
+
+func Test_MultipleSnapshots(t *testing.T) {
+	one := `{"application": "one", "components": [
+		{"name": "bacon", "containerImage": "registry.localhost/bacon:v2.0"},
+		{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"}
+	]}`
+	two := `{"application": "two", "components": [
+		{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"}
+	]}`
+
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "snapshots", args: []string{"--images", one, "--images", two}},
+		{
+			name: "with image",
+			args: []string{"--images", one, "--images", two, "--image", "registry.localhost/eggs:v1.0"},
+			err:  "--image, --file-path and --json-input cannot be used with several --snapshot or --images",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := setUpCobra(validateImageCmd(happyValidator()))
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append([]string{
+				"validate",
+				"image",
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+				"--output",
+				"json",
+			}, c.args...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			var report applicationsnapshot.Report
+			require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+
+			assert.True(t, report.Success)
+			assert.Equal(t, []applicationsnapshot.SnapshotResult{
+				{Name: "one", Success: true, Components: []string{"spam", "bacon"}},
+				{Name: "two", Success: true, Components: []string{"spam"}},
+			}, report.Snapshots)
+
+			snapshots := []string{}
+			for _, c := range report.Components {
+				snapshots = append(snapshots, c.Snapshot)
+			}
+			assert.Equal(t, []string{"one", "one", "two"}, snapshots)
+		})
+	}
+}
+
+func Test_ComponentSelection(t *testing.T) {
+	images := `{"components": [
+		{"name": "bacon", "containerImage": "registry.localhost/bacon:v2.0"},
+		{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"},
+		{"name": "spam-extra", "containerImage": "registry.localhost/spam-extra:v1.0"}
+	]}`
+
+	previous := `{"success": false, "components": [
+		{"name": "spam", "containerImage": "registry.localhost/spam:v0.9", "success": false},
+		{"name": "spam-extra", "containerImage": "registry.localhost/spam-extra:v0.9", "success": false},
+		{"name": "bacon", "containerImage": "registry.localhost/bacon:v1.0", "success": true}
+	]}`
+
+	cases := []struct {
+		name      string
+		args      []string
+		validated []string
+		skipped   map[string]string
+		err       string
+	}{
+		{
+			name:      "component",
+			args:      []string{"--component", "spam*"},
+			validated: []string{"spam", "spam-extra"},
+			skipped:   map[string]string{"bacon": applicationsnapshot.NotSelectedReason},
+		},
+		{
+			name:      "skip component",
+			args:      []string{"--skip-component", "spam-*", "--skip-component", "bacon"},
+			validated: []string{"spam"},
+			skipped:   map[string]string{"bacon": applicationsnapshot.SkippedReason, "spam-extra": applicationsnapshot.SkippedReason},
+		},
+		{
+			name:      "rerun failed",
+			args:      []string{"--rerun-failed", "previous.json", "--skip-component", "spam"},
+			validated: []string{"spam-extra"},
+			skipped:   map[string]string{"bacon": applicationsnapshot.NotFailedReason, "spam": applicationsnapshot.SkippedReason},
+		},
+		{
+			name: "invalid glob",
+			args: []string{"--component", "spam["},
+			err:  `invalid component pattern "spam["`,
+		},
+		{
+			name: "nothing selected",
+			args: []string{"--component", "eggs"},
+			err:  "none of the components were selected for validation",
+		},
+		{
+			name: "nothing failed",
+			args: []string{"--rerun-failed", "previous.json", "--skip-component", "spam*"},
+			err:  "none of the components were selected for validation",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validated := []string{}
+			var mu sync.Mutex
+			validator := func(ctx context.Context, component app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool) (*output.Output, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				validated = append(validated, component.Name)
+				// The snapshot holds only the selected components
+				assert.Len(t, snap.Components, len(c.validated))
+				return happyValidator()(ctx, component, snap, p, evaluators, detailed)
+			}
+
+			cmd := setUpCobra(validateImageCmd(validator))
+
+			client := fake.FakeClient{}
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "previous.json", []byte(previous), 0400))
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append([]string{
+				"validate",
+				"image",
+				"--images",
+				images,
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+				"--output",
+				"json",
+			}, c.args...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, c.validated, validated)
+
+			var report applicationsnapshot.Report
+			require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+
+			names := []string{}
+			for _, component := range report.Components {
+				names = append(names, component.Name)
+			}
+			assert.Equal(t, c.validated, names)
+
+			skipped := map[string]string{}
+			for _, s := range report.Skipped {
+				skipped[s.Name] = s.Reason
+			}
+			assert.Equal(t, c.skipped, skipped)
+		})
+	}
+}
+
+func Test_GroupPlatforms(t *testing.T) {
+	const indexImage = "registry.localhost/spam:v1.0"
+	amd64Image := "registry.localhost/spam@sha256:" + strings.Repeat("a", 64)
+	arm64Image := "registry.localhost/spam@sha256:" + strings.Repeat("b", 64)
+
+	cases := []struct {
+		name       string
+		args       []string
+		policy     string
+		validated  []string
+		platforms  []string
+		violations []string
+		success    bool
+	}{
+		{
+			name:      "all platforms",
+			args:      []string{"--group-platforms"},
+			validated: []string{indexImage, amd64Image, arm64Image},
+			platforms: []string{"linux/amd64", "linux/arm64"},
+			success:   false,
+		},
+		{
+			name:      "platform filter",
+			args:      []string{"--platform", "*/amd64"},
+			validated: []string{indexImage, amd64Image},
+			platforms: []string{"linux/amd64"},
+			success:   true,
+		},
+		{
+			name:       "required platforms",
+			args:       []string{"--platform", "*/amd64", "--require-platform", "linux/amd64", "--require-platform", "linux/s390x"},
+			validated:  []string{indexImage, amd64Image},
+			platforms:  []string{"linux/amd64"},
+			violations: []string{"The image of the required platform linux/amd64 has no signed attestations", "The image index provides no image for the required platform linux/s390x"},
+			success:    false,
+		},
+		{
+			name:       "required platform not matching the filter",
+			args:       []string{"--platform", "*/amd64", "--require-platform", "linux/arm64"},
+			validated:  []string{indexImage, amd64Image, arm64Image},
+			platforms:  []string{"linux/amd64", "linux/arm64"},
+			violations: []string{"The image of the required platform linux/arm64 has no signed attestations"},
+			success:    false,
+		},
+		{
+			name:       "required platforms of the policy",
+			policy:     `"sources": [{"ruleData": {"required_platforms": ["linux/s390x"]}}]`,
+			validated:  []string{indexImage, amd64Image, arm64Image},
+			platforms:  []string{"linux/amd64", "linux/arm64"},
+			violations: []string{"The image index provides no image for the required platform linux/s390x"},
+			success:    false,
+		},
+		{
+			name:       "required platforms of the policy overridden",
+			policy:     `"sources": [{"ruleData": {"required_platforms": ["linux/s390x"]}}]`,
+			args:       []string{"--require-platform", "linux/amd64"},
+			validated:  []string{indexImage, amd64Image, arm64Image},
+			platforms:  []string{"linux/amd64", "linux/arm64"},
+			violations: []string{"The image of the required platform linux/amd64 has no signed attestations"},
+			success:    false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			policyConfiguration := fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON)
+			if c.policy != "" {
+				policyConfiguration = fmt.Sprintf(`{"publicKey": %s, %s}`, utils.TestPublicKeyJSON, c.policy)
+			}
+
+			validated := []string{}
+			validator := func(ctx context.Context, component app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool) (*output.Output, error) {
+				validated = append(validated, component.ContainerImage)
+				out, err := happyValidator()(ctx, component, snap, p, evaluators, detailed)
+				if component.ContainerImage == arm64Image {
+					out.PolicyCheck[0].Failures = []evaluator.Result{{Message: "Failure!", Metadata: map[string]any{"code": "policy.arm"}}}
+				}
+				return out, err
+			}
+
+			cmd := setUpCobra(validateImageCmd(validator))
+
+			index := gcrfake.FakeImageIndex{}
+			index.IndexManifestReturns(&v1.IndexManifest{
+				Manifests: []v1.Descriptor{
+					{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)}},
+					{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("b", 64)}},
+				},
+			}, nil)
+			client := fake.FakeClient{}
+			indexRef := name.MustParseReference(indexImage)
+			client.On("Head", indexRef).Return(&v1.Descriptor{MediaType: types.OCIImageIndex}, nil)
+			client.On("Index", indexRef).Return(&index, nil)
+			commonMockClient(&client)
+			ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs(append([]string{
+				"validate",
+				"image",
+				"--image",
+				indexImage,
+				"--policy",
+				policyConfiguration,
+				"--output",
+				"json",
+				"--strict=false",
+				"--workers",
+				"1",
+			}, c.args...))
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, c.validated, validated)
+
+			var report applicationsnapshot.Report
+			require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+
+			// The image index is reported as a single component
+			require.Len(t, report.Components, 1)
+			component := report.Components[0]
+			assert.Equal(t, indexImage, component.ContainerImage)
+			assert.Equal(t, c.success, component.Success)
+			assert.Equal(t, c.success, report.Success)
+
+			platforms := []string{}
+			for _, p := range component.Platforms {
+				platforms = append(platforms, p.Platform)
+				assert.Equal(t, p.ContainerImage != arm64Image, p.Success)
+			}
+			assert.Equal(t, c.platforms, platforms)
+
+			violations := []string{}
+			for _, v := range component.Violations {
+				violations = append(violations, v.Message)
+			}
+			assert.ElementsMatch(t, c.violations, violations)
+		})
+	}
+}
+
+func Test_RequirePlatformsMixedComponents(t *testing.T) {
+	const indexImage = "registry.localhost/spam:v1.0"
+	const singleImage = "registry.localhost/bacon:v2.0"
+
+	cmd := setUpCobra(validateImageCmd(happyValidator()))
+
+	index := gcrfake.FakeImageIndex{}
+	index.IndexManifestReturns(&v1.IndexManifest{
+		Manifests: []v1.Descriptor{
+			{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)}},
+		},
+	}, nil)
+	client := fake.FakeClient{}
+	indexRef := name.MustParseReference(indexImage)
+	client.On("Head", indexRef).Return(&v1.Descriptor{MediaType: types.OCIImageIndex}, nil)
+	client.On("Index", indexRef).Return(&index, nil)
+	commonMockClient(&client)
+	ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+	ctx = oci.WithClient(ctx, &client)
+	cmd.SetContext(ctx)
+
+	cmd.SetArgs([]string{
+		"validate",
+		"image",
+		"--images",
+		fmt.Sprintf(`{"components": [{"name": "spam", "containerImage": %q}, {"name": "bacon", "containerImage": %q}]}`, indexImage, singleImage),
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--output",
+		"json",
+		"--strict=false",
+		"--require-platform",
+		"linux/s390x",
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	require.NoError(t, cmd.Execute())
+
+	var report applicationsnapshot.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Components, 2)
+
+	components := map[string]applicationsnapshot.Component{}
+	for _, c := range report.Components {
+		components[c.Name] = c
+	}
+
+	// The required platforms apply only to the image index
+	spam := components["spam"]
+	assert.False(t, spam.Success)
+	require.Len(t, spam.Violations, 1)
+	assert.Equal(t, "The image index provides no image for the required platform linux/s390x", spam.Violations[0].Message)
+
+	bacon := components["bacon"]
+	assert.True(t, bacon.Success)
+	assert.Empty(t, bacon.Violations)
+	assert.Empty(t, bacon.Platforms)
+}
+
+func Test_GroupPlatformsBaseline(t *testing.T) {
+	const indexImage = "registry.localhost/spam:v1.0"
+	amd64Image := "registry.localhost/spam@sha256:" + strings.Repeat("a", 64)
+	arm64Image := "registry.localhost/spam@sha256:" + strings.Repeat("b", 64)
+
+	cases := []struct {
+		name     string
+		baseline string
+		success  bool
+	}{
+		{
+			name:     "platform violation not in baseline",
+			baseline: fmt.Sprintf(`{"components": [{"name": "Unnamed", "containerImage": %q, "success": true}]}`, indexImage),
+			success:  false,
+		},
+		{
+			name: "platform violation in baseline",
+			baseline: fmt.Sprintf(`{"components": [{"name": "Unnamed", "containerImage": %q, "success": false, "platforms": [
+				{"platform": "linux/arm64", "containerImage": %q, "violations": [{"msg": "Failure!", "metadata": {"code": "policy.arm"}}], "success": false}
+			]}]}`, indexImage, arm64Image),
+			success: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validator := func(ctx context.Context, component app.SnapshotComponent, snap *app.SnapshotSpec, p policy.Policy, evaluators []evaluator.Evaluator, detailed bool) (*output.Output, error) {
+				out, err := happyValidator()(ctx, component, snap, p, evaluators, detailed)
+				if component.ContainerImage == arm64Image {
+					out.PolicyCheck[0].Failures = []evaluator.Result{{Message: "Failure!", Metadata: map[string]any{"code": "policy.arm"}}}
+				}
+				return out, err
+			}
+
+			cmd := setUpCobra(validateImageCmd(validator))
+
+			index := gcrfake.FakeImageIndex{}
+			index.IndexManifestReturns(&v1.IndexManifest{
+				Manifests: []v1.Descriptor{
+					{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)}},
+					{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("b", 64)}},
+				},
+			}, nil)
+			client := fake.FakeClient{}
+			indexRef := name.MustParseReference(indexImage)
+			client.On("Head", indexRef).Return(&v1.Descriptor{MediaType: types.OCIImageIndex}, nil)
+			client.On("Index", indexRef).Return(&index, nil)
+			commonMockClient(&client)
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "baseline.json", []byte(c.baseline), 0400))
+			ctx := utils.WithFS(context.Background(), fs)
+			ctx = oci.WithClient(ctx, &client)
+			cmd.SetContext(ctx)
+
+			cmd.SetArgs([]string{
+				"validate",
+				"image",
+				"--image",
+				indexImage,
+				"--policy",
+				fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+				"--output",
+				"json",
+				"--strict=false",
+				"--group-platforms",
+				"--baseline",
+				"baseline.json",
+			})
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			utils.SetTestRekorPublicKey(t)
+
+			require.NoError(t, cmd.Execute())
+
+			var report applicationsnapshot.Report
+			require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+			require.Len(t, report.Components, 1)
+			assert.Equal(t, c.success, report.Components[0].Success)
+			assert.Equal(t, c.success, report.Success)
+
+			for _, p := range report.Components[0].Platforms {
+				if p.ContainerImage == amd64Image {
+					assert.True(t, p.Success)
+				} else {
+					assert.Equal(t, c.success, p.Success)
+				}
+			}
+		})
+	}
+}
+
+// withoutCreated removes the creation time from the JSON report, after
+// checking it is present, as it differs in each run
+func withoutCreated(t *testing.T, report string) string {
+	var r map[string]any
+	require.NoError(t, json.Unmarshal([]byte(report), &r))
+
+	created, ok := r["created"].(string)
+	require.True(t, ok, "report has no creation time")
+	_, err := time.Parse(time.RFC3339Nano, created)
+	require.NoError(t, err)
+	delete(r, "created")
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func Test_SnapshotWithImages(t *testing.T) {
+	cmd := setUpCobra(validateImageCmd(happyValidator()))
+
+	client := fake.FakeClient{}
+	commonMockClient(&client)
+	ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+	ctx = oci.WithClient(ctx, &client)
+	ctx = kubernetes.WithClient(ctx, &policy.FakeKubernetesClient{
+		Snapshot: app.SnapshotSpec{
+			Application: "one",
+			Components:  []app.SnapshotComponent{{Name: "bacon", ContainerImage: "registry.localhost/bacon:v2.0"}},
+		},
+	})
+	cmd.SetContext(ctx)
+
+	cmd.SetArgs([]string{
+		"validate",
+		"image",
+		"--snapshot",
+		"my-namespace/one",
+		"--images",
+		`{"components": [{"name": "spam", "containerImage": "registry.localhost/spam:v1.0"}]}`,
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s}`, utils.TestPublicKeyJSON),
+		"--output",
+		"json",
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	require.NoError(t, cmd.Execute())
+
+	var report applicationsnapshot.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+
+	// The images are validated as part of the one snapshot
+	assert.Equal(t, "my-namespace/one", report.Snapshot)
+	assert.Empty(t, report.Snapshots)
+
+	names := []string{}
+	for _, c := range report.Components {
+		assert.Empty(t, c.Snapshot)
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"bacon", "spam"}, names)
+}
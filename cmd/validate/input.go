@@ -26,6 +26,7 @@ import (
 
 	hd "github.com/MakeNowJust/heredoc"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
 	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
@@ -41,6 +42,7 @@ type InputValidationFunc func(context.Context, string, policy.Policy, bool) (*ou
 
 func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 	data := struct {
+		baseline            string
 		effectiveTime       string
 		filePaths           []string
 		info                bool
@@ -202,12 +204,26 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 				return inputs[i].FilePath > inputs[j].FilePath
 			})
 
+			if data.baseline != "" {
+				contents, err := afero.ReadFile(utils.FS(cmd.Context()), data.baseline)
+				if err != nil {
+					return err
+				}
+				baseline, err := input.ReadReport(contents)
+				if err != nil {
+					return fmt.Errorf("unable to read baseline %q: %w", data.baseline, err)
+				}
+				input.ApplyBaseline(inputs, baseline)
+			}
+
 			report, err := input.NewReport(inputs, data.policy, manyPolicyInput)
 			if err != nil {
 				return err
 			}
 
-			p := format.NewTargetParser(input.JSON, format.Options{ShowSuccesses: showSuccesses}, cmd.OutOrStdout(), utils.FS(cmd.Context()))
+			opts := format.DefaultOptions()
+			opts.ShowSuccesses = showSuccesses
+			p := format.NewTargetParser(input.JSON, opts, cmd.OutOrStdout(), utils.FS(cmd.Context()))
 			if err := report.WriteAll(data.output, p); err != nil {
 				return err
 			}
@@ -234,7 +250,14 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 		path for stdout, e.g. yaml. May be used multiple times. Possible formats are:
 		`+strings.Join(validOutputFormats, ", ")+`. In following format and file path
 		additional options can be provided in key=value form following the question
-		mark (?) sign, for example: --output text=output.txt?show-successes=false
+		mark (?) sign, for example: --output text=output.txt?show-successes=false.
+		The options are show-successes and show-warnings (true or false), severity>=
+		(success, warning or failure), component and rules (globs matching the file
+		path and the rule code) and max-message-length, for example:
+		--output yaml?severity>=failure&max-message-length=80
+		The template format renders the report using the given Go template, with the
+		output file provided in the file option, for example:
+		--output template=report.tmpl?file=report.txt
 	`))
 
 	cmd.Flags().BoolVarP(&data.strict, "strict", "s", data.strict,
@@ -248,11 +271,19 @@ func validateInputCmd(validate InputValidationFunc) *cobra.Command {
 	cmd.Flags().BoolVar(&data.info, "info", data.info, hd.Doc(`
 		Include additional information on the failures. For instance for policy
 		violations, include the title and the description of the failed policy
-		rule.`))
+		rule. Without it, the metadata of the results is reduced to the code, the
+		term and the effective on date of the rule, which identify the result for
+		baselines and suggested exclusions.`))
 
 	cmd.Flags().IntVar(&data.workers, "workers", data.workers, hd.Doc(`
 		Number of workers to use for validation. Defaults to 5.`))
 
+	cmd.Flags().StringVar(&data.baseline, "baseline", data.baseline, hd.Doc(`
+		Path to a previously saved JSON or YAML report, e.g. one written with
+		--output baseline=<path>. Violations also present in the baseline, matched by
+		file path, rule code and term, are reported as warnings marked as baselined
+		and do not cause the validation to fail.`))
+
 	if err := cmd.MarkFlagRequired("file"); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,121 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/replay"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+type replayFunc func(context.Context, string) (applicationsnapshot.ReportDiff, error)
+
+func validateReplayCmd(replayBundle replayFunc) *cobra.Command {
+	data := struct {
+		output     []string
+		strict     bool
+		noColor    bool
+		forceColor bool
+	}{
+		strict: true,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "replay <dir>",
+		Short: "Evaluate a replay bundle again and confirm the outcome matches",
+
+		Long: hd.Doc(`
+			Evaluate a replay bundle again and confirm the outcome matches
+
+			The bundle must have been written by the "ec validate image" command using
+			the replay output format, e.g. --output replay=<dir>. It holds the policy
+			input of each component, the policy and data sources, the effective time
+			and the resolved policy configuration used for the validation.
+
+			The policy inputs are evaluated against the policy and data sources from
+			the bundle, using the recorded effective time. The network is not
+			accessed while replaying: functions that need it, e.g. ec.oci.blob, fail
+			as if the registry was not available, so the rules using them may not
+			reproduce the recorded outcome.
+
+			The violations and warnings of the policy rules are compared with the
+			recorded ones. The results of the built-in checks, e.g. of the image
+			signature, are not part of the comparison. The differences are listed
+			for each component, and a non-zero status is returned if there are any,
+			unless --strict=false is used.
+		`),
+
+		Example: hd.Doc(`
+			Write a replay bundle while validating the images:
+
+			  ec validate image --images my-app.yaml --output replay=bundle/
+
+			Evaluate the bundle again:
+
+			  ec validate replay bundle/
+
+			Write the differences in JSON format to a file:
+
+			  ec validate replay bundle/ --output json=differences.json
+		`),
+
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diff, err := replayBundle(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			utils.SetColorEnabled(data.noColor, data.forceColor)
+			p := format.NewTargetParser(applicationsnapshot.DiffText, format.DefaultOptions(), cmd.OutOrStdout(), utils.FS(cmd.Context()))
+			if err := diff.WriteAll(data.output, p); err != nil {
+				return err
+			}
+
+			if data.strict && !replay.Matches(diff) {
+				return errors.New("the replayed outcome does not match the recorded outcome")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&data.output, "output", "o", data.output, hd.Doc(`
+		write output to a file in a specific format. Use empty string path for stdout.
+		May be used multiple times. Possible formats are:
+		`+strings.Join(applicationsnapshot.DiffOutputFormats, ", ")+`.
+	`))
+
+	cmd.Flags().BoolVarP(&data.strict, "strict", "s", data.strict,
+		"Return non-zero status when the outcome does not match. Defaults to true. Use --strict=false to return a zero status code.")
+
+	cmd.Flags().BoolVar(&data.noColor, "no-color", data.noColor, hd.Doc(`
+		Disable color when using text output even when the current terminal supports it`))
+
+	cmd.Flags().BoolVar(&data.forceColor, "color", data.forceColor, hd.Doc(`
+		Enable color when using text output even when the current terminal does not support it`))
+
+	return cmd
+}
@@ -0,0 +1,89 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func Test_ValidateReplayCmd(t *testing.T) {
+	matching := applicationsnapshot.ReportDiff{
+		Components: []applicationsnapshot.ComponentDiff{{Name: "spam", Status: applicationsnapshot.ComponentUnchanged}},
+	}
+	differing := applicationsnapshot.ReportDiff{
+		Components: []applicationsnapshot.ComponentDiff{
+			{
+				Name:   "spam",
+				Status: applicationsnapshot.ComponentChanged,
+				Violations: applicationsnapshot.ResultsDiff{
+					New: []applicationsnapshot.ResultChange{{Code: "a.b", Message: "Failure!"}},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name string
+		diff applicationsnapshot.ReportDiff
+		args []string
+		err  string
+	}{
+		{name: "matching", diff: matching},
+		{name: "differing", diff: differing, err: "the replayed outcome does not match the recorded outcome"},
+		{name: "differing not strict", diff: differing, args: []string{"--strict=false"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var dir string
+			replayBundle := func(_ context.Context, d string) (applicationsnapshot.ReportDiff, error) {
+				dir = d
+				return c.diff, nil
+			}
+
+			fs := afero.NewMemMapFs()
+			cmd := setUpCobra(validateReplayCmd(replayBundle))
+			cmd.SetContext(utils.WithFS(context.Background(), fs))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"validate", "replay", "bundle", "--output", "json=diff.json"}, c.args...))
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "bundle", dir)
+
+			out, err := afero.ReadFile(fs, "diff.json")
+			require.NoError(t, err)
+			assert.Contains(t, string(out), `"name":"spam"`)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/enterprise-contract/ec-cli/internal/input"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	_ "github.com/enterprise-contract/ec-cli/internal/rego"
+	"github.com/enterprise-contract/ec-cli/internal/replay"
 )
 
 var ValidateCmd *cobra.Command
@@ -35,6 +36,7 @@ func init() {
 	ValidateCmd.AddCommand(validateImageCmd(image.ValidateImage))
 	ValidateCmd.AddCommand(validateInputCmd(input.ValidateInput))
 	ValidateCmd.AddCommand(ValidatePolicyCmd(policy.ValidatePolicy))
+	ValidateCmd.AddCommand(validateReplayCmd(replay.Replay))
 }
 
 func NewValidateCmd() *cobra.Command {
@@ -0,0 +1,90 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+// ApplyBaseline downgrades the violations of the components that are also
+// present in the baseline report to warnings marked as baselined. Components
+// are matched by name, or the repository of their image, platforms of image
+// indexes by their name, and violations by the code and term of the rule. The
+// success of the components is updated to reflect only the remaining
+// violations, of the component and of its platforms.
+func ApplyBaseline(components []Component, baseline Report) {
+	known := map[string]Component{}
+	for _, c := range baseline.Components {
+		known[componentKey(c)] = c
+	}
+
+	for i := range components {
+		c := &components[i]
+		b, ok := known[componentKey(*c)]
+		if !ok {
+			continue
+		}
+
+		c.Violations, c.Warnings = evaluator.NewBaseline(b.Violations, b.Warnings).Apply(c.Violations, c.Warnings)
+		c.Success = len(c.Violations) == 0
+
+		platforms := map[string]PlatformResult{}
+		for _, p := range b.Platforms {
+			platforms[p.Platform] = p
+		}
+
+		for j := range c.Platforms {
+			p := &c.Platforms[j]
+			if bp, ok := platforms[p.Platform]; ok {
+				p.Violations, p.Warnings = evaluator.NewBaseline(bp.Violations, bp.Warnings).Apply(p.Violations, p.Warnings)
+				p.Success = len(p.Violations) == 0
+			}
+			c.Success = c.Success && p.Success
+		}
+	}
+}
+
+// toBaseline returns a version of the report holding only the data needed for
+// it to be used as a baseline: the components, and their platforms, with their
+// violations, including the ones already baselined.
+func (r *Report) toBaseline() Report {
+	baseline := Report{
+		Success:       r.Success,
+		EcVersion:     r.EcVersion,
+		EffectiveTime: r.EffectiveTime,
+		Components:    make([]Component, 0, len(r.Components)),
+	}
+
+	for _, c := range r.Components {
+		b := Component{
+			SnapshotComponent: c.SnapshotComponent,
+			Success:           c.Success,
+			Violations:        evaluator.BaselineViolations(c.Violations, c.Warnings),
+		}
+		for _, p := range c.Platforms {
+			b.Platforms = append(b.Platforms, PlatformResult{
+				Platform:       p.Platform,
+				ContainerImage: p.ContainerImage,
+				Success:        p.Success,
+				Violations:     evaluator.BaselineViolations(p.Violations, p.Warnings),
+			})
+		}
+		baseline.Components = append(baseline.Components, b)
+	}
+
+	return baseline
+}
@@ -0,0 +1,198 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"testing"
+
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func TestApplyBaseline(t *testing.T) {
+	baseline := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:1"},
+				Violations:        []evaluator.Result{result("a.b", "t1", "old message")},
+				Warnings: []evaluator.Result{
+					evaluator.MarkBaselined(result("a.c", "", "baselined before")),
+					result("w.x", "", "just a warning"),
+				},
+			},
+		},
+	}
+
+	components := []Component{
+		{
+			// rebuilt image of the same component
+			SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:2"},
+			Violations: []evaluator.Result{
+				result("a.b", "t1", "new message"),
+				result("a.b", "t2", "different term"),
+				result("a.c", "", "baselined before"),
+				result("w.x", "", "was a warning"),
+			},
+		},
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two@sha256:1"},
+			Violations:        []evaluator.Result{result("a.b", "t1", "not in baseline")},
+		},
+	}
+
+	ApplyBaseline(components, baseline)
+
+	assert.Equal(t, []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:2"},
+			Violations: []evaluator.Result{
+				result("a.b", "t2", "different term"),
+				result("w.x", "", "was a warning"),
+			},
+			Warnings: []evaluator.Result{
+				evaluator.MarkBaselined(result("a.b", "t1", "new message")),
+				evaluator.MarkBaselined(result("a.c", "", "baselined before")),
+			},
+			Success: false,
+		},
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two@sha256:1"},
+			Violations:        []evaluator.Result{result("a.b", "t1", "not in baseline")},
+		},
+	}, components)
+}
+
+func TestApplyBaselineSuccess(t *testing.T) {
+	baseline := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/one:tag"},
+				Violations:        []evaluator.Result{result("a.b", "", "violation")},
+			},
+		},
+	}
+
+	components := []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/one@sha256:c3a4b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1"},
+			Violations:        []evaluator.Result{{Message: "violation", Metadata: map[string]any{"code": "a.b"}}},
+		},
+	}
+
+	ApplyBaseline(components, baseline)
+
+	assert.True(t, components[0].Success)
+	assert.Empty(t, components[0].Violations)
+	assert.Equal(t, []evaluator.Result{evaluator.MarkBaselined(result("a.b", "", "violation"))}, components[0].Warnings)
+}
+
+func TestApplyBaselinePlatforms(t *testing.T) {
+	baseline := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:1"},
+				Violations:        []evaluator.Result{result("a.b", "", "violation")},
+				Platforms: []PlatformResult{
+					{Platform: "linux/amd64", Violations: []evaluator.Result{result("p.a", "", "amd64")}},
+				},
+			},
+		},
+	}
+
+	components := []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:2"},
+			Violations:        []evaluator.Result{result("a.b", "", "violation")},
+			Platforms: []PlatformResult{
+				{Platform: "linux/amd64", Violations: []evaluator.Result{result("p.a", "", "amd64")}},
+				{Platform: "linux/arm64", Violations: []evaluator.Result{result("p.a", "", "arm64")}},
+			},
+		},
+	}
+
+	ApplyBaseline(components, baseline)
+
+	// The violation of the platform not in the baseline remains
+	assert.False(t, components[0].Success)
+	assert.Empty(t, components[0].Violations)
+	assert.Equal(t, []PlatformResult{
+		{
+			Platform:   "linux/amd64",
+			Success:    true,
+			Violations: []evaluator.Result{},
+			Warnings:   []evaluator.Result{evaluator.MarkBaselined(result("p.a", "", "amd64"))},
+		},
+		{Platform: "linux/arm64", Violations: []evaluator.Result{result("p.a", "", "arm64")}},
+	}, components[0].Platforms)
+
+	components[0].Platforms = components[0].Platforms[:1]
+	ApplyBaseline(components, baseline)
+	assert.True(t, components[0].Success)
+}
+
+func TestToBaseline(t *testing.T) {
+	r := Report{
+		Success:   true,
+		EcVersion: "v1.2.3",
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:1"},
+				Violations:        []evaluator.Result{result("a.b", "", "violation")},
+				Warnings: []evaluator.Result{
+					evaluator.MarkBaselined(result("a.c", "", "baselined")),
+					result("w.x", "", "warning"),
+				},
+				Successes: []evaluator.Result{result("s.s", "", "Pass")},
+				Platforms: []PlatformResult{
+					{
+						Platform:       "linux/amd64",
+						ContainerImage: "registry.io/one@sha256:2",
+						Warnings:       []evaluator.Result{evaluator.MarkBaselined(result("p.a", "", "baselined")), result("w.x", "", "warning")},
+						Success:        true,
+						SuccessCount:   3,
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, Report{
+		Success:   true,
+		EcVersion: "v1.2.3",
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:1"},
+				Violations: []evaluator.Result{
+					result("a.b", "", "violation"),
+					evaluator.MarkBaselined(result("a.c", "", "baselined")),
+				},
+				Platforms: []PlatformResult{
+					{
+						Platform:       "linux/amd64",
+						ContainerImage: "registry.io/one@sha256:2",
+						Violations:     []evaluator.Result{evaluator.MarkBaselined(result("p.a", "", "baselined"))},
+						Success:        true,
+					},
+				},
+			},
+		},
+	}, r.toBaseline())
+}
@@ -0,0 +1,328 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// Possible formats the report difference can be written as.
+const (
+	DiffText     = "text"
+	DiffJSON     = "json"
+	DiffMarkdown = "markdown"
+)
+
+var DiffOutputFormats = []string{
+	DiffText,
+	DiffJSON,
+	DiffMarkdown,
+}
+
+// Possible statuses of a component in the report difference.
+const (
+	ComponentAdded     = "added"
+	ComponentRemoved   = "removed"
+	ComponentChanged   = "changed"
+	ComponentUnchanged = "unchanged"
+)
+
+// ResultChange describes a result that was added, resolved or changed
+// between two reports. The platform is set for the results of the platforms
+// of image index components.
+type ResultChange struct {
+	Code       string `json:"code"`
+	Term       string `json:"term,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Message    string `json:"msg"`
+	OldMessage string `json:"old_msg,omitempty"`
+}
+
+// ResultsDiff holds the differences between two sets of results of the same
+// kind, e.g. violations.
+type ResultsDiff struct {
+	New      []ResultChange `json:"new,omitempty"`
+	Resolved []ResultChange `json:"resolved,omitempty"`
+	Changed  []ResultChange `json:"changed,omitempty"`
+}
+
+// ComponentDiff holds the differences of a component between two reports.
+type ComponentDiff struct {
+	Name       string      `json:"name"`
+	OldImage   string      `json:"old_image,omitempty"`
+	NewImage   string      `json:"new_image,omitempty"`
+	Status     string      `json:"status"`
+	OldSuccess *bool       `json:"old_success,omitempty"`
+	NewSuccess *bool       `json:"new_success,omitempty"`
+	Violations ResultsDiff `json:"violations"`
+	Warnings   ResultsDiff `json:"warnings"`
+	Successes  ResultsDiff `json:"successes"`
+}
+
+// ReportDiff holds the differences between two reports.
+type ReportDiff struct {
+	OldSuccess bool            `json:"old_success"`
+	NewSuccess bool            `json:"new_success"`
+	Worse      bool            `json:"worse"`
+	Components []ComponentDiff `json:"components"`
+}
+
+// componentKey identifies the component across reports. The name is used
+// when available, otherwise the repository of the image, so that a rebuilt
+// image is still matched with its previous version. Components of named
+// snapshots are prefixed with the name of the snapshot.
+func componentKey(c Component) string {
+	key := c.ContainerImage
+	if c.Name != "" {
+		key = c.Name
+	} else if ref, err := name.ParseReference(c.ContainerImage); err == nil {
+		key = ref.Context().Name()
+	}
+
+	if c.Snapshot != "" {
+		return c.Snapshot + "/" + key
+	}
+
+	return key
+}
+
+// Diff computes the semantic difference between the old and the new report.
+// Components are matched by their name, or image repository, and results by
+// the code and the term of the rule that produced them, and the platform they
+// were reported for.
+func Diff(old, new Report) ReportDiff {
+	diff := ReportDiff{
+		OldSuccess: old.Success,
+		NewSuccess: new.Success,
+		Worse:      old.Success && !new.Success,
+		Components: []ComponentDiff{},
+	}
+
+	olds := map[string]Component{}
+	for _, c := range old.Components {
+		olds[componentKey(c)] = withPlatformResults(c)
+	}
+
+	seen := map[string]bool{}
+	for _, n := range new.Components {
+		n = withPlatformResults(n)
+		key := componentKey(n)
+		seen[key] = true
+		newSuccess := n.Success
+
+		o, found := olds[key]
+		if !found {
+			cd := ComponentDiff{
+				Name:       key,
+				NewImage:   n.ContainerImage,
+				Status:     ComponentAdded,
+				NewSuccess: &newSuccess,
+				Violations: diffResults(nil, n.Violations),
+				Warnings:   diffResults(nil, n.Warnings),
+				Successes:  diffResults(nil, n.Successes),
+			}
+			diff.Worse = diff.Worse || len(cd.Violations.New) > 0
+			diff.Components = append(diff.Components, cd)
+			continue
+		}
+
+		oldSuccess := o.Success
+		cd := ComponentDiff{
+			Name:       key,
+			OldImage:   o.ContainerImage,
+			NewImage:   n.ContainerImage,
+			OldSuccess: &oldSuccess,
+			NewSuccess: &newSuccess,
+			Violations: diffResults(o.Violations, n.Violations),
+			Warnings:   diffResults(o.Warnings, n.Warnings),
+			Successes:  diffResults(o.Successes, n.Successes),
+		}
+
+		cd.Status = ComponentUnchanged
+		if cd.changed() || o.ContainerImage != n.ContainerImage || o.Success != n.Success {
+			cd.Status = ComponentChanged
+		}
+
+		diff.Worse = diff.Worse || len(cd.Violations.New) > 0 || (o.Success && !n.Success)
+		diff.Components = append(diff.Components, cd)
+	}
+
+	for _, o := range old.Components {
+		key := componentKey(o)
+		if seen[key] {
+			continue
+		}
+		o = withPlatformResults(o)
+		oldSuccess := o.Success
+		diff.Components = append(diff.Components, ComponentDiff{
+			Name:       key,
+			OldImage:   o.ContainerImage,
+			Status:     ComponentRemoved,
+			OldSuccess: &oldSuccess,
+			Violations: diffResults(o.Violations, nil),
+			Warnings:   diffResults(o.Warnings, nil),
+			Successes:  diffResults(o.Successes, nil),
+		})
+	}
+
+	sort.SliceStable(diff.Components, func(i, j int) bool {
+		return diff.Components[i].Name < diff.Components[j].Name
+	})
+
+	return diff
+}
+
+func (c ComponentDiff) changed() bool {
+	for _, d := range []ResultsDiff{c.Violations, c.Warnings, c.Successes} {
+		if len(d.New)+len(d.Resolved)+len(d.Changed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// diffKey identifies a result by its key and the platform it was reported
+// for.
+type diffKey struct {
+	evaluator.ResultKey
+	Platform string
+}
+
+// diffResults matches the results by their key. Results with the same key and
+// message are unchanged, results with the same key but a different message
+// are changed, the remaining ones are either new or resolved.
+func diffResults(old, new []evaluator.Result) ResultsDiff {
+	group := func(results []evaluator.Result) (map[diffKey][]string, []diffKey) {
+		grouped := map[diffKey][]string{}
+		keys := []diffKey{}
+		for _, r := range results {
+			k := diffKey{ResultKey: evaluator.KeyOf(r), Platform: evaluator.ExtractStringFromMetadata(r, PlatformMetadata)}
+			if _, ok := grouped[k]; !ok {
+				keys = append(keys, k)
+			}
+			grouped[k] = append(grouped[k], r.Message)
+		}
+		return grouped, keys
+	}
+
+	olds, oldKeys := group(old)
+	news, newKeys := group(new)
+
+	diff := ResultsDiff{}
+	for _, k := range newKeys {
+		oldMsgs := remaining(olds[k], news[k])
+		newMsgs := remaining(news[k], olds[k])
+
+		for i, msg := range newMsgs {
+			change := ResultChange{Code: k.Code, Term: k.Term, Platform: k.Platform, Message: msg}
+			if i < len(oldMsgs) {
+				change.OldMessage = oldMsgs[i]
+				diff.Changed = append(diff.Changed, change)
+			} else {
+				diff.New = append(diff.New, change)
+			}
+		}
+
+		for i := len(newMsgs); i < len(oldMsgs); i++ {
+			diff.Resolved = append(diff.Resolved, ResultChange{Code: k.Code, Term: k.Term, Platform: k.Platform, Message: oldMsgs[i]})
+		}
+	}
+
+	for _, k := range oldKeys {
+		if _, ok := news[k]; ok {
+			continue
+		}
+		for _, msg := range olds[k] {
+			diff.Resolved = append(diff.Resolved, ResultChange{Code: k.Code, Term: k.Term, Platform: k.Platform, Message: msg})
+		}
+	}
+
+	return diff
+}
+
+// remaining returns the messages from a that are not matched by a message in b
+func remaining(a, b []string) []string {
+	counts := map[string]int{}
+	for _, m := range b {
+		counts[m]++
+	}
+
+	var left []string
+	for _, m := range a {
+		if counts[m] > 0 {
+			counts[m]--
+			continue
+		}
+		left = append(left, m)
+	}
+
+	return left
+}
+
+// WriteAll writes the report difference to all the given targets.
+func (d ReportDiff) WriteAll(targets []string, p format.TargetParser) (allErrors error) {
+	if len(targets) == 0 {
+		targets = append(targets, DiffText)
+	}
+	for _, targetName := range targets {
+		target, err := p.Parse(targetName)
+		if err != nil {
+			allErrors = errors.Join(allErrors, err)
+			continue
+		}
+
+		data, err := d.toFormat(target.Format)
+		if err != nil {
+			allErrors = errors.Join(allErrors, err)
+			continue
+		}
+
+		if !bytes.HasSuffix(data, []byte{'\n'}) {
+			data = append(data, "\n"...)
+		}
+
+		if _, err := target.Write(data); err != nil {
+			allErrors = errors.Join(allErrors, err)
+		}
+	}
+	return
+}
+
+// toFormat converts the report difference into the given format.
+func (d *ReportDiff) toFormat(format string) (data []byte, err error) {
+	switch format {
+	case DiffJSON:
+		data, err = json.Marshal(d)
+	case DiffText:
+		data, err = utils.RenderFromTemplatesWithMain(d, "diff_text.tmpl", efs)
+	case DiffMarkdown:
+		data, err = utils.RenderFromTemplatesWithMain(d, "diff_markdown.tmpl", efs)
+	default:
+		return nil, fmt.Errorf("%q is not a valid report difference format", format)
+	}
+	return
+}
@@ -0,0 +1,178 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func result(code, term, msg string) evaluator.Result {
+	r := evaluator.Result{Message: msg, Metadata: map[string]any{"code": code}}
+	if term != "" {
+		r.Metadata["term"] = term
+	}
+	return r
+}
+
+func TestDiffResults(t *testing.T) {
+	old := []evaluator.Result{
+		result("a.b", "", "unchanged"),
+		result("a.c", "t1", "resolved"),
+		result("a.d", "", "before"),
+		result("a.e", "t1", "twice"),
+		result("a.e", "t1", "twice"),
+	}
+	new := []evaluator.Result{
+		result("a.b", "", "unchanged"),
+		result("a.c", "t2", "new term"),
+		result("a.d", "", "after"),
+		result("a.e", "t1", "twice"),
+	}
+
+	assert.Equal(t, ResultsDiff{
+		New:      []ResultChange{{Code: "a.c", Term: "t2", Message: "new term"}},
+		Resolved: []ResultChange{{Code: "a.e", Term: "t1", Message: "twice"}, {Code: "a.c", Term: "t1", Message: "resolved"}},
+		Changed:  []ResultChange{{Code: "a.d", Message: "after", OldMessage: "before"}},
+	}, diffResults(old, new))
+
+	assert.Equal(t, ResultsDiff{}, diffResults(old, old))
+}
+
+func diffReports() (Report, Report) {
+	old := Report{
+		Success: false,
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "same", ContainerImage: "registry.io/same@sha256:1"},
+				Success:           true,
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "rebuilt", ContainerImage: "registry.io/rebuilt@sha256:1"},
+				Violations:        []evaluator.Result{result("a.b", "", "fixed")},
+				Warnings:          []evaluator.Result{result("w.x", "", "warned before")},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "gone", ContainerImage: "registry.io/gone@sha256:1"},
+				Success:           true,
+			},
+		},
+	}
+	new := Report{
+		Success: false,
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "same", ContainerImage: "registry.io/same@sha256:1"},
+				Success:           true,
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "rebuilt", ContainerImage: "registry.io/rebuilt@sha256:2"},
+				Violations:        []evaluator.Result{result("c.d", "term", "broken")},
+				Warnings:          []evaluator.Result{result("w.x", "", "warned after")},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/added:latest"},
+				Success:           true,
+			},
+		},
+	}
+	return old, new
+}
+
+func TestComponentKey(t *testing.T) {
+	cases := []struct {
+		name      string
+		component Component
+		expected  string
+	}{
+		{
+			name:      "name",
+			component: Component{SnapshotComponent: app.SnapshotComponent{Name: "spam", ContainerImage: "registry.io/spam:1"}},
+			expected:  "spam",
+		},
+		{
+			name:      "image repository",
+			component: Component{SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/spam@sha256:" + strings.Repeat("a", 64)}},
+			expected:  "registry.io/spam",
+		},
+		{
+			name:      "snapshot",
+			component: Component{SnapshotComponent: app.SnapshotComponent{Name: "spam"}, Snapshot: "release"},
+			expected:  "release/spam",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, componentKey(c.component))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, new := diffReports()
+
+	diff := Diff(old, new)
+	assert.True(t, diff.Worse)
+	require.Len(t, diff.Components, 4)
+
+	statuses := map[string]string{}
+	for _, c := range diff.Components {
+		statuses[c.Name] = c.Status
+	}
+	assert.Equal(t, map[string]string{
+		"same":              ComponentUnchanged,
+		"rebuilt":           ComponentChanged,
+		"gone":              ComponentRemoved,
+		"registry.io/added": ComponentAdded,
+	}, statuses)
+
+	assert.False(t, Diff(old, old).Worse)
+
+	fixed := old
+	fixed.Components = []Component{old.Components[0]}
+	assert.False(t, Diff(old, fixed).Worse)
+
+	broken := fixed
+	broken.Components = []Component{{SnapshotComponent: old.Components[0].SnapshotComponent, Success: false}}
+	assert.True(t, Diff(fixed, broken).Worse)
+}
+
+func TestDiffFormats(t *testing.T) {
+	old, new := diffReports()
+	diff := Diff(old, new)
+
+	for _, f := range DiffOutputFormats {
+		t.Run(f, func(t *testing.T) {
+			out, err := diff.toFormat(f)
+			require.NoError(t, err)
+			snaps.MatchSnapshot(t, string(out))
+		})
+	}
+
+	_, err := diff.toFormat("spam")
+	assert.EqualError(t, err, `"spam" is not a valid report difference format`)
+}
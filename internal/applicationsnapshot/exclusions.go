@@ -0,0 +1,225 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-containerregistry/pkg/name"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+// Possible types of the patch suggesting the exclusions.
+const (
+	JSONPatch  = "json-patch"
+	MergePatch = "merge-patch"
+)
+
+var ExclusionPatchTypes = []string{
+	JSONPatch,
+	MergePatch,
+}
+
+// ExclusionOptions control the exclusions suggested for the violations.
+type ExclusionOptions struct {
+	// PatchType is one of ExclusionPatchTypes, defaults to JSONPatch
+	PatchType string
+	// PerImage scopes each exclusion to the digest of the image it was
+	// reported for
+	PerImage bool
+	// EffectiveUntil, when set, limits the exclusions to the given time
+	EffectiveUntil time.Time
+}
+
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// SuggestExclusions returns a patch of the given policy configuration, as it
+// was provided by the user, that adds an exclusion to the volatile
+// configuration of each policy source for every violation in the report. The
+// policy of the report cannot be used, its sources are pinned when resolved.
+// As the violations do not record the policy source they originated from, the
+// exclusions are added to all sources. Violations of the built-in checks cannot
+// be excluded and are ignored.
+//
+// The JSON Patch appends to the existing exclusions, while the merge patch, as
+// merge patches do not support modifying arrays, contains all the sources of
+// the policy configuration.
+func SuggestExclusions(r Report, spec ecc.EnterpriseContractPolicySpec, opts ExclusionOptions) ([]byte, error) {
+	if opts.PatchType == "" {
+		opts.PatchType = JSONPatch
+	}
+
+	criteria := exclusionCriteria(r.Components, opts)
+
+	if len(criteria) > 0 && len(spec.Sources) == 0 {
+		return nil, errors.New("the policy has no sources to add the exclusions to")
+	}
+
+	switch opts.PatchType {
+	case JSONPatch:
+		return json.Marshal(exclusionOperations(spec, criteria))
+	case MergePatch:
+		original, err := json.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		modified, err := json.Marshal(withExclusions(spec, criteria))
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonpatch.CreateMergePatch(original, modified)
+	default:
+		return nil, fmt.Errorf("%q is not a valid exclusion patch type, use one of: %s", opts.PatchType, strings.Join(ExclusionPatchTypes, ", "))
+	}
+}
+
+// exclusionCriteria returns a, deduplicated, exclusion for each violation of
+// the components, and of the platforms of image index components, using the
+// code and the term of the rule so that only the reported violation is
+// excluded. When the exclusions are scoped to the image, the image of the
+// platform is used for the violations of a platform, and no exclusions are
+// suggested for the images not referenced by digest.
+func exclusionCriteria(components []Component, opts ExclusionOptions) []ecc.VolatileCriteria {
+	criteria := []ecc.VolatileCriteria{}
+	seen := map[ecc.VolatileCriteria]bool{}
+	notPinned := map[string]bool{}
+
+	for _, c := range components {
+		c = withPlatformResults(c)
+		for _, v := range c.Violations {
+			code := evaluator.ExtractStringFromMetadata(v, "code")
+			if code == "" || strings.HasPrefix(code, "builtin.") {
+				continue
+			}
+
+			digest := ""
+			if opts.PerImage {
+				image := resultImage(c, v)
+				ref, err := name.NewDigest(image)
+				if err != nil {
+					if !notPinned[image] {
+						log.Warnf("Not suggesting exclusions for the image %q, it is not referenced by digest", image)
+						notPinned[image] = true
+					}
+					continue
+				}
+				digest = ref.DigestStr()
+			}
+
+			for _, value := range evaluator.ExclusionValues(code, v.Metadata["term"]) {
+				criterion := ecc.VolatileCriteria{
+					Value:       value,
+					ImageDigest: digest,
+				}
+				if !opts.EffectiveUntil.IsZero() {
+					criterion.EffectiveUntil = opts.EffectiveUntil.UTC().Format(time.RFC3339)
+				}
+
+				if seen[criterion] {
+					continue
+				}
+				seen[criterion] = true
+				criteria = append(criteria, criterion)
+			}
+		}
+	}
+
+	return criteria
+}
+
+// missingCriteria returns the criteria not yet present in the volatile
+// configuration of the source.
+func missingCriteria(source ecc.Source, criteria []ecc.VolatileCriteria) []ecc.VolatileCriteria {
+	missing := []ecc.VolatileCriteria{}
+	for _, c := range criteria {
+		found := false
+		if source.VolatileConfig != nil {
+			for _, e := range source.VolatileConfig.Exclude {
+				if e == c {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			missing = append(missing, c)
+		}
+	}
+
+	return missing
+}
+
+// exclusionOperations returns the JSON Patch operations adding the criteria to
+// the volatile configuration of each of the policy sources.
+func exclusionOperations(spec ecc.EnterpriseContractPolicySpec, criteria []ecc.VolatileCriteria) []jsonPatchOperation {
+	ops := []jsonPatchOperation{}
+	for i, source := range spec.Sources {
+		missing := missingCriteria(source, criteria)
+		if len(missing) == 0 {
+			continue
+		}
+
+		path := fmt.Sprintf("/sources/%d/volatileConfig", i)
+		switch {
+		case source.VolatileConfig == nil:
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: path, Value: ecc.VolatileSourceConfig{Exclude: missing}})
+		case len(source.VolatileConfig.Exclude) == 0:
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: path + "/exclude", Value: missing})
+		default:
+			for _, c := range missing {
+				ops = append(ops, jsonPatchOperation{Op: "add", Path: path + "/exclude/-", Value: c})
+			}
+		}
+	}
+
+	return ops
+}
+
+// withExclusions returns a copy of the policy configuration with the criteria
+// added to the volatile configuration of each of the policy sources.
+func withExclusions(spec ecc.EnterpriseContractPolicySpec, criteria []ecc.VolatileCriteria) ecc.EnterpriseContractPolicySpec {
+	sources := make([]ecc.Source, 0, len(spec.Sources))
+	for _, source := range spec.Sources {
+		missing := missingCriteria(source, criteria)
+		if len(missing) > 0 {
+			config := ecc.VolatileSourceConfig{}
+			if source.VolatileConfig != nil {
+				config = *source.VolatileConfig
+			}
+			config.Exclude = append(append([]ecc.VolatileCriteria{}, config.Exclude...), missing...)
+			source.VolatileConfig = &config
+		}
+		sources = append(sources, source)
+	}
+	spec.Sources = sources
+
+	return spec
+}
@@ -0,0 +1,191 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+const exclusionsDigest = "sha256:4e388ab32b10dc8dbc7e28144f552830adc74787c1e2c0824032078a79f227fb"
+
+func exclusionsReport() Report {
+	return Report{
+		Policy: ecc.EnterpriseContractPolicySpec{
+			Sources: []ecc.Source{
+				{Policy: []string{"registry.io/policy:one"}},
+				{
+					Policy: []string{"registry.io/policy:two"},
+					VolatileConfig: &ecc.VolatileSourceConfig{
+						Exclude: []ecc.VolatileCriteria{{Value: "x.y"}},
+					},
+				},
+			},
+		},
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@" + exclusionsDigest},
+				Violations: []evaluator.Result{
+					{Message: "builtin", Metadata: map[string]any{"code": "builtin.image.signature_check"}},
+					{Message: "single term", Metadata: map[string]any{"code": "a.b", "term": "t1"}},
+					{Message: "many terms", Metadata: map[string]any{"code": "c.d", "term": []any{"t2", "t3"}}},
+					{Message: "no term", Metadata: map[string]any{"code": "e.f"}},
+					{Message: "no code"},
+				},
+				Warnings: []evaluator.Result{
+					{Message: "warning", Metadata: map[string]any{"code": "g.h"}},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two@" + exclusionsDigest},
+				Violations: []evaluator.Result{
+					{Message: "single term", Metadata: map[string]any{"code": "a.b", "term": "t1"}},
+				},
+			},
+		},
+	}
+}
+
+func TestExclusionCriteria(t *testing.T) {
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		opts     ExclusionOptions
+		expected []ecc.VolatileCriteria
+	}{
+		{
+			name: "unscoped",
+			expected: []ecc.VolatileCriteria{
+				{Value: "a.b:t1"},
+				{Value: "c.d:t2"},
+				{Value: "c.d:t3"},
+				{Value: "e.f"},
+			},
+		},
+		{
+			name: "per image and time boxed",
+			opts: ExclusionOptions{PerImage: true, EffectiveUntil: until},
+			expected: []ecc.VolatileCriteria{
+				{Value: "a.b:t1", ImageDigest: exclusionsDigest, EffectiveUntil: "2030-01-01T00:00:00Z"},
+				{Value: "c.d:t2", ImageDigest: exclusionsDigest, EffectiveUntil: "2030-01-01T00:00:00Z"},
+				{Value: "c.d:t3", ImageDigest: exclusionsDigest, EffectiveUntil: "2030-01-01T00:00:00Z"},
+				{Value: "e.f", ImageDigest: exclusionsDigest, EffectiveUntil: "2030-01-01T00:00:00Z"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, exclusionCriteria(exclusionsReport().Components, c.opts))
+		})
+	}
+}
+
+func TestExclusionCriteriaNotPinned(t *testing.T) {
+	components := []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/one:latest"},
+			Violations:        []evaluator.Result{{Metadata: map[string]any{"code": "a.b"}}},
+		},
+		{
+			SnapshotComponent: app.SnapshotComponent{ContainerImage: "registry.io/two@" + exclusionsDigest},
+			Violations:        []evaluator.Result{{Metadata: map[string]any{"code": "c.d"}}},
+		},
+	}
+
+	assert.Equal(t, []ecc.VolatileCriteria{{Value: "c.d", ImageDigest: exclusionsDigest}}, exclusionCriteria(components, ExclusionOptions{PerImage: true}))
+	assert.Equal(t, []ecc.VolatileCriteria{{Value: "a.b"}, {Value: "c.d"}}, exclusionCriteria(components, ExclusionOptions{}))
+}
+
+func TestSuggestExclusions(t *testing.T) {
+	r := exclusionsReport()
+	expected := withExclusions(r.Policy, []ecc.VolatileCriteria{
+		{Value: "a.b:t1"},
+		{Value: "c.d:t2"},
+		{Value: "c.d:t3"},
+		{Value: "e.f"},
+	})
+
+	original, err := json.Marshal(r.Policy)
+	require.NoError(t, err)
+
+	t.Run(JSONPatch, func(t *testing.T) {
+		data, err := SuggestExclusions(r, r.Policy, ExclusionOptions{})
+		require.NoError(t, err)
+
+		patch, err := jsonpatch.DecodePatch(data)
+		require.NoError(t, err)
+		patched, err := patch.Apply(original)
+		require.NoError(t, err)
+
+		var spec ecc.EnterpriseContractPolicySpec
+		require.NoError(t, json.Unmarshal(patched, &spec))
+		assert.Equal(t, expected, spec)
+	})
+
+	t.Run(MergePatch, func(t *testing.T) {
+		data, err := SuggestExclusions(r, r.Policy, ExclusionOptions{PatchType: MergePatch})
+		require.NoError(t, err)
+
+		patched, err := jsonpatch.MergePatch(original, data)
+		require.NoError(t, err)
+
+		var spec ecc.EnterpriseContractPolicySpec
+		require.NoError(t, json.Unmarshal(patched, &spec))
+		assert.Equal(t, expected, spec)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		_, err := SuggestExclusions(r, r.Policy, ExclusionOptions{PatchType: "nope"})
+		assert.EqualError(t, err, `"nope" is not a valid exclusion patch type, use one of: json-patch, merge-patch`)
+	})
+
+	t.Run("no sources", func(t *testing.T) {
+		_, err := SuggestExclusions(r, ecc.EnterpriseContractPolicySpec{}, ExclusionOptions{})
+		assert.EqualError(t, err, "the policy has no sources to add the exclusions to")
+	})
+}
+
+func TestExclusionOperations(t *testing.T) {
+	criteria := []ecc.VolatileCriteria{{Value: "a.b"}, {Value: "x.y"}}
+	spec := ecc.EnterpriseContractPolicySpec{
+		Sources: []ecc.Source{
+			{},
+			{VolatileConfig: &ecc.VolatileSourceConfig{}},
+			{VolatileConfig: &ecc.VolatileSourceConfig{Exclude: []ecc.VolatileCriteria{{Value: "x.y"}}}},
+			{VolatileConfig: &ecc.VolatileSourceConfig{Exclude: criteria}},
+		},
+	}
+
+	assert.Equal(t, []jsonPatchOperation{
+		{Op: "add", Path: "/sources/0/volatileConfig", Value: ecc.VolatileSourceConfig{Exclude: criteria}},
+		{Op: "add", Path: "/sources/1/volatileConfig/exclude", Value: criteria},
+		{Op: "add", Path: "/sources/2/volatileConfig/exclude/-", Value: ecc.VolatileCriteria{Value: "a.b"}},
+	}, exclusionOperations(spec, criteria))
+}
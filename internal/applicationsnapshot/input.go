@@ -26,6 +26,7 @@ import (
 	"strconv"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -50,6 +51,9 @@ type Input struct {
 	Image    string
 	Snapshot string
 	Images   string
+	// GroupPlatforms keeps image indexes as single components instead of
+	// adding a component for each of their platform images
+	GroupPlatforms bool
 }
 
 type snapshot struct {
@@ -172,11 +176,61 @@ func DetermineInputSpec(ctx context.Context, input Input) (*app.SnapshotSpec, er
 		log.Debug("No application snapshot available")
 		return nil, errors.New("neither Snapshot nor image reference provided to validate")
 	}
-	expandImageIndex(ctx, &snapshot.SnapshotSpec)
+	if !input.GroupPlatforms {
+		expandImageIndex(ctx, &snapshot.SnapshotSpec)
+	}
 
 	return &snapshot.SnapshotSpec, nil
 }
 
+// NamedSnapshot is one of several snapshots validated at once.
+type NamedSnapshot struct {
+	Name string
+	Spec *app.SnapshotSpec
+}
+
+// DetermineInputSpecs returns a snapshot for each of the given Kubernetes
+// Snapshot references and images sources, so that they can be validated at
+// once and reported separately. The snapshots are named after the reference
+// or the path of the file they were read from, or the application of the
+// snapshot when it was provided inline. See Input for groupPlatforms.
+func DetermineInputSpecs(ctx context.Context, snapshots []string, images []string, groupPlatforms bool) ([]NamedSnapshot, error) {
+	inputs := make([]Input, 0, len(snapshots)+len(images))
+	for _, s := range snapshots {
+		inputs = append(inputs, Input{Snapshot: s, GroupPlatforms: groupPlatforms})
+	}
+	for _, i := range images {
+		inputs = append(inputs, Input{Images: i, GroupPlatforms: groupPlatforms})
+	}
+
+	named := make([]NamedSnapshot, 0, len(inputs))
+	seen := map[string]bool{}
+	for i, input := range inputs {
+		spec, err := DetermineInputSpec(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		name := input.Snapshot
+		if name == "" {
+			name = input.Images
+		}
+		if utils.IsJson(name) || utils.IsYamlMap(name) {
+			name = spec.Application
+		}
+		// The generated names must not clash with the names of the other
+		// snapshots either
+		for n := i + 1; name == "" || seen[name]; n++ {
+			name = fmt.Sprintf("snapshot-%d", n)
+		}
+		seen[name] = true
+
+		named = append(named, NamedSnapshot{Name: name, Spec: spec})
+	}
+
+	return named, nil
+}
+
 func readSnapshotSource(input []byte) (app.SnapshotSpec, error) {
 	var file app.SnapshotSpec
 	err := yaml.Unmarshal(input, &file)
@@ -200,31 +254,13 @@ func imageIndexWorker(client oci.Client, component app.SnapshotComponent, compon
 		componentChan <- components
 	}()
 
-	ref, err := name.ParseReference(component.ContainerImage)
-	if err != nil {
-		errorsChan <- fmt.Errorf("unable to parse container image %s: %w", component.ContainerImage, err)
-		return
-	}
-
-	desc, err := client.Head(ref)
-	if err != nil {
-		errorsChan <- fmt.Errorf("unable to fetch descriptior for container image %s: %w", ref, err)
-		return
-	}
-
-	if !desc.MediaType.IsIndex() {
-		return
-	}
-
-	index, err := client.Index(ref)
+	ref, indexManifest, err := imageIndexManifest(client, component)
 	if err != nil {
-		errorsChan <- fmt.Errorf("unable to fetch index for container image %s: %w", component.ContainerImage, err)
+		errorsChan <- err
 		return
 	}
 
-	indexManifest, err := index.IndexManifest()
-	if err != nil {
-		errorsChan <- fmt.Errorf("unable to fetch index manifest for container image %s: %w", component.ContainerImage, err)
+	if indexManifest == nil {
 		return
 	}
 
@@ -291,6 +327,36 @@ func expandImageIndex(ctx context.Context, snap *app.SnapshotSpec) {
 	log.Debugf("Snap component after expanding the image index is %v", snap.Components)
 }
 
+// imageIndexManifest returns the parsed image reference of the component and,
+// when the image is an image index, its manifest.
+func imageIndexManifest(client oci.Client, component app.SnapshotComponent) (name.Reference, *v1.IndexManifest, error) {
+	ref, err := name.ParseReference(component.ContainerImage)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse container image %s: %w", component.ContainerImage, err)
+	}
+
+	desc, err := client.Head(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to fetch descriptior for container image %s: %w", ref, err)
+	}
+
+	if !desc.MediaType.IsIndex() {
+		return ref, nil, nil
+	}
+
+	index, err := client.Index(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to fetch index for container image %s: %w", component.ContainerImage, err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to fetch index manifest for container image %s: %w", component.ContainerImage, err)
+	}
+
+	return ref, indexManifest, nil
+}
+
 func imageWorkers() int {
 	workers := defaultWorkers
 	if value, exists := os.LookupEnv(workersEnvVar); exists {
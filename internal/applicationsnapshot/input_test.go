@@ -187,6 +187,47 @@ func Test_DetermineInputSpec(t *testing.T) {
 	}
 }
 
+func Test_DetermineInputSpecs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+	client := fake.FakeClient{}
+	client.On("Head", mock.Anything).Return(&v1.Descriptor{MediaType: types.OCIManifestSchema1}, nil)
+	ctx = oci.WithClient(ctx, &client)
+
+	assert.NoError(t, afero.WriteFile(fs, "one.yaml", []byte(`{"components": [{"name": "a", "containerImage": "registry.io/a:1"}]}`), 0400))
+
+	two := `{"application": "two", "components": [{"name": "b", "containerImage": "registry.io/b:1"}]}`
+	three := `{"components": [{"name": "c", "containerImage": "registry.io/c:1"}]}`
+
+	got, err := DetermineInputSpecs(ctx, nil, []string{"one.yaml", two, three, two}, false)
+	assert.NoError(t, err)
+
+	names := []string{}
+	components := []string{}
+	for _, s := range got {
+		names = append(names, s.Name)
+		for _, c := range s.Spec.Components {
+			components = append(components, c.Name)
+		}
+	}
+	assert.Equal(t, []string{"one.yaml", "two", "snapshot-3", "snapshot-4"}, names)
+	assert.Equal(t, []string{"a", "b", "c", "b"}, components)
+
+	// A generated name does not clash with the name of another snapshot
+	assert.NoError(t, afero.WriteFile(fs, "snapshot-2", []byte(three), 0400))
+	got, err = DetermineInputSpecs(ctx, nil, []string{"snapshot-2", three, three}, false)
+	assert.NoError(t, err)
+
+	names = []string{}
+	for _, s := range got {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"snapshot-2", "snapshot-3", "snapshot-4"}, names)
+
+	_, err = DetermineInputSpecs(ctx, nil, []string{"one.yaml", "{"}, false)
+	assert.Error(t, err)
+}
+
 func TestReadSnapshotFile(t *testing.T) {
 	t.Run("Successful file read and unmarshal", func(t *testing.T) {
 		snapshotSpec := app.SnapshotSpec{
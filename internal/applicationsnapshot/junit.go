@@ -105,8 +105,11 @@ func (r *Report) toJUnit() junit.Testsuites {
 			properties = append(properties, metaProps...)
 		}
 
+		// The platform is part of the name of the test cases of the platforms
+		component = withPlatformResults(component)
+
 		suite := junit.Testsuite{
-			Timestamp:  r.created.Format(time.RFC3339),
+			Timestamp:  r.Created.Format(time.RFC3339),
 			Name:       fmt.Sprintf("%s (%s)", component.Name, component.ContainerImage),
 			Properties: &properties,
 		}
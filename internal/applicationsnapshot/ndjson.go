@@ -0,0 +1,169 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+
+	"github.com/enterprise-contract/ec-cli/internal/format"
+)
+
+// Kinds of the lines written in the NDJSON format.
+const (
+	ndjsonComponentKind = "component"
+	ndjsonSummaryKind   = "summary"
+)
+
+type ndjsonComponent struct {
+	Kind string `json:"kind"`
+	Component
+}
+
+type ndjsonSummary struct {
+	Kind          string                           `json:"kind"`
+	Success       bool                             `json:"success"`
+	Snapshot      string                           `json:"snapshot,omitempty"`
+	Key           string                           `json:"key"`
+	Policy        ecc.EnterpriseContractPolicySpec `json:"policy"`
+	EcVersion     string                           `json:"ec-version"`
+	EffectiveTime time.Time                        `json:"effective-time"`
+	Components    int                              `json:"components"`
+	Violations    int                              `json:"violations"`
+	Warnings      int                              `json:"warnings"`
+	Successes     int                              `json:"successes"`
+}
+
+func ndjsonComponentLine(c Component) ([]byte, error) {
+	line, err := json.Marshal(ndjsonComponent{Kind: ndjsonComponentKind, Component: c})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func ndjsonSummaryLine(r *Report) ([]byte, error) {
+	s := ndjsonSummary{
+		Kind:          ndjsonSummaryKind,
+		Success:       r.Success,
+		Snapshot:      r.Snapshot,
+		Key:           r.Key,
+		Policy:        r.Policy,
+		EcVersion:     r.EcVersion,
+		EffectiveTime: r.EffectiveTime,
+		Components:    len(r.Components),
+	}
+	for _, c := range r.Components {
+		s.Violations += len(c.Violations)
+		s.Warnings += len(c.Warnings)
+		s.Successes += c.SuccessCount
+	}
+
+	line, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// toNDJSON renders a line for each of the components of the report followed
+// by the summary line.
+func (r *Report) toNDJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, c := range r.Components {
+		line, err := ndjsonComponentLine(c)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+	}
+
+	line, err := ndjsonSummaryLine(r)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(line)
+
+	return buf.Bytes(), nil
+}
+
+// Stream writes the report in the NDJSON format progressively: a line for
+// each component as soon as its validation is done, and the summary line once
+// the report is complete. The components are written in the order they are
+// given, not in the order of the components in the report.
+type Stream struct {
+	targets []*format.Target
+}
+
+// NewStream returns a Stream writing to all the given targets, which must use
+// the NDJSON format.
+func NewStream(targets []string, p format.TargetParser) (*Stream, error) {
+	s := Stream{}
+	for _, targetName := range targets {
+		target, err := p.Parse(targetName)
+		if err != nil {
+			return nil, err
+		}
+		if target.Format != NDJSON {
+			return nil, errors.New("only the ndjson format can be streamed")
+		}
+		s.targets = append(s.targets, target)
+	}
+
+	return &s, nil
+}
+
+// WriteComponent writes the line for the component to all targets showing
+// the component.
+func (s *Stream) WriteComponent(c Component) (allErrors error) {
+	for _, target := range s.targets {
+		filtered := Report{Components: []Component{c}}.withOptions(target.Options)
+		if len(filtered.Components) == 0 {
+			continue
+		}
+
+		line, err := ndjsonComponentLine(filtered.Components[0])
+		if err != nil {
+			return err
+		}
+
+		if _, err := target.Write(line); err != nil {
+			allErrors = errors.Join(allErrors, err)
+		}
+	}
+	return
+}
+
+// WriteSummary writes the summary line of the report to all targets.
+func (s *Stream) WriteSummary(r Report) (allErrors error) {
+	for _, target := range s.targets {
+		filtered := r.withOptions(target.Options)
+		line, err := ndjsonSummaryLine(&filtered)
+		if err != nil {
+			return err
+		}
+
+		if _, err := target.Write(line); err != nil {
+			allErrors = errors.Join(allErrors, err)
+		}
+	}
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+)
+
+func ndjsonReport() Report {
+	return Report{
+		Success:       false,
+		Key:           "key",
+		EcVersion:     "v1.2.3",
+		EffectiveTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one:latest"},
+				Violations:        []evaluator.Result{{Message: "violation", Metadata: map[string]any{"code": "a.b"}}},
+				Warnings:          []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "c.d"}}},
+				SuccessCount:      3,
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two:latest"},
+				Success:           true,
+				SuccessCount:      1,
+			},
+		},
+	}
+}
+
+const ndjsonExpected = `{"kind":"component","name":"one","containerImage":"registry.io/one:latest","source":{},"violations":[{"msg":"violation","metadata":{"code":"a.b"}}],"warnings":[{"msg":"warning","metadata":{"code":"c.d"}}],"success":false,"success-count":3}
+{"kind":"component","name":"two","containerImage":"registry.io/two:latest","source":{},"success":true,"success-count":1}
+{"kind":"summary","success":false,"key":"key","policy":{},"ec-version":"v1.2.3","effective-time":"2024-01-01T00:00:00Z","components":2,"violations":1,"warnings":1,"successes":4}
+`
+
+func TestToNDJSON(t *testing.T) {
+	r := ndjsonReport()
+	data, err := r.toFormat(NDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, ndjsonExpected, string(data))
+}
+
+func TestStream(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var out bytes.Buffer
+	p := format.NewTargetParser(JSON, format.DefaultOptions(), &out, fs)
+
+	s, err := NewStream([]string{"ndjson", "ndjson=report.ndjson"}, p)
+	require.NoError(t, err)
+
+	r := ndjsonReport()
+	for _, c := range r.Components {
+		require.NoError(t, s.WriteComponent(c))
+	}
+	require.NoError(t, s.WriteSummary(r))
+
+	assert.Equal(t, ndjsonExpected, out.String())
+	written, err := afero.ReadFile(fs, "report.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, ndjsonExpected, string(written))
+
+	_, err = NewStream([]string{"json"}, p)
+	assert.EqualError(t, err, "only the ndjson format can be streamed")
+}
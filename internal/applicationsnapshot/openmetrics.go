@@ -0,0 +1,193 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+// metricFamily holds the samples of a metric rendered in the OpenMetrics text
+// format. All metrics are gauges so that the output can also be consumed by
+// parsers of the Prometheus text format, e.g. the node-exporter textfile
+// collector.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+func (f *metricFamily) add(value float64, labels ...[2]string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+func (f metricFamily) writeTo(buf *bytes.Buffer) {
+	if len(f.samples) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", f.name)
+	fmt.Fprintf(buf, "# HELP %s %s\n", f.name, f.help)
+	for _, s := range f.samples {
+		buf.WriteString(f.name)
+		if len(s.labels) > 0 {
+			labels := make([]string, 0, len(s.labels))
+			for _, l := range s.labels {
+				labels = append(labels, fmt.Sprintf(`%s="%s"`, l[0], labelValueEscaper.Replace(l[1])))
+			}
+			fmt.Fprintf(buf, "{%s}", strings.Join(labels, ","))
+		}
+		fmt.Fprintf(buf, " %s\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+// labelValueEscaper escapes the characters that need escaping in label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func label(name, value string) [2]string {
+	return [2]string{name, value}
+}
+
+// policySourceRevision returns the revision of the pinned policy source URL,
+// the image digest for OCI sources and the commit for git sources.
+func policySourceRevision(url string) string {
+	if _, ref, found := strings.Cut(url, "?ref="); found {
+		return ref
+	}
+
+	if i := strings.LastIndex(url, "@"); i != -1 && strings.Contains(url[i:], ":") {
+		return url[i+1:]
+	}
+
+	return ""
+}
+
+// countByCode counts the results by their rule code and severity. Without the
+// --info flag the severity is not included in the metadata of the results, so
+// it is taken from the information about the rule, the severity of results
+// without one is the given default severity.
+func countByCode(results []evaluator.Result, ruleInfo map[string]map[string]any, defaultSeverity string) ([][2]string, map[[2]string]int) {
+	keys := [][2]string{}
+	counts := map[[2]string]int{}
+	for _, r := range results {
+		code := evaluator.ExtractStringFromMetadata(r, "code")
+
+		severity := defaultSeverity
+		if s, ok := r.Metadata["severity"].(string); ok && s != "" {
+			severity = s
+		} else if s, ok := ruleInfo[code]["severity"].(string); ok && s != "" {
+			severity = s
+		}
+
+		key := [2]string{code, severity}
+		if _, ok := counts[key]; !ok {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	return keys, counts
+}
+
+// toOpenMetrics renders the report as metrics in the OpenMetrics text format.
+func (r *Report) toOpenMetrics() []byte {
+	success := metricFamily{name: "ec_success", help: "Whether all components satisfy the policy."}
+	sources := metricFamily{name: "ec_policy_source_info", help: "Policy sources, and their revisions, used for the validation."}
+	componentSuccess := metricFamily{name: "ec_component_success", help: "Whether the component satisfies the policy."}
+	duration := metricFamily{name: "ec_component_evaluation_duration_seconds", help: "Time taken to validate the component."}
+	violations := metricFamily{name: "ec_violations", help: "Number of violations by rule code and severity."}
+	warnings := metricFamily{name: "ec_warnings", help: "Number of warnings by rule code and severity."}
+	successes := metricFamily{name: "ec_successes", help: "Number of successes by rule code."}
+	totals := metricFamily{name: "ec_component_results", help: "Number of results of the component by type."}
+
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	success.add(boolValue(r.Success))
+
+	for _, s := range r.Policy.Sources {
+		for _, url := range s.Policy {
+			sources.add(1, label("source", s.Name), label("kind", "policy"), label("url", url), label("revision", policySourceRevision(url)))
+		}
+		for _, url := range s.Data {
+			sources.add(1, label("source", s.Name), label("kind", "data"), label("url", url), label("revision", policySourceRevision(url)))
+		}
+	}
+
+	for _, c := range r.Components {
+		c = withPlatformResults(c)
+		component := [][2]string{label("component", c.Name), label("image", c.ContainerImage)}
+		// The same component can be part of several snapshots validated at once
+		if c.Snapshot != "" {
+			component = [][2]string{label("snapshot", c.Snapshot), label("component", c.Name), label("image", c.ContainerImage)}
+		}
+
+		componentSuccess.add(boolValue(c.Success), component...)
+		if c.Duration > 0 {
+			duration.add(c.Duration.Seconds(), component...)
+		}
+
+		keys, counts := countByCode(c.Violations, c.RuleInfo, "failure")
+		for _, k := range keys {
+			violations.add(float64(counts[k]), append(component, label("code", k[0]), label("severity", k[1]))...)
+		}
+
+		keys, counts = countByCode(c.Warnings, c.RuleInfo, "warning")
+		for _, k := range keys {
+			warnings.add(float64(counts[k]), append(component, label("code", k[0]), label("severity", k[1]))...)
+		}
+
+		keys, counts = countByCode(c.Successes, nil, "")
+		for _, k := range keys {
+			successes.add(float64(counts[k]), append(component, label("code", k[0]))...)
+		}
+
+		totals.add(float64(len(c.Violations)), append(component, label("type", "violation"))...)
+		totals.add(float64(len(c.Warnings)), append(component, label("type", "warning"))...)
+		// Successes are included only when requested, the count is always known
+		totals.add(float64(c.SuccessCount), append(component, label("type", "success"))...)
+	}
+
+	var buf bytes.Buffer
+	for _, f := range []metricFamily{success, sources, componentSuccess, duration, violations, warnings, successes, totals} {
+		f.writeTo(&buf)
+	}
+	buf.WriteString("# EOF\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,165 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"testing"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func TestPolicySourceRevision(t *testing.T) {
+	cases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "oci::registry.io/policy@sha256:abc", expected: "sha256:abc"},
+		{url: "git::github.com/org/policy//policy?ref=0123abcd", expected: "0123abcd"},
+		{url: "git::git@github.com/org/policy", expected: ""},
+		{url: "file::/some/path", expected: ""},
+		{url: "data:application/json;base64,e30=", expected: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			assert.Equal(t, c.expected, policySourceRevision(c.url))
+		})
+	}
+}
+
+func TestToOpenMetrics(t *testing.T) {
+	r := Report{
+		Success: false,
+		Policy: ecc.EnterpriseContractPolicySpec{
+			Sources: []ecc.Source{
+				{
+					Name:   "release",
+					Policy: []string{"oci::registry.io/policy@sha256:abc"},
+					Data:   []string{"git::github.com/org/data?ref=0123abcd"},
+				},
+			},
+		},
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:def"},
+				Violations: []evaluator.Result{
+					{Message: "v1", Metadata: map[string]any{"code": "b.c"}},
+					{Message: "v2", Metadata: map[string]any{"code": "a.b"}},
+					{Message: "v3", Metadata: map[string]any{"code": "a.b"}},
+				},
+				Warnings: []evaluator.Result{
+					{Message: "w1", Metadata: map[string]any{"code": "c.d", "severity": "failure"}},
+					{Message: "w2", Metadata: map[string]any{"code": "e.f"}},
+				},
+				RuleInfo: map[string]map[string]any{
+					"e.f": {"code": "e.f", "severity": "failure"},
+				},
+				SuccessCount: 5,
+				Duration:     1500 * time.Millisecond,
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: `two "quoted"`, ContainerImage: "registry.io/two@sha256:fed"},
+				Success:           true,
+				Successes: []evaluator.Result{
+					{Message: "Pass", Metadata: map[string]any{"code": "a.b"}},
+				},
+				SuccessCount: 1,
+			},
+		},
+	}
+
+	data, err := r.toFormat(OpenMetrics)
+	require.NoError(t, err)
+	assert.Equal(t, `# TYPE ec_success gauge
+# HELP ec_success Whether all components satisfy the policy.
+ec_success 0
+# TYPE ec_policy_source_info gauge
+# HELP ec_policy_source_info Policy sources, and their revisions, used for the validation.
+ec_policy_source_info{source="release",kind="policy",url="oci::registry.io/policy@sha256:abc",revision="sha256:abc"} 1
+ec_policy_source_info{source="release",kind="data",url="git::github.com/org/data?ref=0123abcd",revision="0123abcd"} 1
+# TYPE ec_component_success gauge
+# HELP ec_component_success Whether the component satisfies the policy.
+ec_component_success{component="one",image="registry.io/one@sha256:def"} 0
+ec_component_success{component="two \"quoted\"",image="registry.io/two@sha256:fed"} 1
+# TYPE ec_component_evaluation_duration_seconds gauge
+# HELP ec_component_evaluation_duration_seconds Time taken to validate the component.
+ec_component_evaluation_duration_seconds{component="one",image="registry.io/one@sha256:def"} 1.5
+# TYPE ec_violations gauge
+# HELP ec_violations Number of violations by rule code and severity.
+ec_violations{component="one",image="registry.io/one@sha256:def",code="a.b",severity="failure"} 2
+ec_violations{component="one",image="registry.io/one@sha256:def",code="b.c",severity="failure"} 1
+# TYPE ec_warnings gauge
+# HELP ec_warnings Number of warnings by rule code and severity.
+ec_warnings{component="one",image="registry.io/one@sha256:def",code="c.d",severity="failure"} 1
+ec_warnings{component="one",image="registry.io/one@sha256:def",code="e.f",severity="failure"} 1
+# TYPE ec_successes gauge
+# HELP ec_successes Number of successes by rule code.
+ec_successes{component="two \"quoted\"",image="registry.io/two@sha256:fed",code="a.b"} 1
+# TYPE ec_component_results gauge
+# HELP ec_component_results Number of results of the component by type.
+ec_component_results{component="one",image="registry.io/one@sha256:def",type="violation"} 3
+ec_component_results{component="one",image="registry.io/one@sha256:def",type="warning"} 2
+ec_component_results{component="one",image="registry.io/one@sha256:def",type="success"} 5
+ec_component_results{component="two \"quoted\"",image="registry.io/two@sha256:fed",type="violation"} 0
+ec_component_results{component="two \"quoted\"",image="registry.io/two@sha256:fed",type="warning"} 0
+ec_component_results{component="two \"quoted\"",image="registry.io/two@sha256:fed",type="success"} 1
+# EOF
+`, string(data))
+}
+
+func TestToOpenMetricsSnapshots(t *testing.T) {
+	component := func(snapshot string, success bool) Component {
+		return Component{
+			SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:def"},
+			Snapshot:          snapshot,
+			Success:           success,
+			SuccessCount:      1,
+		}
+	}
+
+	r := Report{
+		Components: []Component{component("a", true), component("b", false)},
+	}
+
+	data, err := r.toFormat(OpenMetrics)
+	require.NoError(t, err)
+	assert.Equal(t, `# TYPE ec_success gauge
+# HELP ec_success Whether all components satisfy the policy.
+ec_success 0
+# TYPE ec_component_success gauge
+# HELP ec_component_success Whether the component satisfies the policy.
+ec_component_success{snapshot="a",component="one",image="registry.io/one@sha256:def"} 1
+ec_component_success{snapshot="b",component="one",image="registry.io/one@sha256:def"} 0
+# TYPE ec_component_results gauge
+# HELP ec_component_results Number of results of the component by type.
+ec_component_results{snapshot="a",component="one",image="registry.io/one@sha256:def",type="violation"} 0
+ec_component_results{snapshot="a",component="one",image="registry.io/one@sha256:def",type="warning"} 0
+ec_component_results{snapshot="a",component="one",image="registry.io/one@sha256:def",type="success"} 1
+ec_component_results{snapshot="b",component="one",image="registry.io/one@sha256:def",type="violation"} 0
+ec_component_results{snapshot="b",component="one",image="registry.io/one@sha256:def",type="warning"} 0
+ec_component_results{snapshot="b",component="one",image="registry.io/one@sha256:def",type="success"} 1
+# EOF
+`, string(data))
+}
@@ -0,0 +1,266 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/signature"
+	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
+)
+
+// RequiredPlatformCode is the code of the violation reported for an image
+// index lacking a required platform, or its signed attestations.
+const RequiredPlatformCode = "builtin.image.required_platform"
+
+// PlatformMetadata is the metadata key holding the platform the result was
+// reported for, set when the results of the platforms of an image index are
+// reported along with the results of the component.
+const PlatformMetadata = "platform"
+
+// RequiredPlatformsRuleData is the key of the rule data of the policy sources
+// listing the platforms an image index is required to provide.
+const RequiredPlatformsRuleData = "required_platforms"
+
+// PlatformImage is the image of one of the platforms of an image index.
+type PlatformImage struct {
+	Platform       string
+	ContainerImage string
+}
+
+// PlatformResult is the outcome of validating the image of one of the
+// platforms of an image index component.
+type PlatformResult struct {
+	Platform       string                      `json:"platform"`
+	ContainerImage string                      `json:"containerImage"`
+	Violations     []evaluator.Result          `json:"violations,omitempty"`
+	Warnings       []evaluator.Result          `json:"warnings,omitempty"`
+	Successes      []evaluator.Result          `json:"successes,omitempty"`
+	Success        bool                        `json:"success"`
+	SuccessCount   int                         `json:"success-count,omitempty"`
+	Signatures     []signature.EntitySignature `json:"signatures,omitempty"`
+	Attestations   []AttestationResult         `json:"attestations,omitempty"`
+}
+
+// IndexPlatforms returns the image of each of the platforms when the image of
+// the component is an image index, and nothing otherwise. Platforms are named
+// <os>/<architecture>[/<variant>], or noarch-<n> when the index does not
+// provide the platform of the nth image.
+func IndexPlatforms(ctx context.Context, component app.SnapshotComponent) ([]PlatformImage, error) {
+	ref, indexManifest, err := imageIndexManifest(oci.NewClient(ctx), component)
+	if err != nil || indexManifest == nil {
+		return nil, err
+	}
+
+	platforms := make([]PlatformImage, 0, len(indexManifest.Manifests))
+	for i, manifest := range indexManifest.Manifests {
+		platform := ""
+		if manifest.Platform != nil {
+			platform = manifest.Platform.String()
+		}
+		if platform == "" {
+			platform = fmt.Sprintf("noarch-%d", i)
+		}
+
+		platforms = append(platforms, PlatformImage{
+			Platform:       platform,
+			ContainerImage: fmt.Sprintf("%s@%s", ref.Context().Name(), manifest.Digest),
+		})
+	}
+
+	return platforms, nil
+}
+
+// SelectPlatforms returns the platform images matching any of the given globs,
+// e.g. "linux/*", or all of them when no globs are given. The images of the
+// required platforms are always selected.
+func SelectPlatforms(platforms []PlatformImage, patterns []string, required []string) []PlatformImage {
+	if len(patterns) == 0 {
+		return platforms
+	}
+
+	selected := make([]PlatformImage, 0, len(platforms))
+	for _, p := range platforms {
+		if slices.Contains(required, p.Platform) {
+			selected = append(selected, p)
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, p.Platform); matched {
+				selected = append(selected, p)
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// ValidatePlatformPatterns checks that the platform globs are well formed.
+func ValidatePlatformPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid platform pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// PolicyRequiredPlatforms returns the platforms listed in the
+// required_platforms rule data of any of the sources of the policy.
+func PolicyRequiredPlatforms(spec ecc.EnterpriseContractPolicySpec) ([]string, error) {
+	var required []string
+	for _, s := range spec.Sources {
+		if s.RuleData == nil {
+			continue
+		}
+
+		var ruleData struct {
+			RequiredPlatforms []string `json:"required_platforms"`
+		}
+		if err := json.Unmarshal(s.RuleData.Raw, &ruleData); err != nil {
+			return nil, fmt.Errorf("unable to read the %s rule data of the policy source %q: %w", RequiredPlatformsRuleData, s.Name, err)
+		}
+
+		for _, p := range ruleData.RequiredPlatforms {
+			if !slices.Contains(required, p) {
+				required = append(required, p)
+			}
+		}
+	}
+
+	return required, nil
+}
+
+// RequiredPlatformViolations returns a violation for each of the required
+// platforms the image index provides no image for, or whose image has no
+// attestations with a valid signature. The images of the required platforms
+// are expected to be validated, see SelectPlatforms.
+func RequiredPlatformViolations(required []string, platforms []PlatformResult) []evaluator.Result {
+	results := map[string]PlatformResult{}
+	for _, p := range platforms {
+		results[p.Platform] = p
+	}
+
+	var violations []evaluator.Result
+	for _, r := range required {
+		var msg string
+		if p, ok := results[r]; !ok {
+			msg = fmt.Sprintf("The image index provides no image for the required platform %s", r)
+		} else if len(p.Attestations) == 0 {
+			msg = fmt.Sprintf("The image of the required platform %s has no signed attestations", r)
+		} else {
+			continue
+		}
+
+		violations = append(violations, evaluator.Result{
+			Message: msg,
+			Metadata: map[string]any{
+				"code": RequiredPlatformCode,
+				"term": r,
+			},
+		})
+	}
+
+	return violations
+}
+
+// platformsWithOptions returns a copy of the platform results holding only
+// the results selected by the options.
+func platformsWithOptions(platforms []PlatformResult, opts format.Options) []PlatformResult {
+	if platforms == nil {
+		return nil
+	}
+
+	filtered := make([]PlatformResult, 0, len(platforms))
+	for _, p := range platforms {
+		p.Violations = opts.FilterResults(p.Violations, format.FailureSeverity)
+		p.Warnings = opts.FilterResults(p.Warnings, format.WarningSeverity)
+		p.Successes = opts.FilterResults(p.Successes, format.SuccessSeverity)
+		if !opts.IncludeSignatures {
+			p.Signatures = nil
+		}
+		if !opts.IncludeAttestations {
+			p.Attestations = nil
+		}
+		filtered = append(filtered, p)
+	}
+
+	return filtered
+}
+
+// withPlatformResults returns a copy of the component holding, along with its
+// own results, the results of each of its platforms marked with the platform
+// they were reported for. The formats that do not list the platforms of a
+// component separately use it so that the outcome of the platforms is
+// reported as well.
+func withPlatformResults(c Component) Component {
+	for _, p := range c.Platforms {
+		c.Violations = appendPlatformResults(c.Violations, p.Platform, p.Violations)
+		c.Warnings = appendPlatformResults(c.Warnings, p.Platform, p.Warnings)
+		c.Successes = appendPlatformResults(c.Successes, p.Platform, p.Successes)
+		c.SuccessCount += p.SuccessCount
+	}
+
+	return c
+}
+
+// appendPlatformResults appends copies of the results of the platform, with
+// the platform in their metadata, to a copy of the given results.
+func appendPlatformResults(results []evaluator.Result, platform string, platformResults []evaluator.Result) []evaluator.Result {
+	if len(platformResults) == 0 {
+		return results
+	}
+
+	// Never modify the backing array of the results of the component
+	results = results[:len(results):len(results)]
+	for _, r := range platformResults {
+		r.Metadata = maps.Clone(r.Metadata)
+		if r.Metadata == nil {
+			r.Metadata = map[string]any{}
+		}
+		r.Metadata[PlatformMetadata] = platform
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// resultImage returns the image the result was reported for, the image of the
+// platform for the results of the platforms, see withPlatformResults, and the
+// image of the component otherwise.
+func resultImage(c Component, r evaluator.Result) string {
+	if platform, ok := r.Metadata[PlatformMetadata].(string); ok {
+		for _, p := range c.Platforms {
+			if p.Platform == platform {
+				return p.ContainerImage
+			}
+		}
+	}
+
+	return c.ContainerImage
+}
@@ -0,0 +1,221 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"context"
+	"testing"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	gcrfake "github.com/google/go-containerregistry/pkg/v1/fake"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
+	"github.com/enterprise-contract/ec-cli/internal/utils/oci/fake"
+)
+
+func TestIndexPlatforms(t *testing.T) {
+	client := fake.FakeClient{}
+	indexRef := name.MustParseReference("registry.io/repository/image:index")
+	client.On("Head", indexRef).Return(&v1.Descriptor{MediaType: types.OCIImageIndex}, nil)
+	imageRef := name.MustParseReference("registry.io/repository/image:single")
+	client.On("Head", imageRef).Return(&v1.Descriptor{MediaType: types.OCIManifestSchema1}, nil)
+
+	index := gcrfake.FakeImageIndex{}
+	index.IndexManifestReturns(&v1.IndexManifest{
+		Manifests: []v1.Descriptor{
+			{
+				Platform: &v1.Platform{OS: "linux", Architecture: "amd64"},
+				Digest:   v1.Hash{Algorithm: "sha256", Hex: "digest1"},
+			},
+			{
+				Platform: &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+				Digest:   v1.Hash{Algorithm: "sha256", Hex: "digest2"},
+			},
+			{
+				Digest: v1.Hash{Algorithm: "sha256", Hex: "digest3"},
+			},
+		},
+	}, nil)
+	client.On("Index", indexRef).Return(&index, nil)
+
+	ctx := oci.WithClient(context.Background(), &client)
+
+	platforms, err := IndexPlatforms(ctx, app.SnapshotComponent{ContainerImage: "registry.io/repository/image:index"})
+	require.NoError(t, err)
+	assert.Equal(t, []PlatformImage{
+		{Platform: "linux/amd64", ContainerImage: "registry.io/repository/image@sha256:digest1"},
+		{Platform: "linux/arm64/v8", ContainerImage: "registry.io/repository/image@sha256:digest2"},
+		{Platform: "noarch-2", ContainerImage: "registry.io/repository/image@sha256:digest3"},
+	}, platforms)
+
+	platforms, err = IndexPlatforms(ctx, app.SnapshotComponent{ContainerImage: "registry.io/repository/image:single"})
+	require.NoError(t, err)
+	assert.Empty(t, platforms)
+
+	_, err = IndexPlatforms(ctx, app.SnapshotComponent{ContainerImage: "not valid"})
+	assert.ErrorContains(t, err, "unable to parse container image")
+}
+
+func TestSelectPlatforms(t *testing.T) {
+	platforms := []PlatformImage{
+		{Platform: "linux/amd64"},
+		{Platform: "linux/arm64/v8"},
+		{Platform: "windows/amd64"},
+	}
+
+	assert.Equal(t, platforms, SelectPlatforms(platforms, nil, nil))
+	assert.Equal(t, platforms[:2], SelectPlatforms(platforms, []string{"linux/*", "linux/*/*"}, nil))
+	assert.Equal(t, []PlatformImage{platforms[0], platforms[2]}, SelectPlatforms(platforms, []string{"*/amd64"}, nil))
+	assert.Empty(t, SelectPlatforms(platforms, []string{"darwin/*"}, nil))
+	assert.Equal(t, []PlatformImage{platforms[1]}, SelectPlatforms(platforms, []string{"darwin/*"}, []string{platforms[1].Platform}))
+
+	assert.NoError(t, ValidatePlatformPatterns([]string{"linux/*"}))
+	assert.EqualError(t, ValidatePlatformPatterns([]string{"linux/["}), `invalid platform pattern "linux/[": syntax error in pattern`)
+}
+
+func TestPolicyRequiredPlatforms(t *testing.T) {
+	required, err := PolicyRequiredPlatforms(ecc.EnterpriseContractPolicySpec{
+		Sources: []ecc.Source{
+			{},
+			{RuleData: &extv1.JSON{Raw: []byte(`{"required_platforms": ["linux/amd64", "linux/arm64"]}`)}},
+			{RuleData: &extv1.JSON{Raw: []byte(`{"required_platforms": ["linux/arm64", "linux/s390x"], "other": 1}`)}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64", "linux/s390x"}, required)
+
+	_, err = PolicyRequiredPlatforms(ecc.EnterpriseContractPolicySpec{
+		Sources: []ecc.Source{{Name: "bad", RuleData: &extv1.JSON{Raw: []byte(`{"required_platforms": "linux/amd64"}`)}}},
+	})
+	assert.ErrorContains(t, err, `unable to read the required_platforms rule data of the policy source "bad"`)
+}
+
+func TestRequiredPlatformViolations(t *testing.T) {
+	platforms := []PlatformResult{
+		{Platform: "linux/amd64", Attestations: []AttestationResult{{Type: "https://in-toto.io/Statement/v0.1"}}},
+		{Platform: "linux/arm64"},
+	}
+
+	assert.Empty(t, RequiredPlatformViolations(nil, platforms))
+	assert.Empty(t, RequiredPlatformViolations([]string{"linux/amd64"}, platforms))
+
+	violations := RequiredPlatformViolations([]string{"linux/amd64", "linux/arm64", "linux/s390x"}, platforms)
+	require.Len(t, violations, 2)
+	assert.Equal(t, "The image of the required platform linux/arm64 has no signed attestations", violations[0].Message)
+	assert.Equal(t, map[string]any{"code": RequiredPlatformCode, "term": "linux/arm64"}, violations[0].Metadata)
+	assert.Equal(t, "The image index provides no image for the required platform linux/s390x", violations[1].Message)
+}
+
+const amd64Digest = "0000000000000000000000000000000000000000000000000000000000000a64"
+
+func platformsReport() Report {
+	return Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "index", ContainerImage: "registry.io/index@" + exclusionsDigest},
+				Violations:        []evaluator.Result{{Message: "index", Metadata: map[string]any{"code": "a.b"}}},
+				Platforms: []PlatformResult{
+					{
+						Platform:       "linux/amd64",
+						ContainerImage: "registry.io/index@sha256:" + amd64Digest,
+						Violations:     []evaluator.Result{{Message: "amd64", Metadata: map[string]any{"code": "c.d"}}},
+						Warnings:       []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "e.f"}}},
+						Successes:      []evaluator.Result{{Message: "Pass", Metadata: map[string]any{"code": "g.h"}}},
+						SuccessCount:   1,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWithPlatformResults(t *testing.T) {
+	r := platformsReport()
+	c := withPlatformResults(r.Components[0])
+
+	assert.Equal(t, []evaluator.Result{
+		{Message: "index", Metadata: map[string]any{"code": "a.b"}},
+		{Message: "amd64", Metadata: map[string]any{"code": "c.d", PlatformMetadata: "linux/amd64"}},
+	}, c.Violations)
+	assert.Equal(t, []evaluator.Result{
+		{Message: "warning", Metadata: map[string]any{"code": "e.f", PlatformMetadata: "linux/amd64"}},
+	}, c.Warnings)
+	assert.Len(t, c.Successes, 1)
+	assert.Equal(t, 1, c.SuccessCount)
+
+	assert.Equal(t, "registry.io/index@"+exclusionsDigest, resultImage(c, c.Violations[0]))
+	assert.Equal(t, "registry.io/index@sha256:"+amd64Digest, resultImage(c, c.Violations[1]))
+
+	// The results of the report are not modified
+	assert.Equal(t, platformsReport(), r)
+}
+
+func TestPlatformResultsInFormats(t *testing.T) {
+	r := platformsReport()
+
+	summary := r.toSummary()
+	require.Len(t, summary.Components, 1)
+	assert.Equal(t, 2, summary.Components[0].TotalViolations)
+	assert.Equal(t, 1, summary.Components[0].TotalWarnings)
+	assert.Equal(t, 1, summary.Components[0].TotalSuccesses)
+
+	appstudio := r.toAppstudioReport()
+	assert.Equal(t, 2, appstudio.Failures)
+	assert.Equal(t, 1, appstudio.Warnings)
+
+	results := r.toSARIF().Runs[0].Results
+	require.Len(t, results, 3)
+	assert.Equal(t, "registry.io/index@"+exclusionsDigest, results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, "registry.io/index@sha256:"+amd64Digest, results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, "registry.io/index@sha256:"+amd64Digest, results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	suites := r.toJUnit()
+	require.Len(t, suites.Suites, 1)
+	assert.Equal(t, 2, suites.Suites[0].Failures)
+
+	assert.Equal(t, []ecc.VolatileCriteria{
+		{Value: "a.b", ImageDigest: exclusionsDigest},
+		{Value: "c.d", ImageDigest: "sha256:" + amd64Digest},
+	}, exclusionCriteria(r.Components, ExclusionOptions{PerImage: true}))
+}
+
+func TestDiffPlatforms(t *testing.T) {
+	old := platformsReport()
+	new := platformsReport()
+	new.Components[0].Platforms[0].Violations = nil
+	new.Components[0].Platforms = append(new.Components[0].Platforms, PlatformResult{
+		Platform:       "linux/arm64",
+		ContainerImage: "registry.io/index@sha256:arm64",
+		Violations:     []evaluator.Result{{Message: "arm64", Metadata: map[string]any{"code": "c.d"}}},
+	})
+
+	diff := Diff(old, new)
+	require.Len(t, diff.Components, 1)
+	assert.True(t, diff.Worse)
+	assert.Equal(t, []ResultChange{{Code: "c.d", Platform: "linux/arm64", Message: "arm64"}}, diff.Components[0].Violations.New)
+	assert.Equal(t, []ResultChange{{Code: "c.d", Platform: "linux/amd64", Message: "amd64"}}, diff.Components[0].Violations.Resolved)
+}
@@ -39,29 +39,53 @@ import (
 
 type Component struct {
 	app.SnapshotComponent
+	// Snapshot is the name of the snapshot the component is part of when
+	// several snapshots are validated at once
+	Snapshot     string                      `json:"snapshot,omitempty"`
 	Violations   []evaluator.Result          `json:"violations,omitempty"`
 	Warnings     []evaluator.Result          `json:"warnings,omitempty"`
 	Successes    []evaluator.Result          `json:"successes,omitempty"`
 	Success      bool                        `json:"success"`
-	SuccessCount int                         `json:"-"`
+	SuccessCount int                         `json:"success-count,omitempty"`
 	Signatures   []signature.EntitySignature `json:"signatures,omitempty"`
 	Attestations []AttestationResult         `json:"attestations,omitempty"`
+	// Platforms holds the outcome for each platform of an image index when
+	// the platforms are validated as part of the index component
+	Platforms []PlatformResult `json:"platforms,omitempty"`
+	Duration  time.Duration    `json:"-"`
+	// RuleInfo holds the full metadata of the rules that produced the
+	// violations and warnings by rule code, regardless of the metadata kept
+	// in the results
+	RuleInfo map[string]map[string]any `json:"-"`
+	// SavedRuleInfo holds the RuleInfo in the JSON and YAML formats when
+	// requested with the include-rule-info option
+	SavedRuleInfo map[string]map[string]any `json:"rule-info,omitempty"`
 }
 
 type Report struct {
-	Success       bool `json:"success"`
-	created       time.Time
+	Success       bool                             `json:"success"`
 	Snapshot      string                           `json:"snapshot,omitempty"`
 	Components    []Component                      `json:"components"`
+	Snapshots     []SnapshotResult                 `json:"snapshots,omitempty"`
+	Skipped       []SkippedComponent               `json:"skipped,omitempty"`
 	Key           string                           `json:"key"`
 	Policy        ecc.EnterpriseContractPolicySpec `json:"policy"`
 	EcVersion     string                           `json:"ec-version"`
+	Created       time.Time                        `json:"created"`
 	Data          any                              `json:"-"`
 	EffectiveTime time.Time                        `json:"effective-time"`
 	PolicyInput   [][]byte                         `json:"-"`
 	ShowSuccesses bool                             `json:"-"`
 }
 
+// SnapshotResult holds the verdict for one of the snapshots validated at once
+// and the names of its components.
+type SnapshotResult struct {
+	Name       string   `json:"name"`
+	Success    bool     `json:"success"`
+	Components []string `json:"components"`
+}
+
 type summary struct {
 	Snapshot   string             `json:"snapshot,omitempty"`
 	Components []componentSummary `json:"components"`
@@ -106,6 +130,14 @@ const (
 	Attestation     = "attestation"
 	PolicyInput     = "policy-input"
 	VSA             = "vsa"
+	SLSAVSA         = "slsa-vsa"
+	SARIF           = "sarif"
+	HTML            = "html"
+	Template        = format.Template
+	Baseline        = "baseline"
+	NDJSON          = "ndjson"
+	OpenMetrics     = "openmetrics"
+	Replay          = "replay"
 	// Deprecated old version of appstudio. Remove some day.
 	HACBS = "hacbs"
 )
@@ -121,6 +153,14 @@ var OutputFormats = []string{
 	Attestation,
 	PolicyInput,
 	VSA,
+	SLSAVSA,
+	SARIF,
+	HTML,
+	Template,
+	Baseline,
+	NDJSON,
+	OpenMetrics,
+	Replay,
 }
 
 // WriteReport returns a new instance of Report representing the state of
@@ -149,7 +189,8 @@ func NewReport(snapshot string, components []Component, policy policy.Policy, po
 		Snapshot:      snapshot,
 		Success:       success,
 		Components:    components,
-		created:       time.Now().UTC(),
+		Snapshots:     snapshotResults(components),
+		Created:       time.Now().UTC(),
 		Key:           string(key),
 		Policy:        policy.Spec(),
 		EcVersion:     info.Version,
@@ -159,6 +200,58 @@ func NewReport(snapshot string, components []Component, policy policy.Policy, po
 	}, nil
 }
 
+// snapshotResults groups the components by the snapshot they are part of, in
+// the order the snapshots first appear. Nothing is returned when the
+// components are not part of named snapshots.
+func snapshotResults(components []Component) []SnapshotResult {
+	var results []SnapshotResult
+	index := map[string]int{}
+	for _, c := range components {
+		if c.Snapshot == "" {
+			continue
+		}
+
+		i, ok := index[c.Snapshot]
+		if !ok {
+			i = len(results)
+			index[c.Snapshot] = i
+			results = append(results, SnapshotResult{Name: c.Snapshot, Success: true, Components: []string{}})
+		}
+
+		results[i].Success = results[i].Success && c.Success
+		results[i].Components = append(results[i].Components, c.Name)
+	}
+
+	return results
+}
+
+// ReadReport parses a report previously written in the JSON or YAML format.
+// Data not included in those formats, like the policy input, is not
+// available in the returned report.
+func ReadReport(data []byte) (Report, error) {
+	var r Report
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return Report{}, fmt.Errorf("unable to parse report: %w", err)
+	}
+
+	// Reports written by older versions do not include the creation time
+	if r.Created.IsZero() {
+		r.Created = time.Now().UTC()
+	}
+	for i := range r.Components {
+		// Reports written by older versions do not include the number of
+		// successes, it is only known if the successes were included
+		if r.Components[i].SuccessCount == 0 {
+			r.Components[i].SuccessCount = len(r.Components[i].Successes)
+		}
+		r.ShowSuccesses = r.ShowSuccesses || len(r.Components[i].Successes) > 0
+		r.Components[i].RuleInfo = r.Components[i].SavedRuleInfo
+		r.Components[i].SavedRuleInfo = nil
+	}
+
+	return r, nil
+}
+
 // WriteAll writes the report to all the given targets.
 func (r Report) WriteAll(targets []string, p format.TargetParser) (allErrors error) {
 	if len(targets) == 0 {
@@ -170,9 +263,14 @@ func (r Report) WriteAll(targets []string, p format.TargetParser) (allErrors err
 			allErrors = errors.Join(allErrors, err)
 			continue
 		}
-		r.applyOptions(target.Options)
+		report := r.withOptions(target.Options)
 
-		data, err := r.toFormat(target.Format)
+		var data []byte
+		if target.Format == Template {
+			data, err = generateUserTemplateReport(report, target.Template)
+		} else {
+			data, err = report.toFormat(target.Format)
+		}
 		if err != nil {
 			allErrors = errors.Join(allErrors, err)
 			continue
@@ -212,6 +310,20 @@ func (r *Report) toFormat(format string) (data []byte, err error) {
 		data = bytes.Join(r.PolicyInput, []byte("\n"))
 	case VSA:
 		data, err = r.toVSA()
+	case SLSAVSA:
+		data, err = r.toSLSAVSA()
+	case SARIF:
+		data, err = json.Marshal(r.toSARIF())
+	case HTML:
+		data, err = generateHTMLReport(r)
+	case Baseline:
+		data, err = json.Marshal(r.toBaseline())
+	case NDJSON:
+		data, err = r.toNDJSON()
+	case OpenMetrics:
+		data = r.toOpenMetrics()
+	case Replay:
+		return nil, errors.New("the replay format writes a directory and is only available when validating images")
 	default:
 		return nil, fmt.Errorf("%q is not a valid report format", format)
 	}
@@ -226,12 +338,34 @@ func (r *Report) toVSA() ([]byte, error) {
 	return json.Marshal(vsa)
 }
 
-// toSummary returns a condensed version of the report.
+// toSLSAVSA renders a SLSA Verification Summary Attestation for each component,
+// one per line.
+func (r *Report) toSLSAVSA() ([]byte, error) {
+	vsas, err := NewSLSAVSAs(*r)
+	if err != nil {
+		return nil, err
+	}
+
+	byts := make([][]byte, 0, len(vsas))
+	for _, vsa := range vsas {
+		b, err := json.Marshal(vsa)
+		if err != nil {
+			return nil, err
+		}
+		byts = append(byts, b)
+	}
+
+	return bytes.Join(byts, []byte{'\n'}), nil
+}
+
+// toSummary returns a condensed version of the report. The results of the
+// platforms of image index components are counted as results of the component.
 func (r *Report) toSummary() summary {
 	pr := summary{
 		Snapshot: r.Snapshot,
 	}
 	for _, cmp := range r.Components {
+		cmp = withPlatformResults(cmp)
 		if !cmp.Success {
 			pr.Success = false
 		}
@@ -255,8 +389,37 @@ func (r *Report) toSummary() summary {
 	return pr
 }
 
-func (r *Report) applyOptions(opts format.Options) {
+// withOptions returns a copy of the report holding only the components and
+// results, including the results of each platform, selected by the options.
+// The success of the report and of the components, and the number of
+// successes, are not affected.
+func (r Report) withOptions(opts format.Options) Report {
 	r.ShowSuccesses = opts.ShowSuccesses
+
+	components := make([]Component, 0, len(r.Components))
+	for _, c := range r.Components {
+		if !opts.ShowsComponent(c.Name, c.ContainerImage, c.Snapshot) {
+			continue
+		}
+
+		c.Violations = opts.FilterResults(c.Violations, format.FailureSeverity)
+		c.Warnings = opts.FilterResults(c.Warnings, format.WarningSeverity)
+		c.Successes = opts.FilterResults(c.Successes, format.SuccessSeverity)
+		if !opts.IncludeSignatures {
+			c.Signatures = nil
+		}
+		if !opts.IncludeAttestations {
+			c.Attestations = nil
+		}
+		if opts.IncludeRuleInfo {
+			c.SavedRuleInfo = c.RuleInfo
+		}
+		c.Platforms = platformsWithOptions(c.Platforms, opts)
+		components = append(components, c)
+	}
+	r.Components = components
+
+	return r
 }
 
 // condensedMsg reduces repetitive error messages.
@@ -303,7 +466,7 @@ func generateMarkdownSummary(r *Report) ([]byte, error) {
 		return ":x:"
 	}
 
-	writeMarkdownField(&markdownBuffer, "Time", r.created.UTC().Format("2006-01-02 15:04:05"), "")
+	writeMarkdownField(&markdownBuffer, "Time", r.Created.UTC().Format("2006-01-02 15:04:05"), "")
 	writeMarkdownField(&markdownBuffer, "Successes", totalSuccesses, writeIcon(totalSuccesses >= 1 && totalViolations == 0))
 	writeMarkdownField(&markdownBuffer, "Failures", totalViolations, writeIcon(totalViolations == 0))
 	writeMarkdownField(&markdownBuffer, "Warnings", totalWarnings, writeIcon(totalWarnings == 0))
@@ -330,6 +493,41 @@ func generateTextReport(r *Report) ([]byte, error) {
 	return utils.RenderFromTemplatesWithMain(input, "text_report.tmpl", efs)
 }
 
+// generateUserTemplateReport renders the report through the template supplied
+// by the user. The template receives the same input as the text report.
+func generateUserTemplateReport(r Report, tmpl string) ([]byte, error) {
+	input := struct {
+		Report     *Report
+		TestReport TestReport
+	}{
+		Report:     &r,
+		TestReport: r.toAppstudioReport(),
+	}
+
+	return utils.RenderFromTemplateText(input, Template, tmpl)
+}
+
+//go:embed templates/html/*.tmpl
+var htmlfs embed.FS
+
+// generateHTMLReport renders the report as a single, self-contained, HTML
+// document
+func generateHTMLReport(r *Report) ([]byte, error) {
+	input := struct {
+		Report        *Report
+		TestReport    TestReport
+		Created       string
+		EffectiveTime string
+	}{
+		Report:        r,
+		TestReport:    r.toAppstudioReport(),
+		Created:       r.Created.UTC().Format(time.RFC3339),
+		EffectiveTime: r.EffectiveTime.UTC().Format(time.RFC3339),
+	}
+
+	return utils.RenderHTMLFromTemplatesWithGlob(input, "html_report.tmpl", []string{"templates/html/*.tmpl"}, htmlfs)
+}
+
 func writeMarkdownField(buffer *bytes.Buffer, name string, value any, icon string) {
 	valueStr := fmt.Sprintf("%v", value)
 	buffer.WriteString(fmt.Sprintf("| %s | %s | %s |\n", name, valueStr, icon))
@@ -339,7 +537,7 @@ func writeMarkdownField(buffer *bytes.Buffer, name string, value any, icon strin
 // TEST_OUTPUT format, usually written to the TEST_OUTPUT Tekton task result
 func (r *Report) toAppstudioReport() TestReport {
 	result := TestReport{
-		Timestamp: fmt.Sprint(r.created.UTC().Unix()),
+		Timestamp: fmt.Sprint(r.Created.UTC().Unix()),
 		// EC generally runs with the AllNamespaces flag set to true
 		// and policies from many namespaces. Rather than try to list
 		// them all in this string field we just leave it blank.
@@ -20,6 +20,7 @@ package applicationsnapshot
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	_ "embed"
 	"encoding/json"
@@ -27,6 +28,7 @@ import (
 	"testing"
 	"time"
 
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
 	"github.com/gkampitakis/go-snaps/snaps"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	"github.com/spf13/afero"
@@ -36,6 +38,7 @@ import (
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 	"github.com/enterprise-contract/ec-cli/internal/format"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/signature"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 )
 
@@ -60,6 +63,7 @@ func Test_ReportJson(t *testing.T) {
     {
       "success": false,
 	  "ec-version": "development",
+	  "created": %q,
 	  "effective-time": %q,
 	  "key": %s,
 	  "snapshot": "snappy",
@@ -92,7 +96,7 @@ func Test_ReportJson(t *testing.T) {
 		"publicKey": %s
 	  }
     }
-  	`, testEffectiveTime, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON)
+  	`, report.Created.Format(time.RFC3339Nano), testEffectiveTime, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON)
 
 	reportJson, err := report.toFormat(JSON)
 	assert.NoError(t, err)
@@ -116,6 +120,7 @@ func Test_ReportYaml(t *testing.T) {
 
 	expected := fmt.Sprintf(`
 success: false
+created: %q
 effective-time: %q
 key: %s
 ec-version: development
@@ -145,7 +150,7 @@ components:
     success: true
 policy:
   publicKey: %s
-`, testEffectiveTime, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON)
+`, report.Created.Format(time.RFC3339Nano), testEffectiveTime, utils.TestPublicKeyJSON, utils.TestPublicKeyJSON)
 
 	reportYaml, err := report.toFormat(YAML)
 	assert.NoError(t, err)
@@ -258,7 +263,7 @@ func Test_GenerateMarkdownSummary(t *testing.T) {
 			ctx := context.Background()
 			report, err := NewReport(c.snapshot, c.components, createTestPolicy(t, ctx), nil, true)
 			assert.NoError(t, err)
-			report.created = time.Unix(0, 0).UTC()
+			report.Created = time.Unix(0, 0).UTC()
 
 			markdownSummary, err := generateMarkdownSummary(&report)
 			assert.NoError(t, err)
@@ -642,12 +647,12 @@ func Test_ReportAppstudio(t *testing.T) {
 			ctx := context.Background()
 			report, err := NewReport(c.snapshot, c.components, createTestPolicy(t, ctx), nil, true)
 			assert.NoError(t, err)
-			assert.False(t, report.created.IsZero())
+			assert.False(t, report.Created.IsZero())
 			assert.Equal(t, c.success, report.Success)
 
-			report.created = time.Unix(0, 0).UTC()
+			report.Created = time.Unix(0, 0).UTC()
 
-			p := format.NewTargetParser(JSON, format.Options{}, defaultWriter, fs)
+			p := format.NewTargetParser(JSON, format.DefaultOptions(), defaultWriter, fs)
 			assert.NoError(t, report.WriteAll([]string{"appstudio=report.json", "appstudio"}, p))
 
 			reportText, err := afero.ReadFile(fs, "report.json")
@@ -790,12 +795,12 @@ func Test_ReportHACBS(t *testing.T) {
 			ctx := context.Background()
 			report, err := NewReport(c.snapshot, c.components, createTestPolicy(t, ctx), nil, true)
 			assert.NoError(t, err)
-			assert.False(t, report.created.IsZero())
+			assert.False(t, report.Created.IsZero())
 			assert.Equal(t, c.success, report.Success)
 
-			report.created = time.Unix(0, 0).UTC()
+			report.Created = time.Unix(0, 0).UTC()
 
-			p := format.NewTargetParser(JSON, format.Options{}, defaultWriter, fs)
+			p := format.NewTargetParser(JSON, format.DefaultOptions(), defaultWriter, fs)
 			assert.NoError(t, report.WriteAll([]string{"hacbs=report.json", "hacbs"}, p))
 
 			reportText, err := afero.ReadFile(fs, "report.json")
@@ -823,13 +828,99 @@ func Test_ReportPolicyInput(t *testing.T) {
 	report, err := NewReport("snapshot", nil, createTestPolicy(t, ctx), policyInput, true)
 	require.NoError(t, err)
 
-	p := format.NewTargetParser(JSON, format.Options{}, defaultWriter, fs)
+	p := format.NewTargetParser(JSON, format.DefaultOptions(), defaultWriter, fs)
 	require.NoError(t, report.WriteAll([]string{"policy-input=policy-input.yaml", "policy-input"}, p))
 
 	matchesJSONLFile(t, fs, policyInput, "policy-input.yaml")
 	matchesJSONLFile(t, fs, policyInput, "default")
 }
 
+func Test_ReadReport(t *testing.T) {
+	r, err := ReadReport([]byte(`{
+		"success": true,
+		"components": [
+			{"name": "one", "containerImage": "registry.io/one:tag", "successes": [{"msg": "Pass"}], "success": true},
+			{"name": "two", "containerImage": "registry.io/two:tag", "success": true}
+		],
+		"ec-version": "v1.2.3",
+		"created": "2024-01-02T03:04:06Z",
+		"effective-time": "2024-01-02T03:04:05Z"
+	}`))
+	require.NoError(t, err)
+
+	assert.True(t, r.Success)
+	assert.True(t, r.ShowSuccesses)
+	assert.Equal(t, "v1.2.3", r.EcVersion)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), r.EffectiveTime)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC), r.Created)
+	require.Len(t, r.Components, 2)
+	assert.Equal(t, 1, r.Components[0].SuccessCount)
+	assert.Equal(t, 0, r.Components[1].SuccessCount)
+
+	// reports written by older versions have no creation time
+	r, err = ReadReport([]byte(`{"success": true, "components": []}`))
+	require.NoError(t, err)
+	assert.False(t, r.Created.IsZero())
+
+	_, err = ReadReport([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestRuleInfoRoundTrip(t *testing.T) {
+	report := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one:tag"},
+				Violations:        []evaluator.Result{{Message: "Fail", Metadata: map[string]any{"code": "a.b"}}},
+				RuleInfo:          map[string]map[string]any{"a.b": {"code": "a.b", "title": "Title", "description": "Description"}},
+			},
+		},
+	}
+
+	for _, c := range []struct {
+		target   string
+		expected map[string]map[string]any
+	}{
+		{target: "json", expected: nil},
+		{target: "json?include-rule-info=true", expected: report.Components[0].RuleInfo},
+		{target: "yaml?include-rule-info=true", expected: report.Components[0].RuleInfo},
+	} {
+		t.Run(c.target, func(t *testing.T) {
+			var out bytes.Buffer
+			p := format.NewTargetParser(JSON, format.DefaultOptions(), &out, afero.NewMemMapFs())
+			require.NoError(t, report.WriteAll([]string{c.target}, p))
+
+			read, err := ReadReport(out.Bytes())
+			require.NoError(t, err)
+			require.Len(t, read.Components, 1)
+			assert.Equal(t, c.expected, read.Components[0].RuleInfo)
+			assert.Nil(t, read.Components[0].SavedRuleInfo)
+		})
+	}
+}
+
+func Test_ReportUserTemplate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.tmpl", []byte(
+		`{{ .TestReport.Result }}{{ range .Report.Components }} {{ indicator "fail" }} {{ .Name }}{{ end }}`), 0400))
+
+	report := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "spam"},
+				Violations:        []evaluator.Result{{Message: "violation"}},
+			},
+		},
+	}
+
+	p := format.NewTargetParser(JSON, format.DefaultOptions(), nil, fs)
+	require.NoError(t, report.WriteAll([]string{"template=report.tmpl?file=report.txt"}, p))
+
+	out, err := afero.ReadFile(fs, "report.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "FAILURE ✕ spam\n", string(out))
+}
+
 func Test_TextReport(t *testing.T) {
 	warnings := []evaluator.Result{
 		{
@@ -921,6 +1012,75 @@ func Test_TextReport(t *testing.T) {
 				},
 			},
 		}},
+		{"snapshots", Report{
+			Components: []Component{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-1",
+						ContainerImage: "registry.io/repository/component-1:tag",
+					},
+					Snapshot:   "snapshot-1",
+					Violations: violations,
+				},
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-2",
+						ContainerImage: "registry.io/repository/component-2:tag",
+					},
+					Snapshot: "snapshot-2",
+					Success:  true,
+				},
+			},
+			Snapshots: []SnapshotResult{
+				{Name: "snapshot-1", Components: []string{"component-1"}},
+				{Name: "snapshot-2", Success: true, Components: []string{"component-2"}},
+			},
+		}},
+		{"skipped", Report{
+			Success: true,
+			Components: []Component{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-1",
+						ContainerImage: "registry.io/repository/component-1:tag",
+					},
+					Success: true,
+				},
+			},
+			Skipped: []SkippedComponent{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-2",
+						ContainerImage: "registry.io/repository/component-2:tag",
+					},
+					Reason: SkippedReason,
+				},
+			},
+		}},
+		{"platforms", Report{
+			Success: false,
+			Components: []Component{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-1",
+						ContainerImage: "registry.io/repository/component-1:tag",
+					},
+					Platforms: []PlatformResult{
+						{
+							Platform:       "linux/amd64",
+							ContainerImage: "registry.io/repository/component-1@sha256:amd64",
+							Success:        true,
+							SuccessCount:   3,
+						},
+						{
+							Platform:       "linux/arm64",
+							ContainerImage: "registry.io/repository/component-1@sha256:arm64",
+							Violations:     violations,
+						},
+					},
+				},
+			},
+		}},
 	}
 
 	for _, c := range cases {
@@ -934,6 +1094,111 @@ func Test_TextReport(t *testing.T) {
 	}
 }
 
+func TestSnapshotResults(t *testing.T) {
+	component := func(name, snapshot string, success bool) Component {
+		return Component{SnapshotComponent: app.SnapshotComponent{Name: name}, Snapshot: snapshot, Success: success}
+	}
+
+	assert.Nil(t, snapshotResults([]Component{component("a", "", true)}))
+
+	assert.Equal(t, []SnapshotResult{
+		{Name: "two", Success: false, Components: []string{"b", "c"}},
+		{Name: "one", Success: true, Components: []string{"a"}},
+	}, snapshotResults([]Component{
+		component("b", "two", true),
+		component("a", "one", true),
+		component("c", "two", false),
+	}))
+}
+
+func Test_HTMLReport(t *testing.T) {
+	cases := []struct {
+		name   string
+		report Report
+	}{
+		{"nothing", Report{}},
+		{"bunch", Report{
+			Snapshot:      "my-snapshot",
+			ShowSuccesses: true,
+			EcVersion:     "v1.2.3",
+			Key:           "-----BEGIN PUBLIC KEY-----\nkey\n-----END PUBLIC KEY-----",
+			Policy: ecc.EnterpriseContractPolicySpec{
+				Name:        "policy",
+				Description: "A <policy>",
+				Sources: []ecc.Source{
+					{
+						Name:   "release",
+						Policy: []string{"oci::registry.io/policy:latest"},
+						Data:   []string{"oci::registry.io/data:latest"},
+					},
+				},
+			},
+			Components: []Component{
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-1",
+						ContainerImage: "registry.io/repository/component-1:tag",
+					},
+					Violations: []evaluator.Result{
+						{
+							Message: "Violation <1> message",
+							Metadata: map[string]any{
+								"code":              "violation-1",
+								"title":             "Violation 1 title",
+								"description":       "Violation 1 description",
+								"solution":          "Violation 1 solution",
+								"term":              "term",
+								"documentation_url": "https://docs.example.com/violation-1",
+							},
+						},
+					},
+					Warnings: []evaluator.Result{
+						{Message: "Warning 1 message", Metadata: map[string]any{"code": "warning-1"}},
+					},
+					Successes: []evaluator.Result{
+						{Message: "Pass", Metadata: map[string]any{"code": "success-1", "title": "Success 1 title"}},
+					},
+					SuccessCount: 1,
+					Signatures: []signature.EntitySignature{
+						{
+							KeyID:       "key-id",
+							Certificate: "-----BEGIN CERTIFICATE-----\ncert\n-----END CERTIFICATE-----",
+							Metadata: map[string]string{
+								"Issuer":  "CN=sigstore-intermediate,O=sigstore.dev",
+								"Subject": "https://github.com/user/repo",
+							},
+						},
+					},
+					Attestations: []AttestationResult{
+						{
+							Type:               "https://in-toto.io/Statement/v0.1",
+							PredicateType:      "https://slsa.dev/provenance/v0.2",
+							PredicateBuildType: "https://tekton.dev/attestations/chains/pipelinerun@v2",
+						},
+					},
+				},
+				{
+					SnapshotComponent: app.SnapshotComponent{
+						Name:           "component-2",
+						ContainerImage: "registry.io/repository/component-2:tag",
+					},
+					Success: true,
+				},
+			},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := c.report
+			output, err := r.toFormat(HTML)
+			require.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(output))
+		})
+	}
+}
+
 func matchesJSONLFile(t *testing.T, fs afero.Fs, expected [][]byte, filename string) {
 	f, err := fs.Open(filename)
 	require.NoError(t, err)
@@ -998,3 +1263,110 @@ func createTestPolicy(t *testing.T, ctx context.Context) policy.Policy {
 	assert.NoError(t, err)
 	return p
 }
+
+func TestWithOptions(t *testing.T) {
+	r := Report{
+		Success: false,
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "app-one", ContainerImage: "registry.io/one:latest"},
+				Violations: []evaluator.Result{
+					{Message: "a rather long violation message", Metadata: map[string]any{"code": "tasks.required"}},
+					{Message: "cve", Metadata: map[string]any{"code": "cve.found"}},
+				},
+				Warnings:     []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "tasks.trusted"}}},
+				Successes:    []evaluator.Result{{Message: "Pass", Metadata: map[string]any{"code": "tasks.pinned"}}},
+				SuccessCount: 1,
+				Signatures:   []signature.EntitySignature{{KeyID: "key"}},
+				Attestations: []AttestationResult{{Type: "type"}},
+				Platforms: []PlatformResult{
+					{
+						Platform:       "linux/amd64",
+						ContainerImage: "registry.io/one@sha256:abc",
+						Violations: []evaluator.Result{
+							{Message: "a rather long platform violation", Metadata: map[string]any{"code": "tasks.required"}},
+							{Message: "cve", Metadata: map[string]any{"code": "cve.found"}},
+						},
+						Warnings:     []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "tasks.trusted"}}},
+						Signatures:   []signature.EntitySignature{{KeyID: "key"}},
+						Attestations: []AttestationResult{{Type: "type"}},
+					},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "other", ContainerImage: "registry.io/other:latest"},
+				Success:           true,
+			},
+		},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		opts := format.DefaultOptions()
+		opts.ShowSuccesses = true
+		assert.Equal(t, Report{Success: false, Components: r.Components, ShowSuccesses: true}, r.withOptions(opts))
+	})
+
+	t.Run("terse", func(t *testing.T) {
+		opts := format.Options{
+			Severity:         format.FailureSeverity,
+			Component:        "app-*",
+			Rules:            "tasks.*",
+			MaxMessageLength: 12,
+		}
+
+		filtered := r.withOptions(opts)
+		assert.Equal(t, []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "app-one", ContainerImage: "registry.io/one:latest"},
+				Violations: []evaluator.Result{
+					{Message: "a rather ...", Metadata: map[string]any{"code": "tasks.required"}},
+				},
+				Warnings:     []evaluator.Result{},
+				Successes:    []evaluator.Result{},
+				SuccessCount: 1,
+				Platforms: []PlatformResult{
+					{
+						Platform:       "linux/amd64",
+						ContainerImage: "registry.io/one@sha256:abc",
+						Violations: []evaluator.Result{
+							{Message: "a rather ...", Metadata: map[string]any{"code": "tasks.required"}},
+						},
+						Warnings: []evaluator.Result{},
+					},
+				},
+			},
+		}, filtered.Components)
+		assert.False(t, filtered.Success)
+
+		// The original report is not modified
+		assert.Len(t, r.Components, 2)
+		assert.Equal(t, "a rather long violation message", r.Components[0].Violations[0].Message)
+		assert.Len(t, r.Components[0].Signatures, 1)
+		assert.Equal(t, "a rather long platform violation", r.Components[0].Platforms[0].Violations[0].Message)
+	})
+}
+
+func TestWriteAllWithOptions(t *testing.T) {
+	r := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one:latest"},
+				Violations:        []evaluator.Result{{Message: "violation", Metadata: map[string]any{"code": "a.b"}}},
+				Warnings:          []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "c.d"}}},
+			},
+		},
+	}
+
+	fs := afero.NewMemMapFs()
+	p := format.NewTargetParser(JSON, format.DefaultOptions(), nil, fs)
+	require.NoError(t, r.WriteAll([]string{"json=full.json", "json=terse.json?show-warnings=false"}, p))
+
+	full, err := afero.ReadFile(fs, "full.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(full), `"warnings"`)
+
+	terse, err := afero.ReadFile(fs, "terse.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(terse), `"warnings"`)
+	assert.Contains(t, string(terse), `"violations"`)
+}
@@ -0,0 +1,215 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "ec"
+	sarifToolURI  = "https://enterprisecontract.dev"
+)
+
+// The types below model the subset of the SARIF 2.1.0 specification used by
+// the report, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string               `json:"id"`
+	ShortDescription *sarifMessage        `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage        `json:"fullDescription,omitempty"`
+	Help             *sarifMessage        `json:"help,omitempty"`
+	HelpURI          string               `json:"helpUri,omitempty"`
+	Properties       *sarifRuleProperties `json:"properties,omitempty"`
+}
+
+type sarifRuleProperties struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// toSARIF returns a version of the report in the SARIF format. Each rule that
+// produced a violation or a warning is described as a SARIF rule, and each
+// violation or warning as a result located at the image of the component, or
+// of the platform of an image index component it was reported for.
+func (r *Report) toSARIF() sarifLog {
+	rules := []sarifRule{}
+	ruleIndexes := map[string]int{}
+	results := []sarifResult{}
+
+	add := func(c Component, res evaluator.Result, level string) {
+		image := resultImage(c, res)
+		result := sarifResult{
+			Level:   level,
+			Message: sarifMessage{Text: res.Message},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: image},
+					},
+					LogicalLocations: []sarifLogicalLocation{
+						{
+							Name:               c.Name,
+							FullyQualifiedName: image,
+							Kind:               "component",
+						},
+					},
+				},
+			},
+		}
+
+		if code, ok := res.Metadata["code"].(string); ok && code != "" {
+			idx, seen := ruleIndexes[code]
+			if !seen {
+				idx = len(rules)
+				ruleIndexes[code] = idx
+				rules = append(rules, asSARIFRule(code, ruleMetadata(c, code, res)))
+			}
+			result.RuleID = code
+			result.RuleIndex = &idx
+		}
+
+		results = append(results, result)
+	}
+
+	for _, c := range r.Components {
+		c = withPlatformResults(c)
+		for _, v := range c.Violations {
+			add(c, v, "error")
+		}
+		for _, w := range c.Warnings {
+			add(c, w, "warning")
+		}
+	}
+
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           sarifToolName,
+						Version:        r.EcVersion,
+						InformationURI: sarifToolURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// ruleMetadata returns the metadata describing the rule with the given code,
+// the rule info of the component complements the metadata of the result which
+// is reduced unless the report was created with the --info flag.
+func ruleMetadata(c Component, code string, res evaluator.Result) map[string]any {
+	info, ok := c.RuleInfo[code]
+	if !ok {
+		return res.Metadata
+	}
+
+	metadata := maps.Clone(info)
+	maps.Copy(metadata, res.Metadata)
+	return metadata
+}
+
+// asSARIFRule describes the rule from the rule information found in the
+// metadata.
+func asSARIFRule(code string, metadata map[string]any) sarifRule {
+	text := func(key string) *sarifMessage {
+		if v, ok := metadata[key]; ok && v != "" {
+			return &sarifMessage{Text: fmt.Sprint(v)}
+		}
+		return nil
+	}
+
+	rule := sarifRule{
+		ID:               code,
+		ShortDescription: text("title"),
+		FullDescription:  text("description"),
+		Help:             text("solution"),
+	}
+
+	if url, ok := metadata["documentation_url"].(string); ok {
+		rule.HelpURI = url
+	}
+
+	if collections, ok := metadata["collections"].([]string); ok && len(collections) > 0 {
+		rule.Properties = &sarifRuleProperties{Tags: collections}
+	}
+
+	return rule
+}
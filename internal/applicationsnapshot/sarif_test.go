@@ -0,0 +1,191 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"encoding/json"
+	"testing"
+
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func TestAsSARIFRule(t *testing.T) {
+	cases := []struct {
+		name     string
+		result   evaluator.Result
+		expected sarifRule
+	}{
+		{
+			name:     "code only",
+			result:   evaluator.Result{Metadata: map[string]any{"code": "a.b"}},
+			expected: sarifRule{ID: "a.b"},
+		},
+		{
+			name: "with rule info",
+			result: evaluator.Result{Metadata: map[string]any{
+				"code":              "a.b",
+				"title":             "Title",
+				"description":       "Description",
+				"solution":          "Solution",
+				"documentation_url": "https://docs.example.com/a#b",
+				"collections":       []string{"minimal", "slsa3"},
+			}},
+			expected: sarifRule{
+				ID:               "a.b",
+				ShortDescription: &sarifMessage{Text: "Title"},
+				FullDescription:  &sarifMessage{Text: "Description"},
+				Help:             &sarifMessage{Text: "Solution"},
+				HelpURI:          "https://docs.example.com/a#b",
+				Properties:       &sarifRuleProperties{Tags: []string{"minimal", "slsa3"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, asSARIFRule("a.b", c.result.Metadata))
+		})
+	}
+}
+
+func TestToSARIF(t *testing.T) {
+	r := Report{
+		EcVersion: "v1.2.3",
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:abc"},
+				Violations: []evaluator.Result{
+					{Message: "violation", Metadata: map[string]any{"code": "a.b", "title": "A B"}},
+				},
+				Warnings: []evaluator.Result{
+					{Message: "warning", Metadata: map[string]any{"code": "c.d"}},
+				},
+				Successes: []evaluator.Result{
+					{Message: "Pass", Metadata: map[string]any{"code": "e.f"}},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two@sha256:def"},
+				Violations: []evaluator.Result{
+					{Message: "violation", Metadata: map[string]any{"code": "a.b", "title": "A B"}},
+					{Message: "no code"},
+				},
+			},
+		},
+	}
+
+	location := func(name, image string) []sarifLocation {
+		return []sarifLocation{
+			{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: image}},
+				LogicalLocations: []sarifLogicalLocation{{Name: name, FullyQualifiedName: image, Kind: "component"}},
+			},
+		}
+	}
+	zero, one := 0, 1
+
+	assert.Equal(t, sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "ec",
+						Version:        "v1.2.3",
+						InformationURI: "https://enterprisecontract.dev",
+						Rules: []sarifRule{
+							{ID: "a.b", ShortDescription: &sarifMessage{Text: "A B"}},
+							{ID: "c.d"},
+						},
+					},
+				},
+				Results: []sarifResult{
+					{
+						RuleID:    "a.b",
+						RuleIndex: &zero,
+						Level:     "error",
+						Message:   sarifMessage{Text: "violation"},
+						Locations: location("one", "registry.io/one@sha256:abc"),
+					},
+					{
+						RuleID:    "c.d",
+						RuleIndex: &one,
+						Level:     "warning",
+						Message:   sarifMessage{Text: "warning"},
+						Locations: location("one", "registry.io/one@sha256:abc"),
+					},
+					{
+						RuleID:    "a.b",
+						RuleIndex: &zero,
+						Level:     "error",
+						Message:   sarifMessage{Text: "violation"},
+						Locations: location("two", "registry.io/two@sha256:def"),
+					},
+					{
+						Level:     "error",
+						Message:   sarifMessage{Text: "no code"},
+						Locations: location("two", "registry.io/two@sha256:def"),
+					},
+				},
+			},
+		},
+	}, r.toSARIF())
+
+	data, err := r.toFormat(SARIF)
+	require.NoError(t, err)
+	assert.True(t, json.Valid(data))
+}
+
+func TestToSARIFRuleInfo(t *testing.T) {
+	r := Report{
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:abc"},
+				// without --info the metadata of the results is reduced
+				Violations: []evaluator.Result{
+					{Message: "violation", Metadata: map[string]any{"code": "a.b", "term": "x"}},
+				},
+				RuleInfo: map[string]map[string]any{
+					"a.b": {
+						"code":              "a.b",
+						"title":             "Title",
+						"description":       "Description",
+						"solution":          "Solution",
+						"documentation_url": "https://docs.example.com/a#b",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []sarifRule{
+		{
+			ID:               "a.b",
+			ShortDescription: &sarifMessage{Text: "Title"},
+			FullDescription:  &sarifMessage{Text: "Description"},
+			Help:             &sarifMessage{Text: "Solution"},
+			HelpURI:          "https://docs.example.com/a#b",
+		},
+	}, r.toSARIF().Runs[0].Tool.Driver.Rules)
+}
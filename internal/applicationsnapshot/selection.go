@@ -0,0 +1,106 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"fmt"
+	"path"
+
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+)
+
+// Reasons for skipping the validation of a component.
+const (
+	NotSelectedReason = "not selected"
+	SkippedReason     = "skipped"
+	NotFailedReason   = "not failed previously"
+)
+
+// SkippedComponent is a component that was not validated, and why.
+type SkippedComponent struct {
+	app.SnapshotComponent
+	Snapshot string `json:"snapshot,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// ComponentSelection selects the components of a snapshot to validate.
+// Components are selected if they match any of the Components globs, or if
+// there are none, and do not match any of the SkipComponents globs. The globs
+// are matched against the component name and image. With a previous report
+// given in Failed, only the components that failed in it are selected.
+type ComponentSelection struct {
+	Components     []string
+	SkipComponents []string
+	Failed         *Report
+}
+
+// Validate checks that the globs of the selection are well formed.
+func (s ComponentSelection) Validate() error {
+	for _, pattern := range append(append([]string{}, s.Components...), s.SkipComponents...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid component pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Select returns the selected components of the snapshot with the given name
+// and the components that were skipped.
+func (s ComponentSelection) Select(snapshot string, components []app.SnapshotComponent) ([]app.SnapshotComponent, []SkippedComponent) {
+	var failed map[string]bool
+	if s.Failed != nil {
+		failed = map[string]bool{}
+		for _, c := range s.Failed.Components {
+			if !c.Success {
+				failed[componentKey(c)] = true
+			}
+		}
+	}
+
+	selected := make([]app.SnapshotComponent, 0, len(components))
+	var skipped []SkippedComponent
+	for _, c := range components {
+		reason := ""
+		switch {
+		case failed != nil && !failed[componentKey(Component{SnapshotComponent: c, Snapshot: snapshot})]:
+			reason = NotFailedReason
+		case len(s.Components) > 0 && !matchesAny(s.Components, c):
+			reason = NotSelectedReason
+		case matchesAny(s.SkipComponents, c):
+			reason = SkippedReason
+		}
+
+		if reason == "" {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, SkippedComponent{SnapshotComponent: c, Snapshot: snapshot, Reason: reason})
+		}
+	}
+
+	return selected, skipped
+}
+
+func matchesAny(patterns []string, c app.SnapshotComponent) bool {
+	for _, pattern := range patterns {
+		for _, n := range []string{c.Name, c.ContainerImage} {
+			if matched, _ := path.Match(pattern, n); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
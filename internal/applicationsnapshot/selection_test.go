@@ -0,0 +1,102 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package applicationsnapshot
+
+import (
+	"testing"
+
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentSelection(t *testing.T) {
+	components := []app.SnapshotComponent{
+		{Name: "spam", ContainerImage: "registry.io/spam:1"},
+		{Name: "spam-extra", ContainerImage: "registry.io/spam-extra:1"},
+		{Name: "bacon", ContainerImage: "registry.io/bacon:1"},
+		{Name: "eggs", ContainerImage: "registry.io/eggs:1"},
+	}
+
+	previous := &Report{
+		Components: []Component{
+			{SnapshotComponent: app.SnapshotComponent{Name: "spam"}, Snapshot: "one"},
+			{SnapshotComponent: app.SnapshotComponent{Name: "bacon"}, Snapshot: "one", Success: true},
+			{SnapshotComponent: app.SnapshotComponent{Name: "eggs"}, Snapshot: "two"},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		selection ComponentSelection
+		selected  []string
+		skipped   map[string]string
+	}{
+		{
+			name:     "everything",
+			selected: []string{"spam", "spam-extra", "bacon", "eggs"},
+		},
+		{
+			name:      "components",
+			selection: ComponentSelection{Components: []string{"spam*", "registry.io/eggs:*"}},
+			selected:  []string{"spam", "spam-extra", "eggs"},
+			skipped:   map[string]string{"bacon": NotSelectedReason},
+		},
+		{
+			name:      "skip components",
+			selection: ComponentSelection{Components: []string{"spam*"}, SkipComponents: []string{"*-extra"}},
+			selected:  []string{"spam"},
+			skipped:   map[string]string{"spam-extra": SkippedReason, "bacon": NotSelectedReason, "eggs": NotSelectedReason},
+		},
+		{
+			name:      "failed",
+			selection: ComponentSelection{Failed: previous},
+			selected:  []string{"spam"},
+			skipped:   map[string]string{"spam-extra": NotFailedReason, "bacon": NotFailedReason, "eggs": NotFailedReason},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.NoError(t, c.selection.Validate())
+
+			selected, skipped := c.selection.Select("one", components)
+
+			names := []string{}
+			for _, s := range selected {
+				names = append(names, s.Name)
+			}
+			assert.Equal(t, c.selected, names)
+
+			reasons := map[string]string{}
+			for _, s := range skipped {
+				assert.Equal(t, "one", s.Snapshot)
+				reasons[s.Name] = s.Reason
+			}
+			if c.skipped == nil {
+				c.skipped = map[string]string{}
+			}
+			assert.Equal(t, c.skipped, reasons)
+		})
+	}
+}
+
+func TestComponentSelectionInvalid(t *testing.T) {
+	err := ComponentSelection{SkipComponents: []string{"spam["}}.Validate()
+	assert.EqualError(t, err, `invalid component pattern "spam[": syntax error in pattern`)
+}
@@ -17,7 +17,20 @@
 package applicationsnapshot
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
 )
 
 const (
@@ -59,3 +72,233 @@ func getSubjects(report Report) ([]in_toto.Subject, error) {
 	}
 	return subjects, nil
 }
+
+const (
+	// PredicateSLSAVSA is the predicate type of the SLSA Verification Summary
+	// Attestation, see https://slsa.dev/spec/v1.0/verification_summary
+	PredicateSLSAVSA = "https://slsa.dev/verification_summary/v1"
+	// VerifierID identifies the ec-cli as the verifier in SLSA VSAs
+	VerifierID = "https://github.com/enterprise-contract/ec-cli"
+
+	vsaPassed       = "PASSED"
+	vsaFailed       = "FAILED"
+	vsaSLSAVersion  = "1.0"
+	vsaBuildLevelFm = "SLSA_BUILD_LEVEL_%s"
+)
+
+// matches the collections that map to a SLSA build track level, e.g. @slsa3
+var slsaCollection = regexp.MustCompile(`^@?slsa([0-3])$`)
+
+type VSAVerifier struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type VSAResourceDescriptor struct {
+	URI    string           `json:"uri,omitempty"`
+	Digest common.DigestSet `json:"digest,omitempty"`
+}
+
+// VSAPredicate is the predicate of a SLSA Verification Summary Attestation v1
+type VSAPredicate struct {
+	Verifier           VSAVerifier             `json:"verifier"`
+	TimeVerified       time.Time               `json:"timeVerified"`
+	ResourceURI        string                  `json:"resourceUri"`
+	Policy             VSAResourceDescriptor   `json:"policy"`
+	InputAttestations  []VSAResourceDescriptor `json:"inputAttestations,omitempty"`
+	VerificationResult string                  `json:"verificationResult"`
+	VerifiedLevels     []string                `json:"verifiedLevels"`
+	SlsaVersion        string                  `json:"slsaVersion"`
+}
+
+type SLSAVerificationSummary struct {
+	in_toto.StatementHeader
+	Predicate VSAPredicate `json:"predicate"`
+}
+
+// NewSLSAVSAs creates a SLSA Verification Summary Attestation for each of the
+// components in the report. The subject of each is the image of the component,
+// components with images not pinned by digest are skipped.
+func NewSLSAVSAs(report Report) ([]SLSAVerificationSummary, error) {
+	policy, err := policyDescriptor(report.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := verifiedLevels(report.Policy)
+
+	vsas := make([]SLSAVerificationSummary, 0, len(report.Components))
+	for _, c := range report.Components {
+		subject, ok := componentSubject(c)
+		if !ok {
+			log.Warnf("Not creating a VSA for the image %q, it is not referenced by digest", c.ContainerImage)
+			continue
+		}
+
+		result := vsaFailed
+		verified := []string{vsaFailed}
+		if c.Success {
+			result = vsaPassed
+			verified = levels
+		}
+
+		vsas = append(vsas, SLSAVerificationSummary{
+			StatementHeader: in_toto.StatementHeader{
+				Type:          StatmentVSA,
+				PredicateType: PredicateSLSAVSA,
+				Subject:       []in_toto.Subject{subject},
+			},
+			Predicate: VSAPredicate{
+				Verifier: VSAVerifier{
+					ID:      VerifierID,
+					Version: map[string]string{"ec-cli": report.EcVersion},
+				},
+				TimeVerified:       report.Created,
+				ResourceURI:        c.ContainerImage,
+				Policy:             policy,
+				InputAttestations:  inputAttestations(c),
+				VerificationResult: result,
+				VerifiedLevels:     verified,
+				SlsaVersion:        vsaSLSAVersion,
+			},
+		})
+	}
+
+	return vsas, nil
+}
+
+// componentSubject returns the subject for the image of the component, which
+// is only available when the image is pinned by digest.
+func componentSubject(c Component) (in_toto.Subject, bool) {
+	ref, err := name.NewDigest(c.ContainerImage)
+	if err != nil {
+		return in_toto.Subject{}, false
+	}
+
+	algorithm, hex, _ := strings.Cut(ref.DigestStr(), ":")
+	return in_toto.Subject{
+		Name:   ref.Context().Name(),
+		Digest: common.DigestSet{algorithm: hex},
+	}, true
+}
+
+// policyDescriptor describes the policy by the URI of its first policy source
+// and the digest of the whole, resolved, policy specification.
+func policyDescriptor(spec ecc.EnterpriseContractPolicySpec) (VSAResourceDescriptor, error) {
+	js, err := json.Marshal(spec)
+	if err != nil {
+		return VSAResourceDescriptor{}, err
+	}
+
+	var uri string
+	for _, s := range spec.Sources {
+		if len(s.Policy) > 0 {
+			uri = sourceURI(s.Policy[0])
+			break
+		}
+	}
+
+	return VSAResourceDescriptor{
+		URI:    uri,
+		Digest: common.DigestSet{"sha256": fmt.Sprintf("%x", sha256.Sum256(js))},
+	}, nil
+}
+
+// sourceURI returns the URI of the policy source given in the go-getter
+// format, e.g. "oci::registry.io/policy:latest" or
+// "github.com/org/repo//policy", as used in the policy configuration.
+func sourceURI(url string) string {
+	getter, rest, forced := strings.Cut(url, "::")
+	if !forced {
+		getter, rest = "", url
+	}
+	hasScheme := strings.Contains(rest, "://")
+
+	switch {
+	case getter == "oci" || (getter == "" && source.SourceIsOci(url)):
+		if hasScheme {
+			return rest
+		}
+		return "oci://" + rest
+	case getter == "git" || (getter == "" && source.SourceIsGit(url)):
+		if hasScheme {
+			return "git+" + rest
+		}
+		return "git+https://" + rest
+	case getter == "" && source.SourceIsHttp(url):
+		if hasScheme {
+			return rest
+		}
+		return "https://" + rest
+	case getter == "" && source.SourceIsFile(url):
+		return "file://" + rest
+	}
+
+	return url
+}
+
+func inputAttestations(c Component) []VSAResourceDescriptor {
+	var attestations []VSAResourceDescriptor
+	for _, a := range c.Attestations {
+		if len(a.Statement) == 0 {
+			continue
+		}
+		attestations = append(attestations, VSAResourceDescriptor{
+			URI:    a.PredicateType,
+			Digest: common.DigestSet{"sha256": fmt.Sprintf("%x", sha256.Sum256(a.Statement))},
+		})
+	}
+
+	return attestations
+}
+
+// verifiedLevels returns the highest SLSA build level verified by the policy.
+// A level is verified only when the policy includes the whole SLSA collection
+// of that level, e.g. @slsa3, without excluding any of the rules, which would
+// otherwise be able to exclude the very checks the level requires.
+func verifiedLevels(spec ecc.EnterpriseContractPolicySpec) []string {
+	excludes := false
+	if spec.Configuration != nil {
+		excludes = len(spec.Configuration.Exclude) > 0
+	}
+
+	collections := []string{}
+	if spec.Configuration != nil && !excludes {
+		allIncluded := true
+		for _, s := range spec.Sources {
+			if hasExclusions(s) {
+				allIncluded = false
+			}
+		}
+		if allIncluded {
+			collections = append(collections, spec.Configuration.Include...)
+			collections = append(collections, spec.Configuration.Collections...)
+		}
+	}
+	for _, s := range spec.Sources {
+		if s.Config != nil && !excludes && !hasExclusions(s) {
+			collections = append(collections, s.Config.Include...)
+		}
+	}
+
+	level := ""
+	for _, col := range collections {
+		if m := slsaCollection.FindStringSubmatch(col); m != nil && m[1] > level {
+			level = m[1]
+		}
+	}
+
+	if level == "" {
+		return []string{}
+	}
+
+	return []string{fmt.Sprintf(vsaBuildLevelFm, level)}
+}
+
+func hasExclusions(s ecc.Source) bool {
+	if s.Config != nil && len(s.Config.Exclude) > 0 {
+		return true
+	}
+
+	return s.VolatileConfig != nil && len(s.VolatileConfig.Exclude) > 0
+}
@@ -19,15 +19,20 @@
 package applicationsnapshot
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
 	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	app "github.com/konflux-ci/application-api/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
@@ -114,6 +119,217 @@ func TestSubjects(t *testing.T) {
 	assert.Equal(t, expected, subjects)
 }
 
+func TestNewSLSAVSAs(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	statement := []byte(`{"subject":[{"name":"registry.io/repository/image","digest":{"sha256":"abc"}}]}`)
+
+	report := Report{
+		Created:   created,
+		EcVersion: "v1.2.3",
+		Policy: ecc.EnterpriseContractPolicySpec{
+			Sources: []ecc.Source{
+				{
+					Policy: []string{"oci::registry.io/policy:latest"},
+					Config: &ecc.SourceConfig{Include: []string{"@slsa3"}},
+				},
+			},
+		},
+		Components: []Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{
+					Name:           "passing",
+					ContainerImage: "registry.io/repository/image@sha256:c3a4b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1",
+				},
+				Success: true,
+				Attestations: []AttestationResult{
+					{PredicateType: "https://slsa.dev/provenance/v0.2", Statement: statement},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{
+					Name:           "failing",
+					ContainerImage: "registry.io/repository/image@sha256:abc1b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1",
+				},
+				Success: false,
+				Attestations: []AttestationResult{
+					{PredicateType: "https://slsa.dev/provenance/v0.2", Statement: statement},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{
+					Name:           "unpinned",
+					ContainerImage: "registry.io/repository/image:tag",
+				},
+				Success: true,
+				Attestations: []AttestationResult{
+					{PredicateType: "https://slsa.dev/provenance/v0.2", Statement: statement},
+				},
+			},
+		},
+	}
+
+	policyJSON, err := json.Marshal(report.Policy)
+	require.NoError(t, err)
+	policyDigest := fmt.Sprintf("%x", sha256.Sum256(policyJSON))
+	statementDigest := fmt.Sprintf("%x", sha256.Sum256(statement))
+
+	vsas, err := NewSLSAVSAs(report)
+	require.NoError(t, err)
+
+	verifier := VSAVerifier{ID: VerifierID, Version: map[string]string{"ec-cli": "v1.2.3"}}
+	policy := VSAResourceDescriptor{URI: "oci://registry.io/policy:latest", Digest: common.DigestSet{"sha256": policyDigest}}
+	inputs := []VSAResourceDescriptor{
+		{URI: "https://slsa.dev/provenance/v0.2", Digest: common.DigestSet{"sha256": statementDigest}},
+	}
+
+	assert.Equal(t, []SLSAVerificationSummary{
+		{
+			StatementHeader: in_toto.StatementHeader{
+				Type:          "https://in-toto.io/Statement/v1",
+				PredicateType: "https://slsa.dev/verification_summary/v1",
+				Subject: []in_toto.Subject{
+					{
+						Name:   "registry.io/repository/image",
+						Digest: common.DigestSet{"sha256": "c3a4b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1"},
+					},
+				},
+			},
+			Predicate: VSAPredicate{
+				Verifier:           verifier,
+				TimeVerified:       created,
+				ResourceURI:        "registry.io/repository/image@sha256:c3a4b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1",
+				Policy:             policy,
+				InputAttestations:  inputs,
+				VerificationResult: "PASSED",
+				VerifiedLevels:     []string{"SLSA_BUILD_LEVEL_3"},
+				SlsaVersion:        "1.0",
+			},
+		},
+		{
+			StatementHeader: in_toto.StatementHeader{
+				Type:          "https://in-toto.io/Statement/v1",
+				PredicateType: "https://slsa.dev/verification_summary/v1",
+				Subject: []in_toto.Subject{
+					{
+						Name:   "registry.io/repository/image",
+						Digest: common.DigestSet{"sha256": "abc1b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1"},
+					},
+				},
+			},
+			Predicate: VSAPredicate{
+				Verifier:           verifier,
+				TimeVerified:       created,
+				ResourceURI:        "registry.io/repository/image@sha256:abc1b1bcf1b1e1ba6a1b1cd6ef52b7c6db2bd2b0f1ce0cd5a4b5b5bb1b2ad3e1",
+				Policy:             policy,
+				InputAttestations:  inputs,
+				VerificationResult: "FAILED",
+				VerifiedLevels:     []string{"FAILED"},
+				SlsaVersion:        "1.0",
+			},
+		},
+	}, vsas)
+
+	data, err := report.toFormat(SLSAVSA)
+	require.NoError(t, err)
+	assert.Len(t, bytes.Split(data, []byte{'\n'}), 2)
+}
+
+func TestVerifiedLevels(t *testing.T) {
+	cases := []struct {
+		name     string
+		spec     ecc.EnterpriseContractPolicySpec
+		expected []string
+	}{
+		{
+			name:     "no slsa collections",
+			expected: []string{},
+		},
+		{
+			name: "from policy configuration",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Configuration: &ecc.EnterpriseContractPolicyConfiguration{Include: []string{"@minimal", "@slsa2"}},
+			},
+			expected: []string{"SLSA_BUILD_LEVEL_2"},
+		},
+		{
+			name: "highest level wins",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Sources: []ecc.Source{
+					{Config: &ecc.SourceConfig{Include: []string{"@slsa1"}}},
+					{Config: &ecc.SourceConfig{Include: []string{"@slsa3"}}},
+				},
+			},
+			expected: []string{"SLSA_BUILD_LEVEL_3"},
+		},
+		{
+			name: "source with exclusions",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Sources: []ecc.Source{
+					{Config: &ecc.SourceConfig{Include: []string{"@slsa1"}}},
+					{Config: &ecc.SourceConfig{Include: []string{"@slsa3"}, Exclude: []string{"slsa_build_scripted_build"}}},
+				},
+			},
+			expected: []string{"SLSA_BUILD_LEVEL_1"},
+		},
+		{
+			name: "source with volatile exclusions",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Sources: []ecc.Source{
+					{
+						Config:         &ecc.SourceConfig{Include: []string{"@slsa3"}},
+						VolatileConfig: &ecc.VolatileSourceConfig{Exclude: []ecc.VolatileCriteria{{Value: "slsa_source_version_controlled"}}},
+					},
+				},
+			},
+			expected: []string{},
+		},
+		{
+			name: "policy configuration with exclusions",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Configuration: &ecc.EnterpriseContractPolicyConfiguration{Exclude: []string{"slsa_build_scripted_build"}},
+				Sources: []ecc.Source{
+					{Config: &ecc.SourceConfig{Include: []string{"@slsa3"}}},
+				},
+			},
+			expected: []string{},
+		},
+		{
+			name: "policy configuration with exclusions in a source",
+			spec: ecc.EnterpriseContractPolicySpec{
+				Configuration: &ecc.EnterpriseContractPolicyConfiguration{Include: []string{"@slsa3"}},
+				Sources: []ecc.Source{
+					{Config: &ecc.SourceConfig{Exclude: []string{"slsa_build_scripted_build"}}},
+				},
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, verifiedLevels(c.spec))
+		})
+	}
+}
+
+func TestSourceURI(t *testing.T) {
+	cases := map[string]string{
+		"oci::registry.io/policy:latest":                    "oci://registry.io/policy:latest",
+		"quay.io/org/policy:latest":                         "oci://quay.io/org/policy:latest",
+		"github.com/org/repo//policy":                       "git+https://github.com/org/repo//policy",
+		"git::https://github.com/org/repo//policy?ref=abc":  "git+https://github.com/org/repo//policy?ref=abc",
+		"https://example.com/policy.tar.gz":                 "https://example.com/policy.tar.gz",
+		"/tmp/policy":                                       "file:///tmp/policy",
+		"s3::https://s3.amazonaws.com/bucket/policy.tar.gz": "s3::https://s3.amazonaws.com/bucket/policy.tar.gz",
+	}
+
+	for url, expected := range cases {
+		t.Run(url, func(t *testing.T) {
+			assert.Equal(t, expected, sourceURI(url))
+		})
+	}
+}
+
 func toJson(policy any) string {
 	newInline, err := json.Marshal(policy)
 	if err != nil {
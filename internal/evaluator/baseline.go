@@ -0,0 +1,94 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+// BaselinedMetadata is the metadata key set on warnings that were downgraded
+// from violations because they are present in the baseline.
+const BaselinedMetadata = "baselined"
+
+// ResultKey identifies the result of a rule by the code and the term of the
+// rule.
+type ResultKey struct {
+	Code string
+	Term string
+}
+
+// KeyOf returns the key identifying the given result.
+func KeyOf(r Result) ResultKey {
+	return ResultKey{
+		Code: ExtractStringFromMetadata(r, "code"),
+		Term: ExtractStringFromMetadata(r, "term"),
+	}
+}
+
+// Baseline holds the keys of the known violations of a component or of an
+// input.
+type Baseline map[ResultKey]bool
+
+// NewBaseline returns the Baseline holding the given violations, and the
+// warnings that were downgraded from violations by a previous baseline.
+func NewBaseline(violations, warnings []Result) Baseline {
+	b := Baseline{}
+	for _, r := range BaselineViolations(violations, warnings) {
+		b[KeyOf(r)] = true
+	}
+
+	return b
+}
+
+// Apply downgrades the violations present in the baseline to warnings marked
+// as baselined. The remaining violations and the warnings are returned.
+func (b Baseline) Apply(violations, warnings []Result) ([]Result, []Result) {
+	remaining := make([]Result, 0, len(violations))
+	for _, r := range violations {
+		if !b[KeyOf(r)] {
+			remaining = append(remaining, r)
+			continue
+		}
+		warnings = append(warnings, MarkBaselined(r))
+	}
+
+	return remaining, warnings
+}
+
+// BaselineViolations returns the results to include in a baseline: the
+// violations and the warnings that were downgraded from violations.
+func BaselineViolations(violations, warnings []Result) []Result {
+	results := append([]Result{}, violations...)
+	for _, w := range warnings {
+		if IsBaselined(w) {
+			results = append(results, w)
+		}
+	}
+
+	return results
+}
+
+// MarkBaselined marks the result as downgraded from a violation.
+func MarkBaselined(r Result) Result {
+	if r.Metadata == nil {
+		r.Metadata = map[string]any{}
+	}
+	r.Metadata[BaselinedMetadata] = true
+	return r
+}
+
+// IsBaselined returns true if the result was downgraded from a violation.
+func IsBaselined(r Result) bool {
+	b, _ := r.Metadata[BaselinedMetadata].(bool)
+	return b
+}
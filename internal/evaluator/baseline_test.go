@@ -0,0 +1,59 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseline(t *testing.T) {
+	result := func(code, term, msg string) Result {
+		r := Result{Message: msg, Metadata: map[string]any{"code": code}}
+		if term != "" {
+			r.Metadata["term"] = term
+		}
+		return r
+	}
+
+	b := NewBaseline(
+		[]Result{result("a.b", "t1", "violation")},
+		[]Result{result("w.x", "", "warning"), MarkBaselined(result("a.c", "", "baselined"))},
+	)
+	assert.Equal(t, Baseline{
+		{Code: "a.b", Term: "t1"}: true,
+		{Code: "a.c"}:             true,
+	}, b)
+
+	violations, warnings := b.Apply(
+		[]Result{result("a.b", "t1", "changed"), result("a.b", "t2", "other term"), result("a.c", "", "baselined")},
+		[]Result{result("w.x", "", "warning")},
+	)
+	assert.Equal(t, []Result{result("a.b", "t2", "other term")}, violations)
+	assert.Equal(t, []Result{
+		result("w.x", "", "warning"),
+		MarkBaselined(result("a.b", "t1", "changed")),
+		MarkBaselined(result("a.c", "", "baselined")),
+	}, warnings)
+
+	assert.True(t, IsBaselined(warnings[1]))
+	assert.False(t, IsBaselined(warnings[0]))
+	assert.Equal(t, []Result{result("a.b", "t2", "other term"), warnings[1], warnings[2]}, BaselineViolations(violations, warnings))
+}
@@ -131,35 +131,48 @@ func trim(results *[]Outcome) {
 // Use the term if one is provided so it's as specific as possible.
 func excludeDirectives(code string, rawTerm any) string {
 	output := []string{}
+	for _, value := range ExclusionValues(code, rawTerm) {
+		output = append(output, fmt.Sprintf(`"%s"`, value))
+	}
+
+	prefix := ""
+	if len(output) > 1 {
+		// For required tasks I think just the first one would be sufficient, but I'm
+		// not sure if that's always true, so let's give some slightly vague advice
+		prefix = "one or more of "
+	}
+
+	// Put it all together and return a string
+	return fmt.Sprintf("%s%s", prefix, strings.Join(output, ", "))
+}
+
+// ExclusionValues returns the values that can be added to the exclude section
+// of the policy configuration to skip the result of the rule with the given
+// code and term. The term can be a single string or a list of strings, when
+// no term is provided the code of the rule is returned.
+func ExclusionValues(code string, rawTerm any) []string {
+	output := []string{}
 
 	if term, ok := rawTerm.(string); ok && term != "" {
 		// A single term was provided
-		output = append(output, fmt.Sprintf(`"%s:%s"`, code, term))
+		output = append(output, fmt.Sprintf("%s:%s", code, term))
 	}
 
 	if rawTerms, ok := rawTerm.([]any); ok {
 		// Multiple terms were provided
 		for _, t := range rawTerms {
 			if term, ok := t.(string); ok && term != "" {
-				output = append(output, fmt.Sprintf(`"%s:%s"`, code, term))
+				output = append(output, fmt.Sprintf("%s:%s", code, term))
 			}
 		}
 	}
 
 	if len(output) == 0 {
 		// No terms were provided (or some unexpected edge case)
-		output = append(output, fmt.Sprintf(`"%s"`, code))
-	}
-
-	prefix := ""
-	if len(output) > 1 {
-		// For required tasks I think just the first one would be sufficient, but I'm
-		// not sure if that's always true, so let's give some slightly vague advice
-		prefix = "one or more of "
+		output = append(output, code)
 	}
 
-	// Put it all together and return a string
-	return fmt.Sprintf("%s%s", prefix, strings.Join(output, ", "))
+	return output
 }
 
 type testRunner interface {
@@ -167,17 +180,18 @@ type testRunner interface {
 }
 
 const (
-	effectiveOnFormat   = "2006-01-02T15:04:05Z"
-	effectiveOnTimeout  = -90 * 24 * time.Hour // keep effective_on metadata up to 90 days
-	metadataCode        = "code"
-	metadataCollections = "collections"
-	metadataDependsOn   = "depends_on"
-	metadataDescription = "description"
-	metadataSeverity    = "severity"
-	metadataEffectiveOn = "effective_on"
-	metadataSolution    = "solution"
-	metadataTerm        = "term"
-	metadataTitle       = "title"
+	effectiveOnFormat        = "2006-01-02T15:04:05Z"
+	effectiveOnTimeout       = -90 * 24 * time.Hour // keep effective_on metadata up to 90 days
+	metadataCode             = "code"
+	metadataCollections      = "collections"
+	metadataDependsOn        = "depends_on"
+	metadataDescription      = "description"
+	metadataDocumentationUrl = "documentation_url"
+	metadataSeverity         = "severity"
+	metadataEffectiveOn      = "effective_on"
+	metadataSolution         = "solution"
+	metadataTerm             = "term"
+	metadataTitle            = "title"
 )
 
 const (
@@ -676,6 +690,9 @@ func addMetadataToResults(ctx context.Context, r *Result, rule rule.Info) {
 	if rule.Solution != "" {
 		r.Metadata[metadataSolution] = rule.Solution
 	}
+	if rule.DocumentationUrl != "" {
+		r.Metadata[metadataDocumentationUrl] = rule.DocumentationUrl
+	}
 	if len(rule.Collections) > 0 {
 		r.Metadata[metadataCollections] = rule.Collections
 	}
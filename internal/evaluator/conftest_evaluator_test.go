@@ -1425,6 +1425,10 @@ func TestRuleMetadata(t *testing.T) {
 			Description: "Warning 3 description",
 			EffectiveOn: effectiveOnTest,
 		},
+		"failure3": rule.Info{
+			Title:            "Failure3",
+			DocumentationUrl: "https://docs.example.com/failure3",
+		},
 	}
 	cases := []struct {
 		name   string
@@ -1504,6 +1508,22 @@ func TestRuleMetadata(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "add documentation url",
+			result: Result{
+				Metadata: map[string]any{
+					"code": "failure3",
+				},
+			},
+			rules: rules,
+			want: Result{
+				Metadata: map[string]any{
+					"code":              "failure3",
+					"title":             "Failure3",
+					"documentation_url": "https://docs.example.com/failure3",
+				},
+			},
+		},
 		{
 			name: "rule not found",
 			result: Result{
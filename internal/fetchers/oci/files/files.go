@@ -20,6 +20,8 @@ import (
 	"archive/tar"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"path"
 	"runtime/trace"
@@ -40,9 +42,30 @@ type Extractor interface {
 
 type Matcher func(*tar.Header) bool
 
+// Limits restrict the files read by ImageFilesWithLimits, zero values impose no limit.
+type Limits struct {
+	// MaxSize is the maximum combined size, in bytes, of the files read
+	MaxSize int64
+	// MaxFiles is the maximum number of files read
+	MaxFiles int
+	// Reserve, when set, is called with the size of each file before it is read, returning an
+	// error stops the extraction. It allows enforcing limits spanning multiple extractions.
+	Reserve func(size int64) error
+}
+
+// ErrLimitExceeded is returned, wrapped, when the files exceed the given Limits.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
 var supportedExtensions = []string{".yaml", ".yml", ".json"}
 
 func ImageFiles(ctx context.Context, ref name.Reference, extractors []Extractor) (map[string]json.RawMessage, error) {
+	return ImageFilesWithLimits(ctx, ref, extractors, Limits{})
+}
+
+// ImageFilesWithLimits extracts the files matched by the extractors from the image, like
+// ImageFiles, failing as soon as the files exceed the given limits. The limits are checked using
+// the sizes from the archive headers, so the content of offending files is never read.
+func ImageFilesWithLimits(ctx context.Context, ref name.Reference, extractors []Extractor, limits Limits) (map[string]json.RawMessage, error) {
 	if trace.IsEnabled() {
 		region := trace.StartRegion(ctx, "ec:image-fetch-image-files")
 		defer region.End()
@@ -72,6 +95,8 @@ func ImageFiles(ctx context.Context, ref name.Reference, extractors []Extractor)
 	archive := tar.NewReader(content)
 
 	files := map[string]json.RawMessage{}
+	var count int
+	var size int64
 	for {
 		header, err := archive.Next()
 		if err != nil {
@@ -86,6 +111,20 @@ func ImageFiles(ctx context.Context, ref name.Reference, extractors []Extractor)
 				continue
 			}
 
+			count++
+			size += header.Size
+			if limits.MaxFiles > 0 && count > limits.MaxFiles {
+				return nil, fmt.Errorf("%w: more than %d files", ErrLimitExceeded, limits.MaxFiles)
+			}
+			if limits.MaxSize > 0 && size > limits.MaxSize {
+				return nil, fmt.Errorf("%w: files larger than %d bytes", ErrLimitExceeded, limits.MaxSize)
+			}
+			if limits.Reserve != nil {
+				if err := limits.Reserve(header.Size); err != nil {
+					return nil, err
+				}
+			}
+
 			// TODO: large files could be an issue. We do need to read the archive
 			// in one pass making it difficult to not to buffer in memory.
 			// Offloading to disk and read at the time of JSON marshalling the input
@@ -22,6 +22,7 @@ import (
 	"archive/tar"
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/crane"
@@ -124,6 +125,64 @@ func TestCustomExtractor(t *testing.T) {
 	}, files)
 }
 
+func TestImageFilesWithLimits(t *testing.T) {
+	ref := name.MustParseReference("registry.io/repository/image:tag")
+
+	image, err := crane.Image(map[string][]byte{
+		"manifests/a.json": []byte(`{"a":1}`),
+		"manifests/b.yaml": []byte(`b: 2`),
+	})
+	require.NoError(t, err)
+
+	errReserve := errors.New("no more")
+
+	cases := []struct {
+		name   string
+		limits Limits
+		err    error
+	}{
+		{
+			name:   "within limits",
+			limits: Limits{MaxSize: 11, MaxFiles: 2},
+		},
+		{
+			name:   "too many files",
+			limits: Limits{MaxFiles: 1},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:   "too large",
+			limits: Limits{MaxSize: 10},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name: "reserve fails",
+			limits: Limits{Reserve: func(int64) error {
+				return errReserve
+			}},
+			err: errReserve,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("Image", ref).Return(image, nil)
+
+			ctx := oci.WithClient(context.Background(), &client)
+
+			files, err := ImageFilesWithLimits(ctx, ref, []Extractor{PathExtractor{Path: "manifests"}}, c.limits)
+			if c.err != nil {
+				assert.ErrorIs(t, err, c.err)
+				assert.Nil(t, files)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, files, 2)
+			}
+		})
+	}
+}
+
 func TestShouldFilter(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -17,24 +17,80 @@
 package format
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/url"
+	"os"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/afero"
+	"golang.org/x/exp/slices"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 )
 
+// Template is the format used to render through a user supplied Go template.
+// For it the path following the equals sign is the path of the template, the
+// output is written to the path given in the "file" option, for example:
+// template=report.tmpl?file=report.txt
+const Template = "template"
+
 // Target represents a writer with a specified format.
 type Target struct {
 	Format  string
 	Options Options
-	writer  io.Writer
+	// Template holds the text of the user supplied template when the format
+	// is Template
+	Template string
+	// Path holds the path given for the target, empty when writing to the
+	// default writer
+	Path   string
+	writer io.Writer
 }
 
+// Severities of the results, in increasing order
+const (
+	SuccessSeverity = "success"
+	WarningSeverity = "warning"
+	FailureSeverity = "failure"
+)
+
+var severities = []string{SuccessSeverity, WarningSeverity, FailureSeverity}
+
 // options that can be configured per Target
 type Options struct {
 	ShowSuccesses bool
+	ShowWarnings  bool
+	// Severity is the minimum severity of the results shown
+	Severity string
+	// Component is a glob matching the names, or images, of the components
+	// shown
+	Component string
+	// Rules is a glob matching the codes of the rules of the results shown
+	Rules               string
+	IncludeSignatures   bool
+	IncludeAttestations bool
+	// IncludeRuleInfo includes the information about the rules that produced
+	// the violations and warnings in the JSON and YAML formats, so that their
+	// descriptions are available when converting a report saved without the
+	// --info flag
+	IncludeRuleInfo bool
+	// MaxMessageLength truncates the messages of the results to the given
+	// number of characters, 0 means no limit
+	MaxMessageLength int
+}
+
+// DefaultOptions returns the options showing everything apart from the
+// successes.
+func DefaultOptions() Options {
+	return Options{
+		ShowWarnings:        true,
+		IncludeSignatures:   true,
+		IncludeAttestations: true,
+	}
 }
 
 // mutate parses the given string as URL query parameters and sets the fields
@@ -45,17 +101,136 @@ func (o *Options) mutate(given string) error {
 		return err
 	}
 
-	if v := vals.Get("show-successes"); v != "" {
-		if f, err := strconv.ParseBool(v); err == nil {
-			o.ShowSuccesses = f
+	bools := map[string]*bool{
+		"show-successes":       &o.ShowSuccesses,
+		"show-warnings":        &o.ShowWarnings,
+		"include-signatures":   &o.IncludeSignatures,
+		"include-attestations": &o.IncludeAttestations,
+		"include-rule-info":    &o.IncludeRuleInfo,
+	}
+	for name, field := range bools {
+		if v := vals.Get(name); v != "" {
+			if f, err := strconv.ParseBool(v); err == nil {
+				*field = f
+			} else {
+				return err
+			}
+		}
+	}
+
+	// The option is given as severity>=<severity>, which is parsed as the
+	// "severity>" key
+	if v := vals.Get("severity>"); v != "" {
+		if !slices.Contains(severities, v) {
+			return fmt.Errorf("invalid severity %q, expected one of: %s", v, strings.Join(severities, ", "))
+		}
+		o.Severity = v
+	}
+
+	globs := map[string]*string{
+		"component": &o.Component,
+		"rules":     &o.Rules,
+	}
+	for name, field := range globs {
+		if v := vals.Get(name); v != "" {
+			if _, err := path.Match(v, ""); err != nil {
+				return fmt.Errorf("invalid %s glob %q: %w", name, v, err)
+			}
+			*field = v
+		}
+	}
+
+	if v := vals.Get("max-message-length"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l >= 0 {
+			o.MaxMessageLength = l
 		} else {
-			return err
+			return fmt.Errorf("invalid max-message-length %q, expected a positive number", v)
 		}
 	}
 
 	return nil
 }
 
+// ShowsComponent returns true if the component, identified by any of the
+// given names, is to be shown.
+func (o Options) ShowsComponent(names ...string) bool {
+	if o.Component == "" {
+		return true
+	}
+
+	for _, n := range names {
+		if matched, _ := path.Match(o.Component, n); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShowsResult returns true if the result of the given severity, produced by
+// the rule with the given code, is to be shown.
+func (o Options) ShowsResult(severity, code string) bool {
+	switch severity {
+	case SuccessSeverity:
+		if !o.ShowSuccesses {
+			return false
+		}
+	case WarningSeverity:
+		if !o.ShowWarnings {
+			return false
+		}
+	}
+
+	if o.Severity != "" && slices.Index(severities, severity) < slices.Index(severities, o.Severity) {
+		return false
+	}
+
+	if o.Rules != "" {
+		matched, _ := path.Match(o.Rules, code)
+		return matched
+	}
+
+	return true
+}
+
+// FilterResults returns the results of the given severity to be shown, with
+// their messages truncated to the maximum message length.
+func (o Options) FilterResults(results []evaluator.Result, severity string) []evaluator.Result {
+	if results == nil {
+		return nil
+	}
+
+	filtered := make([]evaluator.Result, 0, len(results))
+	for _, res := range results {
+		if !o.ShowsResult(severity, evaluator.ExtractStringFromMetadata(res, "code")) {
+			continue
+		}
+		res.Message = o.Message(res.Message)
+		filtered = append(filtered, res)
+	}
+
+	return filtered
+}
+
+// Message returns the message truncated to the maximum message length.
+func (o Options) Message(msg string) string {
+	if o.MaxMessageLength == 0 {
+		return msg
+	}
+
+	runes := []rune(msg)
+	if len(runes) <= o.MaxMessageLength {
+		return msg
+	}
+
+	const ellipsis = "..."
+	if o.MaxMessageLength <= len(ellipsis) {
+		return string(runes[:o.MaxMessageLength])
+	}
+
+	return string(runes[:o.MaxMessageLength-len(ellipsis)]) + ellipsis
+}
+
 // Write proxies the write operation to the underlying writer.
 func (t *Target) Write(data []byte) (int, error) {
 	return t.writer.Write(data)
@@ -94,6 +269,25 @@ func (tm *TargetParser) Parse(given string) (*Target, error) {
 		target.Format = tm.defaultFormat
 	}
 
+	if target.Format == Template {
+		if path == "" {
+			return nil, errors.New("the template format requires a path to the template, e.g. template=<path>")
+		}
+
+		tmpl, err := afero.ReadFile(tm.fs, path)
+		if err != nil {
+			return nil, err
+		}
+		target.Template = string(tmpl)
+
+		vals, err := url.ParseQuery(opts)
+		if err != nil {
+			return nil, err
+		}
+		path = vals.Get("file")
+	}
+
+	target.Path = path
 	if path != "" {
 		target.writer = &fileWriter{path: path, fs: tm.fs}
 	}
@@ -101,17 +295,27 @@ func (tm *TargetParser) Parse(given string) (*Target, error) {
 	return &target, nil
 }
 
-// fileWriter implements a simple Writer wrapper for afero.Fs.
+// fileWriter implements a simple Writer wrapper for afero.Fs. The file is
+// truncated on the first write, subsequent writes append to it so that the
+// output can be streamed.
 type fileWriter struct {
-	path string
-	fs   afero.Fs
+	path    string
+	fs      afero.Fs
+	written bool
 }
 
-func (w fileWriter) Write(data []byte) (int, error) {
-	file, err := w.fs.Create(w.path)
+func (w *fileWriter) Write(data []byte) (int, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if w.written {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := w.fs.OpenFile(w.path, flag, 0666)
 	if err != nil {
 		return 0, err
 	}
 	defer file.Close()
+	w.written = true
+
 	return file.Write(data)
 }
@@ -24,6 +24,8 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 )
 
 func TestTargetParser(t *testing.T) {
@@ -47,17 +49,34 @@ func TestTargetParser(t *testing.T) {
 		{name: "format and option", expectedFormat: "spam", expectedOptions: Options{ShowSuccesses: true}, targetName: "spam?show-successes=true"},
 		{name: "format no file with option", expectedFormat: "spam", expectedOptions: Options{ShowSuccesses: true}, targetName: "spam=?show-successes=true"},
 		{name: "format with file and option", expectedFormat: "spam", expectedOptions: Options{ShowSuccesses: true}, targetName: "spam=spam.out?show-successes=true", expectedPath: "spam.out"},
+		{
+			name:           "all options",
+			expectedFormat: "spam",
+			expectedOptions: Options{
+				ShowSuccesses:       true,
+				ShowWarnings:        true,
+				Severity:            "warning",
+				Component:           "app-*",
+				Rules:               "tasks.*",
+				IncludeSignatures:   true,
+				IncludeAttestations: true,
+				IncludeRuleInfo:     true,
+				MaxMessageLength:    80,
+			},
+			targetName: "spam?show-successes=true&show-warnings=true&severity>=warning&component=app-*&rules=tasks.*&include-signatures=true&include-attestations=true&include-rule-info=true&max-message-length=80",
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			fs := afero.NewMemMapFs()
-			defaultWriter := fileWriter{path: defaultPath, fs: fs}
+			defaultWriter := &fileWriter{path: defaultPath, fs: fs}
 			parser := NewTargetParser(defaultFormat, defaultOptions, defaultWriter, fs)
 			target, err := parser.Parse(c.targetName)
 			require.NoError(t, err)
 
 			assert.Equal(t, c.expectedFormat, target.Format)
+			assert.Equal(t, c.expectedPath, target.Path)
 			if c.expectedPath == "" {
 				assert.Equal(t, defaultWriter, target.writer)
 			} else {
@@ -69,6 +88,30 @@ func TestTargetParser(t *testing.T) {
 	}
 }
 
+func TestTargetParserTemplate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "report.tmpl", []byte("{{ . }}"), 0400))
+	defaultWriter := &fileWriter{path: "default.out", fs: fs}
+	parser := NewTargetParser("default", Options{}, defaultWriter, fs)
+
+	target, err := parser.Parse("template=report.tmpl?file=report.txt&show-successes=true")
+	require.NoError(t, err)
+	assert.Equal(t, Template, target.Format)
+	assert.Equal(t, "{{ . }}", target.Template)
+	assert.Equal(t, "report.txt", target.writer.(*fileWriter).path)
+	assert.Equal(t, Options{ShowSuccesses: true}, target.Options)
+
+	target, err = parser.Parse("template=report.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, defaultWriter, target.writer)
+
+	_, err = parser.Parse("template")
+	assert.EqualError(t, err, "the template format requires a path to the template, e.g. template=<path>")
+
+	_, err = parser.Parse("template=missing.tmpl")
+	assert.Error(t, err)
+}
+
 func TestSimpleFileWriter(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	writer := fileWriter{path: "out", fs: fs}
@@ -78,3 +121,108 @@ func TestSimpleFileWriter(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "spam", string(actual))
 }
+
+func TestFileWriterAppends(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "out", []byte("previous"), 0644))
+
+	writer := fileWriter{path: "out", fs: fs}
+	_, err := writer.Write([]byte("spam\n"))
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("eggs\n"))
+	assert.NoError(t, err)
+
+	actual, err := afero.ReadFile(fs, "out")
+	assert.NoError(t, err)
+	assert.Equal(t, "spam\neggs\n", string(actual))
+}
+
+func TestInvalidOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		options string
+		err     string
+	}{
+		{name: "boolean", options: "show-warnings=maybe", err: `strconv.ParseBool: parsing "maybe": invalid syntax`},
+		{name: "severity", options: "severity>=fatal", err: `invalid severity "fatal", expected one of: success, warning, failure`},
+		{name: "glob", options: "rules=[", err: `invalid rules glob "[": syntax error in pattern`},
+		{name: "length", options: "max-message-length=-1", err: `invalid max-message-length "-1", expected a positive number`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parser := NewTargetParser("default", DefaultOptions(), nil, afero.NewMemMapFs())
+			_, err := parser.Parse("spam?" + c.options)
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestShowsComponent(t *testing.T) {
+	assert.True(t, Options{}.ShowsComponent("anything"))
+
+	opts := Options{Component: "app-*"}
+	assert.True(t, opts.ShowsComponent("app-one", "registry.io/one"))
+	assert.True(t, opts.ShowsComponent("", "app-one"))
+	assert.False(t, opts.ShowsComponent("other", "registry.io/app-one"))
+}
+
+func TestShowsResult(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     Options
+		severity string
+		code     string
+		expected bool
+	}{
+		{name: "defaults violation", opts: DefaultOptions(), severity: FailureSeverity, expected: true},
+		{name: "defaults warning", opts: DefaultOptions(), severity: WarningSeverity, expected: true},
+		{name: "defaults success", opts: DefaultOptions(), severity: SuccessSeverity, expected: false},
+		{name: "show successes", opts: Options{ShowSuccesses: true}, severity: SuccessSeverity, expected: true},
+		{name: "hide warnings", opts: Options{}, severity: WarningSeverity, expected: false},
+		{name: "severity below", opts: Options{ShowWarnings: true, Severity: FailureSeverity}, severity: WarningSeverity, expected: false},
+		{name: "severity equal", opts: Options{Severity: WarningSeverity, ShowWarnings: true}, severity: WarningSeverity, expected: true},
+		{name: "severity above", opts: Options{Severity: WarningSeverity}, severity: FailureSeverity, expected: true},
+		{name: "rules match", opts: Options{Rules: "tasks.*"}, severity: FailureSeverity, code: "tasks.required", expected: true},
+		{name: "rules no match", opts: Options{Rules: "tasks.*"}, severity: FailureSeverity, code: "cve.found", expected: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.opts.ShowsResult(c.severity, c.code))
+		})
+	}
+}
+
+func TestFilterResults(t *testing.T) {
+	results := []evaluator.Result{
+		{Message: "first message", Metadata: map[string]any{"code": "tasks.required"}},
+		{Message: "second message", Metadata: map[string]any{"code": "cve.found"}},
+	}
+
+	opts := Options{Rules: "tasks.*", MaxMessageLength: 5}
+	assert.Equal(t, []evaluator.Result{
+		{Message: "fi...", Metadata: map[string]any{"code": "tasks.required"}},
+	}, opts.FilterResults(results, FailureSeverity))
+	assert.Empty(t, opts.FilterResults(results, SuccessSeverity))
+	assert.Nil(t, opts.FilterResults(nil, FailureSeverity))
+}
+
+func TestMessage(t *testing.T) {
+	cases := []struct {
+		length   int
+		msg      string
+		expected string
+	}{
+		{length: 0, msg: "a long message", expected: "a long message"},
+		{length: 20, msg: "a long message", expected: "a long message"},
+		{length: 14, msg: "a long message", expected: "a long message"},
+		{length: 9, msg: "a long message", expected: "a long..."},
+		{length: 2, msg: "a long message", expected: "a "},
+		{length: 5, msg: "ünïcödé", expected: "ün..."},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Options{MaxMessageLength: c.length}.Message(c.msg))
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+// ApplyBaseline downgrades the violations of the inputs that are also present
+// in the baseline report to warnings marked as baselined. Inputs are matched
+// by their file path, and violations by the code and term of the rule. The
+// success of the inputs is updated to reflect only the remaining violations.
+func ApplyBaseline(inputs []Input, baseline Report) {
+	known := map[string]evaluator.Baseline{}
+	for _, i := range baseline.FilePaths {
+		known[i.FilePath] = evaluator.NewBaseline(i.Violations, i.Warnings)
+	}
+
+	for idx := range inputs {
+		i := &inputs[idx]
+		b, ok := known[i.FilePath]
+		if !ok {
+			continue
+		}
+
+		i.Violations, i.Warnings = b.Apply(i.Violations, i.Warnings)
+		i.Success = len(i.Violations) == 0
+	}
+}
+
+// toBaseline returns a version of the report holding only the data needed for
+// it to be used as a baseline: the inputs with their violations, including
+// the ones already baselined.
+func (r *Report) toBaseline() Report {
+	baseline := Report{
+		Success:       r.Success,
+		EcVersion:     r.EcVersion,
+		EffectiveTime: r.EffectiveTime,
+		FilePaths:     make([]Input, 0, len(r.FilePaths)),
+	}
+
+	for _, i := range r.FilePaths {
+		baseline.FilePaths = append(baseline.FilePaths, Input{
+			FilePath:   i.FilePath,
+			Success:    i.Success,
+			Violations: evaluator.BaselineViolations(i.Violations, i.Warnings),
+		})
+	}
+
+	return baseline
+}
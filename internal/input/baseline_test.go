@@ -0,0 +1,78 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package input
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func TestApplyBaseline(t *testing.T) {
+	baseline := Report{
+		FilePaths: []Input{
+			{
+				FilePath: "one.yaml",
+				Violations: []evaluator.Result{
+					{Message: "violation", Metadata: map[string]any{"code": "a.b", "term": "t1"}},
+				},
+			},
+		},
+	}
+
+	inputs := []Input{
+		{
+			FilePath: "one.yaml",
+			Violations: []evaluator.Result{
+				{Message: "changed", Metadata: map[string]any{"code": "a.b", "term": "t1"}},
+			},
+		},
+		{
+			FilePath: "two.yaml",
+			Violations: []evaluator.Result{
+				{Message: "violation", Metadata: map[string]any{"code": "a.b", "term": "t1"}},
+			},
+		},
+	}
+
+	ApplyBaseline(inputs, baseline)
+
+	assert.True(t, inputs[0].Success)
+	assert.Empty(t, inputs[0].Violations)
+	assert.Equal(t, []evaluator.Result{
+		{Message: "changed", Metadata: map[string]any{"code": "a.b", "term": "t1", "baselined": true}},
+	}, inputs[0].Warnings)
+
+	assert.False(t, inputs[1].Success)
+	assert.Len(t, inputs[1].Violations, 1)
+
+	// writing a baseline keeps the baselined violations
+	r := Report{FilePaths: inputs}
+	data, err := r.toFormat(Baseline)
+	require.NoError(t, err)
+
+	var written Report
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Len(t, written.FilePaths[0].Violations, 1)
+	assert.Len(t, written.FilePaths[1].Violations, 1)
+}
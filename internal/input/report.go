@@ -29,6 +29,7 @@ import (
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 	"github.com/enterprise-contract/ec-cli/internal/format"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
 	"github.com/enterprise-contract/ec-cli/internal/version"
 )
 
@@ -85,9 +86,11 @@ type TestReport struct {
 
 // Possible formats the report can be written as.
 const (
-	JSON    = "json"
-	YAML    = "yaml"
-	Summary = "summary"
+	JSON     = "json"
+	YAML     = "yaml"
+	Summary  = "summary"
+	Template = format.Template
+	Baseline = "baseline"
 )
 
 // WriteReport returns a new instance of Report representing the state of
@@ -116,6 +119,19 @@ func NewReport(inputs []Input, policy policy.Policy, policyInput [][]byte) (Repo
 	}, nil
 }
 
+// ReadReport parses a report previously written in the JSON or YAML format.
+// The creation time is set to the time of reading.
+func ReadReport(data []byte) (Report, error) {
+	var r Report
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return Report{}, fmt.Errorf("unable to parse report: %w", err)
+	}
+
+	r.created = time.Now().UTC()
+
+	return r, nil
+}
+
 // WriteAll writes the report to all the given targets.
 func (r Report) WriteAll(targets []string, p format.TargetParser) (allErrors error) {
 	if len(targets) == 0 {
@@ -128,7 +144,14 @@ func (r Report) WriteAll(targets []string, p format.TargetParser) (allErrors err
 			continue
 		}
 
-		data, err := r.toFormat(target.Format)
+		report := r.withOptions(target.Options)
+
+		var data []byte
+		if target.Format == Template {
+			data, err = utils.RenderFromTemplateText(struct{ Report *Report }{&report}, Template, target.Template)
+		} else {
+			data, err = report.toFormat(target.Format)
+		}
 		if err != nil {
 			allErrors = errors.Join(allErrors, err)
 			continue
@@ -145,6 +168,26 @@ func (r Report) WriteAll(targets []string, p format.TargetParser) (allErrors err
 	return
 }
 
+// withOptions returns a copy of the report holding only the inputs and
+// results selected by the options. The success of the report and of the
+// inputs, and the number of successes, are not affected.
+func (r Report) withOptions(opts format.Options) Report {
+	inputs := make([]Input, 0, len(r.FilePaths))
+	for _, i := range r.FilePaths {
+		if !opts.ShowsComponent(i.FilePath) {
+			continue
+		}
+
+		i.Violations = opts.FilterResults(i.Violations, format.FailureSeverity)
+		i.Warnings = opts.FilterResults(i.Warnings, format.WarningSeverity)
+		i.Successes = opts.FilterResults(i.Successes, format.SuccessSeverity)
+		inputs = append(inputs, i)
+	}
+	r.FilePaths = inputs
+
+	return r
+}
+
 // toFormat converts the report into the given format.
 func (r *Report) toFormat(format string) (data []byte, err error) {
 	switch format {
@@ -154,6 +197,8 @@ func (r *Report) toFormat(format string) (data []byte, err error) {
 		data, err = yaml.Marshal(r)
 	case Summary:
 		data, err = json.Marshal(r.toSummary())
+	case Baseline:
+		data, err = json.Marshal(r.toBaseline())
 	default:
 		return nil, fmt.Errorf("%q is not a valid report format", format)
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/format"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 )
@@ -307,3 +308,39 @@ sources:
 	assert.NoError(t, err)
 	return p
 }
+
+func TestWithOptions(t *testing.T) {
+	r := Report{
+		FilePaths: []Input{
+			{
+				FilePath: "/path/to/pipeline.yaml",
+				Violations: []evaluator.Result{
+					{Message: "a rather long violation message", Metadata: map[string]any{"code": "pipeline.required"}},
+					{Message: "other", Metadata: map[string]any{"code": "other.rule"}},
+				},
+				Warnings: []evaluator.Result{{Message: "warning", Metadata: map[string]any{"code": "pipeline.trusted"}}},
+			},
+			{FilePath: "/path/to/task.yaml", Success: true},
+		},
+	}
+
+	filtered := r.withOptions(format.Options{
+		Component:        "/path/to/pipeline*",
+		Rules:            "pipeline.*",
+		MaxMessageLength: 12,
+	})
+
+	assert.Equal(t, []Input{
+		{
+			FilePath: "/path/to/pipeline.yaml",
+			Violations: []evaluator.Result{
+				{Message: "a rather ...", Metadata: map[string]any{"code": "pipeline.required"}},
+			},
+			Warnings: []evaluator.Result{},
+		},
+	}, filtered.FilePaths)
+
+	// The original report is not modified
+	assert.Len(t, r.FilePaths, 2)
+	assert.Equal(t, "a rather long violation message", r.FilePaths[0].Violations[0].Message)
+}
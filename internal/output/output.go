@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"sort"
 
 	"github.com/sigstore/cosign/v2/pkg/cosign"
@@ -87,6 +88,10 @@ type Output struct {
 	Detailed                  bool                        `json:"-"`
 	Policy                    policy.Policy               `json:"-"`
 	PolicyInput               []byte                      `json:"-"`
+	// RuleInfo holds the full metadata of the rules that produced the policy
+	// check results by rule code, it is retained even when the metadata of the
+	// results is not Detailed
+	RuleInfo map[string]map[string]any `json:"-"`
 }
 
 // SetImageAccessibleCheck sets the passed and result.message fields of the ImageAccessibleCheck to the given values.
@@ -195,6 +200,9 @@ func (o *Output) SetPolicyCheck(results []evaluator.Outcome) {
 			results[r].FileName = ""
 		}
 
+		o.addRuleInfo(results[r].Failures)
+		o.addRuleInfo(results[r].Warnings)
+
 		if !o.Detailed {
 			keepSomeMetadata(results[r].Exceptions)
 			keepSomeMetadata(results[r].Failures)
@@ -210,15 +218,37 @@ func (o *Output) SetPolicyCheck(results []evaluator.Outcome) {
 	o.PolicyCheck = results
 }
 
+// addRuleInfo records a copy of the metadata of the rules that produced the
+// results, before the metadata of the results is reduced.
+func (o *Output) addRuleInfo(results []evaluator.Result) {
+	for _, result := range results {
+		code, ok := result.Metadata["code"].(string)
+		if !ok || code == "" {
+			continue
+		}
+		if _, seen := o.RuleInfo[code]; seen {
+			continue
+		}
+		if o.RuleInfo == nil {
+			o.RuleInfo = map[string]map[string]any{}
+		}
+		o.RuleInfo[code] = maps.Clone(result.Metadata)
+	}
+}
+
 func keepSomeMetadata(results []evaluator.Result) {
 	for i := range results {
 		keepSomeMetadataSingle(results[i])
 	}
 }
 
+// keepSomeMetadataSingle keeps only the metadata identifying the result, the
+// code and the term of the rule, and the effective on date of the rule. The
+// term is kept, unlike the other metadata, as the baselines and the suggested
+// exclusions match the results by their code and term.
 func keepSomeMetadataSingle(result evaluator.Result) {
 	for key := range result.Metadata {
-		if key == "code" || key == "effective_on" {
+		if key == "code" || key == "term" || key == "effective_on" {
 			continue
 		}
 		delete(result.Metadata, key)
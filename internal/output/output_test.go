@@ -23,6 +23,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"testing"
 	"unsafe"
@@ -1041,3 +1042,49 @@ func TestSetAttestationSyntaxCheckFromError(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPolicyCheckKeepsSomeMetadata(t *testing.T) {
+	metadata := func() map[string]any {
+		return map[string]any{
+			"code":         "a.b",
+			"term":         "c",
+			"effective_on": "2022-01-01T00:00:00Z",
+			"title":        "Title",
+			"description":  "Description",
+		}
+	}
+
+	o := Output{}
+	o.SetPolicyCheck([]evaluator.Outcome{{Failures: []evaluator.Result{{Metadata: metadata()}}}})
+	assert.Equal(t, map[string]any{
+		"code":         "a.b",
+		"term":         "c",
+		"effective_on": "2022-01-01T00:00:00Z",
+	}, o.PolicyCheck[0].Failures[0].Metadata)
+
+	detailed := Output{Detailed: true}
+	detailed.SetPolicyCheck([]evaluator.Outcome{{Failures: []evaluator.Result{{Metadata: metadata()}}}})
+	assert.Equal(t, metadata(), detailed.PolicyCheck[0].Failures[0].Metadata)
+}
+
+func TestSetPolicyCheckRuleInfo(t *testing.T) {
+	metadata := map[string]any{
+		"code":        "a.b",
+		"term":        "c",
+		"title":       "Title",
+		"description": "Description",
+	}
+
+	o := Output{}
+	o.SetPolicyCheck([]evaluator.Outcome{{
+		Failures: []evaluator.Result{{Metadata: maps.Clone(metadata)}, {Message: "no code"}},
+		Warnings: []evaluator.Result{{Metadata: map[string]any{"code": "d.e", "title": "Warning"}}},
+	}})
+
+	// the rule info is retained even though the metadata of the results is reduced
+	assert.Equal(t, map[string]map[string]any{
+		"a.b": metadata,
+		"d.e": {"code": "d.e", "title": "Warning"},
+	}, o.RuleInfo)
+	assert.NotContains(t, o.PolicyCheck[0].Failures[0].Metadata, "title")
+}
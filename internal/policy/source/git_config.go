@@ -46,6 +46,11 @@ func SourceIsHttp(src string) bool {
 	return detector.HttpDetector(src)
 }
 
+// SourceIsOci returns true if go-getter thinks the src looks like an OCI reference
+func SourceIsOci(src string) bool {
+	return detector.OciDetector(src)
+}
+
 func GoGetterDownload(ctx context.Context, tmpDir, src string) (string, error) {
 	// Download the config from a url
 	c := PolicyUrl{
@@ -89,3 +89,21 @@ func TestSourceIsHttp(t *testing.T) {
 		assert.Equal(t, tt.want, SourceIsHttp(tt.src), "SourceIsHttp(%s) = %v, want %v", tt.src, SourceIsHttp(tt.src), tt.want)
 	}
 }
+
+func TestSourceIsOci(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{src: "", want: false},
+		{src: "oci::quay.io/foo/bar:latest", want: true},
+		{src: "quay.io/foo/bar:latest", want: true},
+		{src: "git::https://foo.bar/asdf", want: false},
+		{src: "github.com/foo/bar", want: false},
+		{src: "https://raw.githubusercontent.com/foo/bar", want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, SourceIsOci(tt.src), "SourceIsOci(%s) = %v, want %v", tt.src, SourceIsOci(tt.src), tt.want)
+	}
+}
@@ -17,7 +17,11 @@
 package rego
 
 import (
+	_ "github.com/enterprise-contract/ec-cli/internal/rego/crypto"
+	_ "github.com/enterprise-contract/ec-cli/internal/rego/license"
 	_ "github.com/enterprise-contract/ec-cli/internal/rego/oci"
 	_ "github.com/enterprise-contract/ec-cli/internal/rego/purl"
+	_ "github.com/enterprise-contract/ec-cli/internal/rego/sbom"
 	_ "github.com/enterprise-contract/ec-cli/internal/rego/sigstore"
+	_ "github.com/enterprise-contract/ec-cli/internal/rego/tekton"
 )
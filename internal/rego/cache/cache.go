@@ -0,0 +1,289 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cache provides a persistent, content-addressed cache for the results of rego builtins
+// that rely on the network. OPA's memoization only lasts for the duration of a single query, so
+// without it the same content is fetched again for each component and for each run. Only results
+// that can never change for a given key, e.g. content referenced by digest, should be persisted,
+// other results can be remembered for the duration of a single run.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/open-policy-agent/opa/ast"
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey string
+
+const cacheContextKey contextKey = "ec.rego.cache"
+
+// maxMemorySize is the size of the largest serialized result held in memory, larger results, e.g.
+// blobs, are read from the directory each time so that they do not remain in memory for the
+// rest of the run.
+const maxMemorySize = 64 << 10 // 64KiB
+
+var defaultCache = sync.OnceValue(initCache)
+
+func initCache() *Cache {
+	// if a value was set and it is parsed as false, turn the cache off
+	if v, err := strconv.ParseBool(os.Getenv("EC_CACHE")); err == nil && !v {
+		return nil
+	}
+
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		log.Debug("unable to find user cache directory")
+		return nil
+	}
+
+	return New(path.Join(userCache, "ec", "rego"))
+}
+
+// Cache holds the results of rego builtins in memory and in the given directory, so that they are
+// available across components and across runs. A nil Cache is valid and never holds any results.
+type Cache struct {
+	dir    string
+	mem    sync.Map
+	run    sync.Map
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// New creates a Cache storing results in the given directory. When the directory cannot be
+// created, only small results are held in memory.
+func New(dir string) *Cache {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Debugf("unable to create directory for rego cache in %q: %v", dir, err)
+		dir = ""
+	} else {
+		log.Debugf("using %q directory to store rego cache", dir)
+	}
+
+	return &Cache{dir: dir}
+}
+
+// WithCache returns a context holding the given Cache, nil disables caching.
+func WithCache(ctx context.Context, c *Cache) context.Context {
+	return context.WithValue(ctx, cacheContextKey, c)
+}
+
+// FromContext returns the Cache held by the context, or the default Cache stored in the user's
+// cache directory. The default Cache is disabled by setting the EC_CACHE environment variable to
+// false.
+func FromContext(ctx context.Context) *Cache {
+	if c, ok := ctx.Value(cacheContextKey).(*Cache); ok {
+		return c
+	}
+
+	return defaultCache()
+}
+
+// Key computes the key of a cached result from the parts identifying it. The parts should
+// include a digest of the content the result is derived from.
+func Key(parts ...string) string {
+	hasher := sha256.New()
+	for _, p := range parts {
+		hasher.Write([]byte(p))
+		hasher.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// Get returns the cached result of the named function for the given key.
+func (c *Cache) Get(ctx context.Context, function, key string) (*ast.Term, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	term, ok := c.load(Key(function, key))
+	c.record(ctx, function, key, ok)
+
+	return term, ok
+}
+
+// Recall returns the result of the named function for the given key remembered during this run.
+func (c *Cache) Recall(ctx context.Context, function, key string) (*ast.Term, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	var term *ast.Term
+	v, ok := c.run.Load(Key(function, key))
+	if ok {
+		term = v.(*ast.Term)
+	}
+	c.record(ctx, function, key, ok)
+
+	return term, ok
+}
+
+// Put stores the result of the named function under the given key.
+func (c *Cache) Put(ctx context.Context, function, key string, term *ast.Term) {
+	if c == nil || term == nil {
+		return
+	}
+
+	id := Key(function, key)
+
+	data, err := encode(term.Value)
+	if err == nil {
+		if len(data) <= maxMemorySize {
+			c.mem.Store(id, term)
+		}
+
+		if c.dir == "" {
+			return
+		}
+
+		err = c.write(id, data)
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"function": function,
+			"key":      key,
+			"error":    err,
+		}).Debug("unable to store result in rego cache")
+	}
+}
+
+// Remember stores the result of the named function under the given key in memory only, for
+// results that may change across runs, e.g. results depending on the state of the transparency
+// log or on mutable tags.
+func (c *Cache) Remember(ctx context.Context, function, key string, term *ast.Term) {
+	if c == nil || term == nil {
+		return
+	}
+
+	c.run.Store(Key(function, key), term)
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (c *Cache) Stats() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+
+	return c.hits.Load(), c.misses.Load()
+}
+
+func (c *Cache) record(ctx context.Context, function, key string, hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	if trace.IsEnabled() {
+		trace.Logf(ctx, "ec:rego-cache", "function=%q hit=%t hits=%d misses=%d", function, hit, c.hits.Load(), c.misses.Load())
+	}
+	log.WithFields(log.Fields{
+		"function": function,
+		"key":      key,
+		"hit":      hit,
+	}).Trace("rego cache lookup")
+}
+
+func (c *Cache) load(id string) (*ast.Term, bool) {
+	if term, ok := c.mem.Load(id); ok {
+		return term.(*ast.Term), true
+	}
+
+	if c.dir == "" {
+		return nil, false
+	}
+
+	entry, err := os.ReadFile(c.file(id))
+	if err != nil {
+		return nil, false
+	}
+
+	// Entries start with the digest of their data, checked so that an entry modified or
+	// truncated on disk is never used
+	sum, data, ok := bytes.Cut(entry, []byte{'\n'})
+	if digest := sha256.Sum256(data); !ok || hex.EncodeToString(digest[:]) != string(sum) {
+		log.Debugf("rego cache entry %q does not match its digest, ignoring it", id)
+		return nil, false
+	}
+
+	value, err := ast.ValueFromReader(bytes.NewReader(data))
+	if err != nil {
+		log.Debugf("unable to read rego cache entry %q: %v", id, err)
+		return nil, false
+	}
+
+	term := ast.NewTerm(value)
+	if len(data) <= maxMemorySize {
+		c.mem.Store(id, term)
+	}
+
+	return term, true
+}
+
+func encode(value ast.Value) ([]byte, error) {
+	v, err := ast.JSON(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+func (c *Cache) write(id string, data []byte) error {
+	file := c.file(id)
+	if err := os.MkdirAll(path.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that concurrent readers never observe partial content
+	tmp, err := os.CreateTemp(path.Dir(file), id+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	digest := sha256.Sum256(data)
+	if _, err := fmt.Fprintf(tmp, "%x\n%s", digest, data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// file returns the path of the entry, entries are spread across subdirectories to avoid having
+// too many files in a single directory
+func (c *Cache) file(id string) string {
+	return path.Join(c.dir, id[:2], id)
+}
@@ -0,0 +1,172 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPut(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	_, ok := c.Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	term := ast.MustParseTerm(`{"a": [1, 2.5, "three", true, null], "b": {"c": "d"}}`)
+	c.Put(ctx, "ec.test", "key", term)
+
+	got, ok := c.Get(ctx, "ec.test", "key")
+	require.True(t, ok)
+	require.Equal(t, term.String(), got.String())
+
+	// the same key for a different function is a different entry
+	_, ok = c.Get(ctx, "ec.other", "key")
+	require.False(t, ok)
+
+	hits, misses := c.Stats()
+	require.Equal(t, uint64(1), hits)
+	require.Equal(t, uint64(2), misses)
+
+	// entries persist across instances using the same directory
+	got, ok = New(dir).Get(ctx, "ec.test", "key")
+	require.True(t, ok)
+	require.Equal(t, term.String(), got.String())
+}
+
+func TestCorruptEntry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	c.Put(ctx, "ec.test", "key", ast.StringTerm("value"))
+	id := Key("ec.test", "key")
+	require.FileExists(t, path.Join(dir, id[:2], id))
+
+	require.NoError(t, os.WriteFile(path.Join(dir, id[:2], id), []byte("{not json"), 0600))
+
+	_, ok := New(dir).Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+}
+
+func TestModifiedEntry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	c.Put(ctx, "ec.test", "key", ast.StringTerm("value"))
+	id := Key("ec.test", "key")
+	file := path.Join(dir, id[:2], id)
+
+	entry, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, []byte(strings.Replace(string(entry), "value", "other", 1)), 0600))
+
+	// still valid JSON, but no longer matching its digest
+	_, ok := New(dir).Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+}
+
+func TestLargeResultsNotHeldInMemory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	small := ast.StringTerm("value")
+	large := ast.StringTerm(strings.Repeat("x", maxMemorySize))
+	c.Put(ctx, "ec.test", "small", small)
+	c.Put(ctx, "ec.test", "large", large)
+
+	got, ok := c.Get(ctx, "ec.test", "large")
+	require.True(t, ok)
+	require.Equal(t, large.String(), got.String())
+
+	require.NoError(t, os.RemoveAll(dir))
+
+	// small results are held in memory, large results are read from the directory
+	_, ok = c.Get(ctx, "ec.test", "small")
+	require.True(t, ok)
+	_, ok = c.Get(ctx, "ec.test", "large")
+	require.False(t, ok)
+}
+
+func TestRememberRecall(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	_, ok := c.Recall(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	term := ast.MustParseTerm(`{"success": true}`)
+	c.Remember(ctx, "ec.test", "key", term)
+
+	got, ok := c.Recall(ctx, "ec.test", "key")
+	require.True(t, ok)
+	require.Same(t, term, got)
+
+	// remembered results are not persisted
+	_, ok = c.Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+	_, ok = New(dir).Recall(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	// persisted results are not recalled
+	c.Put(ctx, "ec.test", "other", term)
+	_, ok = c.Recall(ctx, "ec.test", "other")
+	require.False(t, ok)
+}
+
+func TestNilCache(t *testing.T) {
+	ctx := context.Background()
+	var c *Cache
+
+	c.Put(ctx, "ec.test", "key", ast.StringTerm("value"))
+	_, ok := c.Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	c.Remember(ctx, "ec.test", "key", ast.StringTerm("value"))
+	_, ok = c.Recall(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	hits, misses := c.Stats()
+	require.Zero(t, hits)
+	require.Zero(t, misses)
+}
+
+func TestFromContext(t *testing.T) {
+	c := New(t.TempDir())
+	require.Same(t, c, FromContext(WithCache(context.Background(), c)))
+	require.Nil(t, FromContext(WithCache(context.Background(), nil)))
+}
+
+func TestKey(t *testing.T) {
+	require.Equal(t, Key("a", "b"), Key("a", "b"))
+	// parts are delimited, so they cannot be shifted to produce the same key
+	require.NotEqual(t, Key("ab", "c"), Key("a", "bc"))
+	require.Len(t, Key("a"), 64)
+}
@@ -0,0 +1,170 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"unicode/utf8"
+
+	"github.com/open-policy-agent/opa/ast"
+	log "github.com/sirupsen/logrus"
+)
+
+// StringWriter stores a string result in the cache directory while it is being produced, e.g.
+// read from the network, so that the result is not copied again in memory to be stored. The
+// result is only stored once committed. Errors writing to the directory are not reported to the
+// producer of the result, the result is then simply not stored.
+type StringWriter struct {
+	c        *Cache
+	function string
+	key      string
+	tmp      *os.File
+	hasher   hash.Hash
+	size     int64
+	// partial holds the bytes of a UTF-8 sequence split across writes
+	partial []byte
+	err     error
+}
+
+// NewStringWriter returns a StringWriter for the result of the named function under the given
+// key. A StringWriter of a nil Cache discards the result.
+func (c *Cache) NewStringWriter(function, key string) *StringWriter {
+	w := &StringWriter{c: c, function: function, key: key, hasher: sha256.New()}
+	if c == nil || c.dir == "" {
+		return w
+	}
+
+	file := c.file(Key(function, key))
+	if w.err = os.MkdirAll(path.Dir(file), 0700); w.err != nil {
+		return w
+	}
+
+	if w.tmp, w.err = os.CreateTemp(path.Dir(file), path.Base(file)+".*"); w.err != nil {
+		return w
+	}
+
+	// Room for the digest of the data, written once the data is complete, see Cache.load
+	_, w.err = w.tmp.Write(make([]byte, sha256.Size*2+1))
+	w.write([]byte{'"'})
+
+	return w
+}
+
+// Write encodes the given bytes of the string. It never fails.
+func (w *StringWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+
+	// Only complete UTF-8 sequences can be encoded, the rest is kept for the next write
+	end := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	w.partial = append([]byte(nil), data[end:]...)
+
+	w.writeString(data[:end])
+
+	return len(p), nil
+}
+
+// Commit stores the given result, which must be the string written.
+func (w *StringWriter) Commit(ctx context.Context, term *ast.Term) {
+	if w.c == nil {
+		return
+	}
+
+	w.writeString(w.partial)
+	w.write([]byte{'"'})
+
+	if w.size <= maxMemorySize {
+		w.c.mem.Store(Key(w.function, w.key), term)
+	}
+
+	if w.tmp == nil {
+		return
+	}
+	defer os.Remove(w.tmp.Name())
+
+	if w.err == nil {
+		_, w.err = w.tmp.WriteAt([]byte(hex.EncodeToString(w.hasher.Sum(nil))+"\n"), 0)
+	}
+
+	if err := w.tmp.Close(); w.err == nil {
+		w.err = err
+	}
+
+	if w.err == nil {
+		w.err = os.Rename(w.tmp.Name(), w.c.file(Key(w.function, w.key)))
+	}
+
+	if w.err != nil {
+		log.WithFields(log.Fields{
+			"function": w.function,
+			"key":      w.key,
+			"error":    w.err,
+		}).Debug("unable to store result in rego cache")
+	}
+}
+
+// Discard drops the result written so far.
+func (w *StringWriter) Discard() {
+	if w.tmp == nil {
+		return
+	}
+
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+// writeString writes the bytes of the string as the content of a JSON string.
+func (w *StringWriter) writeString(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	encoded, err := json.Marshal(string(data))
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	w.write(encoded[1 : len(encoded)-1])
+}
+
+func (w *StringWriter) write(data []byte) {
+	w.size += int64(len(data))
+	if w.tmp == nil || w.err != nil {
+		return
+	}
+
+	if _, w.err = w.tmp.Write(data); w.err == nil {
+		w.hasher.Write(data)
+	}
+}
+
+var _ io.Writer = (*StringWriter)(nil)
@@ -0,0 +1,102 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringWriter(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	value := "spam \"and\" <eggs>\né€" + strings.Repeat("x", maxMemorySize)
+
+	w := c.NewStringWriter("ec.test", "key")
+	// one byte at a time splits the multibyte characters across writes
+	for i := 0; i < len(value); i++ {
+		n, err := w.Write([]byte{value[i]})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	}
+	w.Commit(ctx, ast.StringTerm(value))
+
+	got, ok := New(dir).Get(ctx, "ec.test", "key")
+	require.True(t, ok)
+	require.Equal(t, ast.StringTerm(value), got)
+
+	// the same entry as stored by Put
+	entry, err := os.ReadFile(c.file(Key("ec.test", "key")))
+	require.NoError(t, err)
+	c.Put(ctx, "ec.test", "put", ast.StringTerm(value))
+	put, err := os.ReadFile(c.file(Key("ec.test", "put")))
+	require.NoError(t, err)
+	require.Equal(t, string(put), string(entry))
+}
+
+func TestStringWriterSmallInMemory(t *testing.T) {
+	ctx := context.Background()
+	c := New("")
+
+	w := c.NewStringWriter("ec.test", "key")
+	_, err := w.Write([]byte("value"))
+	require.NoError(t, err)
+	w.Commit(ctx, ast.StringTerm("value"))
+
+	got, ok := c.Get(ctx, "ec.test", "key")
+	require.True(t, ok)
+	require.Equal(t, ast.StringTerm("value"), got)
+}
+
+func TestStringWriterDiscard(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir)
+
+	w := c.NewStringWriter("ec.test", "key")
+	_, err := w.Write([]byte("value"))
+	require.NoError(t, err)
+	w.Discard()
+
+	_, ok := c.Get(ctx, "ec.test", "key")
+	require.False(t, ok)
+
+	id := Key("ec.test", "key")
+	entries, err := os.ReadDir(path.Join(dir, id[:2]))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestStringWriterNilCache(t *testing.T) {
+	var c *Cache
+	w := c.NewStringWriter("ec.test", "key")
+	n, err := w.Write([]byte("value"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	w.Commit(context.Background(), ast.StringTerm("value"))
+	w.Discard()
+}
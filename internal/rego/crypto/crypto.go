@@ -0,0 +1,388 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package crypto
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown/builtins"
+	"github.com/open-policy-agent/opa/types"
+
+	"github.com/enterprise-contract/ec-cli/internal/signature"
+)
+
+const cryptoX509VerifyName = "ec.crypto.x509.verify"
+
+const (
+	timeAttribute         = "time"
+	keyUsagesAttribute    = "key_usages"
+	extKeyUsagesAttribute = "ext_key_usages"
+)
+
+var keyUsages = map[string]x509.KeyUsage{
+	"digital_signature":  x509.KeyUsageDigitalSignature,
+	"content_commitment": x509.KeyUsageContentCommitment,
+	"key_encipherment":   x509.KeyUsageKeyEncipherment,
+	"data_encipherment":  x509.KeyUsageDataEncipherment,
+	"key_agreement":      x509.KeyUsageKeyAgreement,
+	"cert_sign":          x509.KeyUsageCertSign,
+	"crl_sign":           x509.KeyUsageCRLSign,
+	"encipher_only":      x509.KeyUsageEncipherOnly,
+	"decipher_only":      x509.KeyUsageDecipherOnly,
+}
+
+var extKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":              x509.ExtKeyUsageAny,
+	"server_auth":      x509.ExtKeyUsageServerAuth,
+	"client_auth":      x509.ExtKeyUsageClientAuth,
+	"code_signing":     x509.ExtKeyUsageCodeSigning,
+	"email_protection": x509.ExtKeyUsageEmailProtection,
+	"time_stamping":    x509.ExtKeyUsageTimeStamping,
+	"ocsp_signing":     x509.ExtKeyUsageOCSPSigning,
+}
+
+var certificateType = types.NewObject([]*types.StaticProperty{
+	{Key: "subject", Value: types.S},
+	{Key: "issuer", Value: types.S},
+	{Key: "serial_number", Value: types.S},
+	{Key: "not_before", Value: types.S},
+	{Key: "not_after", Value: types.S},
+	{Key: "is_ca", Value: types.B},
+	{Key: "key_usages", Value: types.NewArray(nil, types.S)},
+	{Key: "ext_key_usages", Value: types.NewArray(nil, types.S)},
+	{Key: "metadata", Value: types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))},
+}, nil)
+
+func registerCryptoX509Verify() {
+	// The options are all optional, hence the dynamic object type
+	opts := types.Named("opts", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).
+		Description("verification options: time, the time of verification as RFC 3339 or nanoseconds since " +
+			"the epoch, defaulting to the evaluation time, key_usages, the key usages the certificate must have, " +
+			"e.g. digital_signature, and ext_key_usages, the extended key usages the certificate must have, " +
+			"e.g. code_signing")
+
+	result := types.Named(
+		"result",
+		types.NewObject([]*types.StaticProperty{
+			{Key: "valid", Value: types.Named("valid", types.B).Description("true when the chain is valid")},
+			{Key: "errors", Value: types.Named("errors", types.NewArray(nil, types.S)).Description("reasons of the verification failure")},
+			{Key: "chain", Value: types.Named("chain", types.NewArray(nil, certificateType)).Description("the verified chain from the certificate to the root, or the provided certificates when verification fails")},
+		}, nil),
+	).Description("the result of the verification")
+
+	decl := rego.Function{
+		Name: cryptoX509VerifyName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("chain_pem", types.S).Description("PEM encoded certificate to verify, followed by any intermediate certificates"),
+				types.Named("roots_pem", types.S).Description("PEM encoded trusted root certificates"),
+				opts,
+			),
+			result,
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin3(&decl, cryptoX509Verify)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Verify an X.509 certificate chain against trusted root certificates.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func cryptoX509Verify(bctx rego.BuiltinContext, chainTerm, rootsTerm, optsTerm *ast.Term) (*ast.Term, error) {
+	chainPEM, err := builtins.StringOperand(chainTerm.Value, 1)
+	if err != nil {
+		return verifyFailedResult(fmt.Errorf("chain_pem parameter: %w", err))
+	}
+
+	rootsPEM, err := builtins.StringOperand(rootsTerm.Value, 2)
+	if err != nil {
+		return verifyFailedResult(fmt.Errorf("roots_pem parameter: %w", err))
+	}
+
+	opts, err := builtins.ObjectOperand(optsTerm.Value, 3)
+	if err != nil {
+		return verifyFailedResult(fmt.Errorf("opts parameter: %w", err))
+	}
+
+	chain, err := parseCertificates(string(chainPEM))
+	if err != nil {
+		return verifyFailedResult(fmt.Errorf("chain_pem parameter: %w", err))
+	}
+
+	roots, err := parseCertificates(string(rootsPEM))
+	if err != nil {
+		return verifyFailedResult(fmt.Errorf("roots_pem parameter: %w", err))
+	}
+
+	verifyOpts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   time.Now(),
+		// Unlike the default of server authentication, any extended key usage is accepted unless
+		// required by the options
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if bctx.Time != nil {
+		if n, ok := bctx.Time.Value.(ast.Number); ok {
+			if ns, ok := n.Int64(); ok {
+				verifyOpts.CurrentTime = time.Unix(0, ns)
+			}
+		}
+	}
+	for _, r := range roots {
+		verifyOpts.Roots.AddCert(r)
+	}
+	for _, i := range chain[1:] {
+		verifyOpts.Intermediates.AddCert(i)
+	}
+
+	var required x509.KeyUsage
+	if err := parseOptions(opts, &verifyOpts, &required); err != nil {
+		return verifyFailedResult(fmt.Errorf("opts parameter: %w", err))
+	}
+
+	var errs []error
+	if chains, err := chain[0].Verify(verifyOpts); err != nil {
+		errs = append(errs, err)
+	} else {
+		chain = chains[0]
+	}
+
+	// The key usages are not verified by crypto/x509
+	if chain[0].KeyUsage&required != required {
+		for _, name := range keyUsageNames(required &^ chain[0].KeyUsage) {
+			errs = append(errs, fmt.Errorf("x509: certificate is missing the %s key usage", name))
+		}
+	}
+
+	return verifyResult(chain, errs)
+}
+
+func parseCertificates(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+
+	return certs, nil
+}
+
+func parseOptions(opts ast.Object, verifyOpts *x509.VerifyOptions, required *x509.KeyUsage) error {
+	if t := opts.Get(ast.StringTerm(timeAttribute)); t != nil {
+		switch v := t.Value.(type) {
+		case ast.String:
+			if v == "" {
+				break
+			}
+			at, err := time.Parse(time.RFC3339, string(v))
+			if err != nil {
+				return fmt.Errorf("time: %w", err)
+			}
+			verifyOpts.CurrentTime = at
+		case ast.Number:
+			ns, ok := v.Int64()
+			if !ok {
+				return fmt.Errorf("time: %s is not an integer", v)
+			}
+			verifyOpts.CurrentTime = time.Unix(0, ns)
+		default:
+			return fmt.Errorf("time: %s is neither a string nor a number", t)
+		}
+	}
+
+	names, err := stringsAttribute(opts, keyUsagesAttribute)
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		usage, ok := keyUsages[n]
+		if !ok {
+			return fmt.Errorf("%s: unknown key usage %q", keyUsagesAttribute, n)
+		}
+		*required |= usage
+	}
+
+	names, err = stringsAttribute(opts, extKeyUsagesAttribute)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		verifyOpts.KeyUsages = nil
+	}
+	for _, n := range names {
+		usage, ok := extKeyUsages[n]
+		if !ok {
+			return fmt.Errorf("%s: unknown extended key usage %q", extKeyUsagesAttribute, n)
+		}
+		verifyOpts.KeyUsages = append(verifyOpts.KeyUsages, usage)
+	}
+
+	return nil
+}
+
+func stringsAttribute(opts ast.Object, attribute string) ([]string, error) {
+	t := opts.Get(ast.StringTerm(attribute))
+	if t == nil {
+		return nil, nil
+	}
+
+	arr, ok := t.Value.(*ast.Array)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not an array", attribute, t)
+	}
+
+	values := make([]string, 0, arr.Len())
+	for i := 0; i < arr.Len(); i++ {
+		s, ok := arr.Elem(i).Value.(ast.String)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not a string", attribute, arr.Elem(i))
+		}
+		values = append(values, string(s))
+	}
+
+	return values, nil
+}
+
+func keyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for n, u := range keyUsages {
+		if usage&u != 0 {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func extKeyUsageNames(usages []x509.ExtKeyUsage) []string {
+	var names []string
+	for n, u := range extKeyUsages {
+		for _, usage := range usages {
+			if usage == u {
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func toCertificateTerm(cert *x509.Certificate) (*ast.Term, error) {
+	metadata, err := signature.CertificateMetadata(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadataItems [][2]*ast.Term
+	for k, v := range metadata {
+		metadataItems = append(metadataItems, ast.Item(ast.StringTerm(k), ast.StringTerm(v)))
+	}
+
+	stringsTerm := func(values []string) *ast.Term {
+		terms := make([]*ast.Term, 0, len(values))
+		for _, v := range values {
+			terms = append(terms, ast.StringTerm(v))
+		}
+		return ast.ArrayTerm(terms...)
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("subject"), ast.StringTerm(cert.Subject.String())),
+		ast.Item(ast.StringTerm("issuer"), ast.StringTerm(cert.Issuer.String())),
+		ast.Item(ast.StringTerm("serial_number"), ast.StringTerm(cert.SerialNumber.Text(16))),
+		ast.Item(ast.StringTerm("not_before"), ast.StringTerm(cert.NotBefore.UTC().Format(time.RFC3339))),
+		ast.Item(ast.StringTerm("not_after"), ast.StringTerm(cert.NotAfter.UTC().Format(time.RFC3339))),
+		ast.Item(ast.StringTerm("is_ca"), ast.BooleanTerm(cert.IsCA)),
+		ast.Item(ast.StringTerm("key_usages"), stringsTerm(keyUsageNames(cert.KeyUsage))),
+		ast.Item(ast.StringTerm("ext_key_usages"), stringsTerm(extKeyUsageNames(cert.ExtKeyUsage))),
+		ast.Item(ast.StringTerm("metadata"), ast.ObjectTerm(metadataItems...)),
+	), nil
+}
+
+func verifyFailedResult(err error) (*ast.Term, error) {
+	return verifyResult(nil, []error{err})
+}
+
+func verifyResult(chain []*x509.Certificate, errs []error) (*ast.Term, error) {
+	var errorTerms []*ast.Term
+	var chainTerms []*ast.Term
+
+	for _, err := range errs {
+		errorTerms = append(errorTerms, ast.StringTerm(err.Error()))
+	}
+
+	for _, c := range chain {
+		cert, err := toCertificateTerm(c)
+		if err != nil {
+			errorTerms = append(errorTerms, ast.StringTerm(fmt.Sprintf("parsing certificate: %s", err)))
+			continue
+		}
+		chainTerms = append(chainTerms, cert)
+	}
+
+	valid := len(errorTerms) == 0
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("valid"), ast.BooleanTerm(valid)),
+		ast.Item(ast.StringTerm("errors"), ast.ArrayTerm(errorTerms...)),
+		ast.Item(ast.StringTerm("chain"), ast.ArrayTerm(chainTerms...)),
+	), nil
+}
+
+func init() {
+	registerCryptoX509Verify()
+}
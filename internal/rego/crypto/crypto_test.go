@@ -0,0 +1,298 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	notBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter  = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	validTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func createCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.NotBefore = notBefore
+	template.NotAfter = notAfter
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestCryptoX509Verify(t *testing.T) {
+	ca := func(serial int64, name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+	}
+
+	root, rootKey, rootPEM := createCertificate(t, ca(1, "Root"), nil, nil)
+	intermediate, intermediateKey, intermediatePEM := createCertificate(t, ca(2, "Intermediate"), root, rootKey)
+	_, _, leafPEM := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Leaf"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, intermediate, intermediateKey)
+	_, _, otherRootPEM := createCertificate(t, ca(4, "Other Root"), nil, nil)
+
+	validOpts := ast.ObjectTerm(ast.Item(ast.StringTerm("time"), ast.StringTerm(validTime.Format(time.RFC3339))))
+
+	cases := []struct {
+		name     string
+		chain    *ast.Term
+		roots    *ast.Term
+		opts     *ast.Term
+		evalTime *ast.Term
+		valid    bool
+		errors   []string
+		chainLen int
+	}{
+		{
+			name:     "valid chain",
+			chain:    ast.StringTerm(leafPEM + intermediatePEM),
+			roots:    ast.StringTerm(rootPEM),
+			opts:     validOpts,
+			valid:    true,
+			chainLen: 3,
+		},
+		{
+			name:  "valid chain with key usages",
+			chain: ast.StringTerm(leafPEM + intermediatePEM),
+			roots: ast.StringTerm(rootPEM),
+			opts: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("time"), ast.IntNumberTerm(int(validTime.UnixNano()))),
+				ast.Item(ast.StringTerm("key_usages"), ast.ArrayTerm(ast.StringTerm("digital_signature"))),
+				ast.Item(ast.StringTerm("ext_key_usages"), ast.ArrayTerm(ast.StringTerm("code_signing"))),
+			),
+			valid:    true,
+			chainLen: 3,
+		},
+		{
+			name:     "evaluation time",
+			chain:    ast.StringTerm(leafPEM + intermediatePEM),
+			roots:    ast.StringTerm(rootPEM),
+			opts:     ast.ObjectTerm(),
+			evalTime: ast.IntNumberTerm(int(validTime.UnixNano())),
+			valid:    true,
+			chainLen: 3,
+		},
+		{
+			name:     "expired",
+			chain:    ast.StringTerm(leafPEM + intermediatePEM),
+			roots:    ast.StringTerm(rootPEM),
+			opts:     ast.ObjectTerm(ast.Item(ast.StringTerm("time"), ast.StringTerm("2025-06-01T00:00:00Z"))),
+			errors:   []string{"x509: certificate has expired or is not yet valid: current time 2025-06-01T00:00:00Z is after 2025-01-01T00:00:00Z"},
+			chainLen: 2,
+		},
+		{
+			name:     "unknown authority",
+			chain:    ast.StringTerm(leafPEM + intermediatePEM),
+			roots:    ast.StringTerm(otherRootPEM),
+			opts:     validOpts,
+			errors:   []string{"x509: certificate signed by unknown authority"},
+			chainLen: 2,
+		},
+		{
+			name:     "missing intermediate",
+			chain:    ast.StringTerm(leafPEM),
+			roots:    ast.StringTerm(rootPEM),
+			opts:     validOpts,
+			errors:   []string{"x509: certificate signed by unknown authority"},
+			chainLen: 1,
+		},
+		{
+			name:  "missing extended key usage",
+			chain: ast.StringTerm(leafPEM + intermediatePEM),
+			roots: ast.StringTerm(rootPEM),
+			opts: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("time"), ast.StringTerm(validTime.Format(time.RFC3339))),
+				ast.Item(ast.StringTerm("ext_key_usages"), ast.ArrayTerm(ast.StringTerm("server_auth"))),
+			),
+			errors:   []string{"x509: certificate specifies an incompatible key usage"},
+			chainLen: 2,
+		},
+		{
+			name:  "missing key usage",
+			chain: ast.StringTerm(leafPEM + intermediatePEM),
+			roots: ast.StringTerm(rootPEM),
+			opts: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("time"), ast.StringTerm(validTime.Format(time.RFC3339))),
+				ast.Item(ast.StringTerm("key_usages"), ast.ArrayTerm(ast.StringTerm("digital_signature"), ast.StringTerm("crl_sign"))),
+			),
+			errors:   []string{"x509: certificate is missing the crl_sign key usage"},
+			chainLen: 3,
+		},
+		{
+			name:  "unknown key usage",
+			chain: ast.StringTerm(leafPEM + intermediatePEM),
+			roots: ast.StringTerm(rootPEM),
+			opts: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("key_usages"), ast.ArrayTerm(ast.StringTerm("spam"))),
+			),
+			errors: []string{`opts parameter: key_usages: unknown key usage "spam"`},
+		},
+		{
+			name:  "unknown extended key usage",
+			chain: ast.StringTerm(leafPEM + intermediatePEM),
+			roots: ast.StringTerm(rootPEM),
+			opts: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("ext_key_usages"), ast.ArrayTerm(ast.StringTerm("spam"))),
+			),
+			errors: []string{`opts parameter: ext_key_usages: unknown extended key usage "spam"`},
+		},
+		{
+			name:   "invalid time",
+			chain:  ast.StringTerm(leafPEM + intermediatePEM),
+			roots:  ast.StringTerm(rootPEM),
+			opts:   ast.ObjectTerm(ast.Item(ast.StringTerm("time"), ast.StringTerm("yesterday"))),
+			errors: []string{`opts parameter: time: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`},
+		},
+		{
+			name:   "no certificates",
+			chain:  ast.StringTerm("spam"),
+			roots:  ast.StringTerm(rootPEM),
+			opts:   validOpts,
+			errors: []string{"chain_pem parameter: no PEM encoded certificates found"},
+		},
+		{
+			name:   "no roots",
+			chain:  ast.StringTerm(leafPEM),
+			roots:  ast.StringTerm(""),
+			opts:   validOpts,
+			errors: []string{"roots_pem parameter: no PEM encoded certificates found"},
+		},
+		{
+			name:   "malformed certificate",
+			chain:  ast.StringTerm(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("spam")}))),
+			roots:  ast.StringTerm(rootPEM),
+			opts:   validOpts,
+			errors: []string{"chain_pem parameter: parsing certificate: x509: malformed certificate"},
+		},
+		{
+			name:   "unexpected chain type",
+			chain:  ast.IntNumberTerm(42),
+			roots:  ast.StringTerm(rootPEM),
+			opts:   validOpts,
+			errors: []string{"chain_pem parameter: operand 1 must be string but got number"},
+		},
+		{
+			name:   "unexpected opts type",
+			chain:  ast.StringTerm(leafPEM),
+			roots:  ast.StringTerm(rootPEM),
+			opts:   ast.StringTerm("spam"),
+			errors: []string{"opts parameter: operand 3 must be object but got string"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Time: c.evalTime}
+
+			result, err := cryptoX509Verify(bctx, c.chain, c.roots, c.opts)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+
+			errors := make([]*ast.Term, 0, len(c.errors))
+			for _, e := range c.errors {
+				errors = append(errors, ast.StringTerm(e))
+			}
+			require.Equal(t, ast.ArrayTerm(errors...).String(), result.Get(ast.StringTerm("errors")).String())
+			require.Equal(t, ast.BooleanTerm(c.valid), result.Get(ast.StringTerm("valid")))
+			require.Equal(t, c.chainLen, result.Get(ast.StringTerm("chain")).Value.(*ast.Array).Len())
+		})
+	}
+}
+
+func TestCertificateTerm(t *testing.T) {
+	cert, _, _ := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "Leaf", Organization: []string{"Example"}},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"leaf.example.com"},
+	}, nil, nil)
+
+	term, err := toCertificateTerm(cert)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"subject": "CN=Leaf,O=Example",
+		"issuer": "CN=Leaf,O=Example",
+		"serial_number": "2a",
+		"not_before": "2024-01-01T00:00:00Z",
+		"not_after": "2025-01-01T00:00:00Z",
+		"is_ca": false,
+		"key_usages": ["digital_signature", "key_encipherment"],
+		"ext_key_usages": ["client_auth", "code_signing"],
+		"metadata": {
+			"Subject": "CN=Leaf,O=Example",
+			"Issuer": "CN=Leaf,O=Example",
+			"Serial Number": "2a",
+			"Not Before": "2024-01-01T00:00:00Z",
+			"Not After": "2025-01-01T00:00:00Z",
+			"Subject Alternative Name": "DNS Names:leaf.example.com"
+		}
+	}`, term.String())
+}
+
+func TestFunctionsRegistered(t *testing.T) {
+	names := []string{
+		cryptoX509VerifyName,
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, builtin := range ast.Builtins {
+				if builtin.Name == name {
+					return
+				}
+			}
+			t.Fatalf("%s builtin not registered", name)
+		})
+	}
+}
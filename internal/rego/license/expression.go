@@ -0,0 +1,279 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package license
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	nodeLicense = "license"
+	nodeAnd     = "and"
+	nodeOr      = "or"
+)
+
+// node is a node of a parsed SPDX license expression, either a license or a conjunction or
+// disjunction of its operands.
+type node struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id,omitempty"`
+	OrLater   bool    `json:"or_later,omitempty"`
+	Exception string  `json:"exception,omitempty"`
+	Operands  []*node `json:"operands,omitempty"`
+}
+
+// idRegexp matches license and exception identifiers, including references to licenses defined
+// in the SBOM, e.g. LicenseRef-custom or DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2.
+var idRegexp = regexp.MustCompile(`^[A-Za-z0-9.-]+(:[A-Za-z0-9.-]+)?$`)
+
+// parse parses an SPDX license expression, as specified by Annex D of the SPDX specification. WITH
+// has precedence over AND, which has precedence over OR.
+func parse(expr string) (*node, error) {
+	p := parser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+
+	return n, nil
+}
+
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the given operator, operators are case insensitive.
+func (p *parser) accept(operator string) bool {
+	if t, ok := p.peek(); ok && strings.EqualFold(t, operator) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) next() (string, error) {
+	t, ok := p.peek()
+	if !ok {
+		return "", errors.New("unexpected end of expression")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) or() (*node, error) {
+	return p.compound(nodeOr, "OR", p.and)
+}
+
+func (p *parser) and() (*node, error) {
+	return p.compound(nodeAnd, "AND", p.license)
+}
+
+// compound parses the operands joined by the operator, nested compounds of the same type are
+// flattened, i.e. "A AND (B AND C)" is the same as "A AND B AND C".
+func (p *parser) compound(typ, operator string, operand func() (*node, error)) (*node, error) {
+	n := &node{Type: typ}
+
+	for {
+		o, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Type == typ {
+			n.Operands = append(n.Operands, o.Operands...)
+		} else {
+			n.Operands = append(n.Operands, o)
+		}
+
+		if !p.accept(operator) {
+			break
+		}
+	}
+
+	if len(n.Operands) == 1 {
+		return n.Operands[0], nil
+	}
+
+	return n, nil
+}
+
+func (p *parser) license() (*node, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if t == "(" {
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil {
+			return nil, err
+		} else if t != ")" {
+			return nil, fmt.Errorf("unexpected %q, expecting \")\"", t)
+		}
+		return n, nil
+	}
+
+	n := &node{Type: nodeLicense}
+	n.ID, n.OrLater = strings.CutSuffix(t, "+")
+	if !idRegexp.MatchString(n.ID) || isOperator(n.ID) {
+		return nil, fmt.Errorf("invalid license identifier %q", t)
+	}
+
+	if p.accept("WITH") {
+		e, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !idRegexp.MatchString(e) || isOperator(e) {
+			return nil, fmt.Errorf("invalid license exception identifier %q", e)
+		}
+		n.Exception = e
+	}
+
+	return n, nil
+}
+
+func isOperator(t string) bool {
+	for _, o := range []string{"AND", "OR", "WITH"} {
+		if strings.EqualFold(t, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the normalized expression, with parentheses only where needed.
+func (n *node) String() string {
+	switch n.Type {
+	case nodeLicense:
+		s := n.ID
+		if n.OrLater {
+			s += "+"
+		}
+		if n.Exception != "" {
+			s += " WITH " + n.Exception
+		}
+		return s
+	}
+
+	operands := make([]string, 0, len(n.Operands))
+	for _, o := range n.Operands {
+		s := o.String()
+		// AND has precedence over OR, so only disjunctions within conjunctions need parentheses
+		if n.Type == nodeAnd && o.Type == nodeOr {
+			s = "(" + s + ")"
+		}
+		operands = append(operands, s)
+	}
+
+	return strings.Join(operands, " "+strings.ToUpper(n.Type)+" ")
+}
+
+// licenses returns the sorted, unique, license identifiers in the expression.
+func (n *node) licenses() []string {
+	seen := map[string]bool{}
+	var collect func(*node)
+	collect = func(n *node) {
+		if n.Type == nodeLicense {
+			seen[n.ID] = true
+		}
+		for _, o := range n.Operands {
+			collect(o)
+		}
+	}
+	collect(n)
+
+	licenses := make([]string, 0, len(seen))
+	for l := range seen {
+		licenses = append(licenses, l)
+	}
+	sort.Strings(licenses)
+
+	return licenses
+}
+
+// satisfiedBy returns true when the expression can be fulfilled with the allowed licenses, without
+// using any of the denied ones: all the operands of a conjunction and at least one of the operands
+// of a disjunction must be allowed and not denied. A license with an exception is allowed, or
+// denied, either when it is listed with that exception, or when the license itself is listed, as
+// exceptions grant additional permissions. Identifiers are matched case insensitively.
+func (n *node) satisfiedBy(allowed, denied []string) bool {
+	switch n.Type {
+	case nodeAnd:
+		for _, o := range n.Operands {
+			if !o.satisfiedBy(allowed, denied) {
+				return false
+			}
+		}
+		return true
+	case nodeOr:
+		for _, o := range n.Operands {
+			if o.satisfiedBy(allowed, denied) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return n.listedIn(allowed) && !n.listedIn(denied)
+}
+
+// listedIn returns true when the license, with or without its exception, is one of the given
+// licenses.
+func (n *node) listedIn(licenses []string) bool {
+	license := &node{Type: nodeLicense, ID: n.ID, OrLater: n.OrLater}
+	candidates := []string{n.String(), license.String()}
+
+	for _, l := range licenses {
+		l = strings.Join(strings.Fields(l), " ")
+		for _, c := range candidates {
+			if strings.EqualFold(l, c) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		expr       string
+		normalized string
+		licenses   []string
+	}{
+		{"MIT", "MIT", []string{"MIT"}},
+		{"  ( MIT )  ", "MIT", []string{"MIT"}},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", "(MIT OR Apache-2.0) AND BSD-3-Clause", []string{"Apache-2.0", "BSD-3-Clause", "MIT"}},
+		{"MIT OR Apache-2.0 AND BSD-3-Clause", "MIT OR Apache-2.0 AND BSD-3-Clause", []string{"Apache-2.0", "BSD-3-Clause", "MIT"}},
+		{"MIT and (Apache-2.0 and MIT)", "MIT AND Apache-2.0 AND MIT", []string{"Apache-2.0", "MIT"}},
+		{"GPL-2.0+ with Classpath-exception-2.0", "GPL-2.0+ WITH Classpath-exception-2.0", []string{"GPL-2.0"}},
+		{"DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", []string{"DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			n, err := parse(c.expr)
+			require.NoError(t, err)
+			require.Equal(t, c.normalized, n.String())
+			require.Equal(t, c.licenses, n.licenses())
+		})
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	n, err := parse("(MIT OR Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0")
+	require.NoError(t, err)
+	require.Equal(t, &node{
+		Type: nodeAnd,
+		Operands: []*node{
+			{Type: nodeOr, Operands: []*node{
+				{Type: nodeLicense, ID: "MIT"},
+				{Type: nodeLicense, ID: "Apache-2.0"},
+			}},
+			{Type: nodeLicense, ID: "GPL-2.0-only", Exception: "Classpath-exception-2.0"},
+		},
+	}, n)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{"", "empty expression"},
+		{"MIT AND", "unexpected end of expression"},
+		{"(MIT", "unexpected end of expression"},
+		{"MIT)", `unexpected ")"`},
+		{"(MIT Apache-2.0)", `unexpected "Apache-2.0", expecting ")"`},
+		{"MIT Apache-2.0", `unexpected "Apache-2.0"`},
+		{"AND MIT", `invalid license identifier "AND"`},
+		{"MIT/X11", `invalid license identifier "MIT/X11"`},
+		{"GPL-2.0 WITH", "unexpected end of expression"},
+		{"GPL-2.0 WITH OR", `invalid license exception identifier "OR"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			_, err := parse(c.expr)
+			require.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestSatisfiedBy(t *testing.T) {
+	cases := []struct {
+		expr     string
+		allowed  []string
+		denied   []string
+		expected bool
+	}{
+		{"MIT", []string{"MIT"}, nil, true},
+		{"MIT", []string{"mit"}, nil, true},
+		{"MIT", []string{"Apache-2.0"}, nil, false},
+		{"MIT", nil, nil, false},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", []string{"Apache-2.0", "BSD-3-Clause"}, nil, true},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", []string{"Apache-2.0", "MIT"}, nil, false},
+		{"MIT OR GPL-3.0-only", []string{"MIT"}, nil, true},
+		{"MIT AND GPL-3.0-only", []string{"MIT"}, nil, false},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}, nil, true},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only  WITH  Classpath-exception-2.0"}, nil, true},
+		{"GPL-2.0-only", []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, nil, false},
+		{"GPL-2.0+", []string{"GPL-2.0"}, nil, false},
+		{"GPL-2.0+", []string{"GPL-2.0+"}, nil, true},
+		{"MIT", []string{"MIT"}, []string{"mit"}, false},
+		{"MIT OR GPL-3.0-only", []string{"MIT", "GPL-3.0-only"}, []string{"GPL-3.0-only"}, true},
+		{"MIT AND GPL-3.0-only", []string{"MIT", "GPL-3.0-only"}, []string{"GPL-3.0-only"}, false},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}, []string{"GPL-2.0-only"}, false},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}, []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, false},
+		{"GPL-2.0-only", []string{"GPL-2.0-only"}, []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			n, err := parse(c.expr)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, n.satisfiedBy(c.allowed, c.denied))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package license
+
+import (
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	licenseParseName     = "ec.license.parse"
+	licenseSatisfiesName = "ec.license.satisfies"
+)
+
+func registerLicenseParse() {
+	decl := rego.Function{
+		Name: licenseParseName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("expr", types.S).Description("the SPDX license expression"),
+			),
+			types.Named("object", types.NewObject(
+				[]*types.StaticProperty{
+					// Specifying the properties like this ensure the compiler catches typos when
+					// evaluating rego functions.
+					{Key: "expression", Value: types.Named("expression", types.S).Description("the normalized expression")},
+					{Key: "licenses", Value: types.Named("licenses", types.NewArray(nil, types.S)).Description("the license identifiers used in the expression")},
+					{Key: "tree", Value: types.Named("tree", types.A).Description(`the parsed expression, nodes are either of type "license", with the "id", "or_later" and "exception" attributes, or of type "and" or "or", with the "operands" attribute`)},
+				},
+				nil,
+			)).Description("the parsed license expression"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin1(&decl, licenseParse)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Parse an SPDX license expression.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerLicenseSatisfies() {
+	decl := rego.Function{
+		Name: licenseSatisfiesName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("expr", types.S).Description("the SPDX license expression"),
+				types.Named("licenses", types.NewAny(
+					types.NewArray(nil, types.S),
+					types.NewObject(nil, types.NewDynamicProperty(types.S, types.NewArray(nil, types.S))),
+				)).Description(`the allowed license identifiers, optionally with an exception, e.g. "GPL-2.0-only WITH Classpath-exception-2.0", or an object with the "allowed" and the "denied" license identifiers, denied licenses are never used even when allowed`),
+			),
+			types.Named("result", types.B).Description("true when the expression can be satisfied using only allowed licenses, none of them denied"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin2(&decl, licenseSatisfies)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Determine whether or not an SPDX license expression is satisfied by a list of allowed licenses, optionally excluding a list of denied licenses.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func licenseParse(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	expr, ok := a.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+
+	tree, err := parse(string(expr))
+	if err != nil {
+		log.Errorf("Parsing license expression %q failed: %s", expr, err)
+		return nil, nil
+	}
+
+	licenses := ast.NewArray()
+	for _, l := range tree.licenses() {
+		licenses = licenses.Append(ast.StringTerm(l))
+	}
+
+	treeValue, err := ast.InterfaceToValue(tree)
+	if err != nil {
+		log.Errorf("Converting license expression %q failed: %s", expr, err)
+		return nil, nil
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("expression"), ast.StringTerm(tree.String())),
+		ast.Item(ast.StringTerm("licenses"), ast.NewTerm(licenses)),
+		ast.Item(ast.StringTerm("tree"), ast.NewTerm(treeValue)),
+	), nil
+}
+
+func licenseSatisfies(bctx rego.BuiltinContext, a *ast.Term, b *ast.Term) (*ast.Term, error) {
+	expr, ok := a.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+
+	var allowed, denied []string
+	switch licenses := b.Value.(type) {
+	case *ast.Array:
+		if allowed, ok = licenseList(licenses, "Allowed"); !ok {
+			return nil, nil
+		}
+	case ast.Object:
+		for _, k := range licenses.Keys() {
+			list, isArray := licenses.Get(k).Value.(*ast.Array)
+			switch {
+			case !isArray:
+				log.Errorf("Licenses %s are not an array", k)
+				return nil, nil
+			case k.Equal(ast.StringTerm("allowed")):
+				if allowed, ok = licenseList(list, "Allowed"); !ok {
+					return nil, nil
+				}
+			case k.Equal(ast.StringTerm("denied")):
+				if denied, ok = licenseList(list, "Denied"); !ok {
+					return nil, nil
+				}
+			default:
+				log.Errorf("Unexpected licenses %s, expecting allowed or denied", k)
+				return nil, nil
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	tree, err := parse(string(expr))
+	if err != nil {
+		log.Errorf("Parsing license expression %q failed: %s", expr, err)
+		return nil, nil
+	}
+
+	return ast.BooleanTerm(tree.satisfiedBy(allowed, denied)), nil
+}
+
+// licenseList returns the licenses of the array, logging the first one that is not a string.
+func licenseList(array *ast.Array, kind string) ([]string, bool) {
+	licenses := make([]string, 0, array.Len())
+	for i := 0; i < array.Len(); i++ {
+		s, ok := array.Elem(i).Value.(ast.String)
+		if !ok {
+			log.Errorf("%s license %s is not a string", kind, array.Elem(i))
+			return nil, false
+		}
+		licenses = append(licenses, string(s))
+	}
+
+	return licenses, true
+}
+
+func init() {
+	registerLicenseParse()
+	registerLicenseSatisfies()
+}
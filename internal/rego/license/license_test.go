@@ -0,0 +1,189 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package license
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		expr     *ast.Term
+		expected string
+	}{
+		{
+			name: "success",
+			expr: ast.StringTerm("(MIT OR Apache-2.0) AND GPL-2.0+ WITH Classpath-exception-2.0"),
+			expected: `{
+				"expression": "(MIT OR Apache-2.0) AND GPL-2.0+ WITH Classpath-exception-2.0",
+				"licenses": ["Apache-2.0", "GPL-2.0", "MIT"],
+				"tree": {
+					"type": "and",
+					"operands": [
+						{"type": "or", "operands": [{"type": "license", "id": "MIT"}, {"type": "license", "id": "Apache-2.0"}]},
+						{"type": "license", "id": "GPL-2.0", "or_later": true, "exception": "Classpath-exception-2.0"}
+					]
+				}
+			}`,
+		},
+		{
+			name: "unexpected expression type",
+			expr: ast.IntNumberTerm(42),
+		},
+		{
+			name: "malformed expression",
+			expr: ast.StringTerm("MIT AND"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := licenseParse(bctx, c.expr)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			require.JSONEq(t, c.expected, result.String())
+		})
+	}
+}
+
+func TestLicenseSatisfies(t *testing.T) {
+	cases := []struct {
+		name     string
+		expr     *ast.Term
+		allowed  *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "satisfied",
+			expr:     ast.StringTerm("(MIT OR Apache-2.0) AND BSD-3-Clause"),
+			allowed:  ast.ArrayTerm(ast.StringTerm("Apache-2.0"), ast.StringTerm("BSD-3-Clause")),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "not satisfied",
+			expr:     ast.StringTerm("(MIT OR Apache-2.0) AND BSD-3-Clause"),
+			allowed:  ast.ArrayTerm(ast.StringTerm("Apache-2.0")),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:     "nothing allowed",
+			expr:     ast.StringTerm("MIT"),
+			allowed:  ast.ArrayTerm(),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name: "denied",
+			expr: ast.StringTerm("MIT OR GPL-3.0-only"),
+			allowed: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("allowed"), ast.ArrayTerm(ast.StringTerm("MIT"), ast.StringTerm("GPL-3.0-only"))),
+				ast.Item(ast.StringTerm("denied"), ast.ArrayTerm(ast.StringTerm("MIT"))),
+			),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name: "only denied",
+			expr: ast.StringTerm("MIT AND GPL-3.0-only"),
+			allowed: ast.ObjectTerm(
+				ast.Item(ast.StringTerm("allowed"), ast.ArrayTerm(ast.StringTerm("MIT"), ast.StringTerm("GPL-3.0-only"))),
+				ast.Item(ast.StringTerm("denied"), ast.ArrayTerm(ast.StringTerm("GPL-3.0-only"))),
+			),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:     "no allowed licenses",
+			expr:     ast.StringTerm("MIT"),
+			allowed:  ast.ObjectTerm(ast.Item(ast.StringTerm("denied"), ast.ArrayTerm(ast.StringTerm("GPL-3.0-only")))),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:    "unexpected licenses",
+			expr:    ast.StringTerm("MIT"),
+			allowed: ast.ObjectTerm(ast.Item(ast.StringTerm("permitted"), ast.ArrayTerm(ast.StringTerm("MIT")))),
+		},
+		{
+			name:    "unexpected denied type",
+			expr:    ast.StringTerm("MIT"),
+			allowed: ast.ObjectTerm(ast.Item(ast.StringTerm("denied"), ast.StringTerm("MIT"))),
+		},
+		{
+			name:    "unexpected denied license type",
+			expr:    ast.StringTerm("MIT"),
+			allowed: ast.ObjectTerm(ast.Item(ast.StringTerm("denied"), ast.ArrayTerm(ast.IntNumberTerm(42)))),
+		},
+		{
+			name:    "unexpected expression type",
+			expr:    ast.IntNumberTerm(42),
+			allowed: ast.ArrayTerm(ast.StringTerm("MIT")),
+		},
+		{
+			name:    "unexpected allowed type",
+			expr:    ast.StringTerm("MIT"),
+			allowed: ast.StringTerm("MIT"),
+		},
+		{
+			name:    "unexpected allowed license type",
+			expr:    ast.StringTerm("MIT"),
+			allowed: ast.ArrayTerm(ast.IntNumberTerm(42)),
+		},
+		{
+			name:    "malformed expression",
+			expr:    ast.StringTerm("MIT AND"),
+			allowed: ast.ArrayTerm(ast.StringTerm("MIT")),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := licenseSatisfies(bctx, c.expr, c.allowed)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
+func TestFunctionsRegistered(t *testing.T) {
+	names := []string{
+		licenseParseName,
+		licenseSatisfiesName,
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, builtin := range ast.Builtins {
+				if builtin.Name == name {
+					return
+				}
+			}
+			t.Fatalf("%s builtin not registered", name)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/open-policy-agent/opa/ast"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci/files"
+)
+
+const (
+	maxSizeAttribute  = "max_size"
+	maxFilesAttribute = "max_files"
+)
+
+// Default limits of a single call to ec.oci.blob or ec.oci.image_files, policy authors can raise
+// those using the options of ec.oci.fetch_blob and ec.oci.fetch_image_files.
+const (
+	defaultMaxSize  int64 = 128 << 20 // 128MiB
+	defaultMaxFiles       = 1_000
+)
+
+// Default limits of the content fetched by all calls to ec.oci.blob, ec.oci.image_files,
+// ec.oci.fetch_blob and ec.oci.fetch_image_files for a single component, see WithBudget. Those
+// can be changed by setting the EC_OCI_MAX_TOTAL_SIZE and EC_OCI_MAX_TOTAL_FILES environment
+// variables.
+const (
+	defaultMaxTotalSize  int64 = 1 << 30 // 1GiB
+	defaultMaxTotalFiles int64 = 10_000
+)
+
+// limits restrict the content fetched by a single call.
+type limits struct {
+	maxSize  int64
+	maxFiles int
+}
+
+func defaultLimits() limits {
+	return limits{
+		maxSize:  defaultMaxSize,
+		maxFiles: defaultMaxFiles,
+	}
+}
+
+// limitsFromOptions returns the default limits overridden by any limits set in the options.
+func limitsFromOptions(opts ast.Object) (limits, error) {
+	l := defaultLimits()
+
+	if v := opts.Get(ast.StringTerm(maxSizeAttribute)); v != nil {
+		size, err := positiveInt(v)
+		if err != nil {
+			return l, fmt.Errorf("%s: %w", maxSizeAttribute, err)
+		}
+		l.maxSize = size
+	}
+
+	if v := opts.Get(ast.StringTerm(maxFilesAttribute)); v != nil {
+		count, err := positiveInt(v)
+		if err != nil {
+			return l, fmt.Errorf("%s: %w", maxFilesAttribute, err)
+		}
+		l.maxFiles = int(count)
+	}
+
+	return l, nil
+}
+
+func positiveInt(t *ast.Term) (int64, error) {
+	n, ok := t.Value.(ast.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number, got %s", ast.TypeName(t.Value))
+	}
+
+	i, ok := n.Int64()
+	if !ok || i <= 0 {
+		return 0, fmt.Errorf("expected a positive integer, got %s", n)
+	}
+
+	return i, nil
+}
+
+// budget restricts the content fetched by all calls for a single component, or within a single
+// run for the calls made without a budget of their own. The same content is only charged once.
+type budget struct {
+	maxSize  int64
+	maxFiles int64
+	size     atomic.Int64
+	files    atomic.Int64
+	charged  sync.Map
+	exceeded sync.Once
+}
+
+type budgetContextKey struct{}
+
+var runBudget = sync.OnceValue(initBudget)
+
+func initBudget() *budget {
+	return &budget{
+		maxSize:  envInt("EC_OCI_MAX_TOTAL_SIZE", defaultMaxTotalSize),
+		maxFiles: envInt("EC_OCI_MAX_TOTAL_FILES", defaultMaxTotalFiles),
+	}
+}
+
+func envInt(name string, fallback int64) int64 {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || i <= 0 {
+		log.Warnf("ignoring the value of %s, %q is not a positive integer, using %d", name, v, fallback)
+		return fallback
+	}
+
+	return i
+}
+
+// WithBudget returns a context with a budget of its own, limiting the content fetched by the
+// rego functions evaluated with it independently of any other context, e.g. to apply the limits
+// to each component separately. Without it the limits apply to the whole run.
+func WithBudget(ctx context.Context) context.Context {
+	limits := runBudget()
+	return context.WithValue(ctx, budgetContextKey{}, &budget{maxSize: limits.maxSize, maxFiles: limits.maxFiles})
+}
+
+// budgetFromContext returns the budget of the context, or the budget for the run.
+func budgetFromContext(ctx context.Context) *budget {
+	if b, ok := ctx.Value(budgetContextKey{}).(*budget); ok {
+		return b
+	}
+
+	return runBudget()
+}
+
+// reserve accounts for the given size and number of files, failing when the budget is exceeded.
+// Once exceeded the budget remains exhausted, which is reported once.
+func (b *budget) reserve(size int64, count int64) error {
+	var err error
+	if b.size.Add(size) > b.maxSize {
+		err = fmt.Errorf("%w: more than %d bytes fetched in total", files.ErrLimitExceeded, b.maxSize)
+	} else if b.files.Add(count) > b.maxFiles {
+		err = fmt.Errorf("%w: more than %d files fetched in total", files.ErrLimitExceeded, b.maxFiles)
+	}
+
+	if err != nil {
+		b.exceeded.Do(func() {
+			log.Warnf("No more content is fetched from OCI registries by the rego functions, %v. The limits can be raised using the EC_OCI_MAX_TOTAL_SIZE and EC_OCI_MAX_TOTAL_FILES environment variables.", err)
+		})
+	}
+
+	return err
+}
+
+// charge reserves the given size and number of files for the content with the given key, unless
+// it was already charged for.
+func (b *budget) charge(key string, size int64, count int64) error {
+	if b.isCharged(key) {
+		return nil
+	}
+
+	if err := b.reserve(size, count); err != nil {
+		return err
+	}
+
+	b.markCharged(key)
+	return nil
+}
+
+// isCharged returns true when the content with the given key was already charged for.
+func (b *budget) isCharged(key string) bool {
+	_, ok := b.charged.Load(key)
+	return ok
+}
+
+// markCharged records that the content with the given key was charged for, e.g. when it was
+// reserved while being fetched.
+func (b *budget) markCharged(key string) {
+	b.charged.Store(key, true)
+}
+
+// limitedReader reads from the underlying reader until more than max bytes have been read, at
+// which point it fails instead of reading the remaining content. All bytes read are reserved from
+// the budget, if any.
+type limitedReader struct {
+	r      io.Reader
+	max    int64
+	read   int64
+	budget *budget
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// read at most one byte beyond the limit, enough to detect that it was exceeded
+	if remaining := l.max - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+
+	if l.read > l.max {
+		return n, fmt.Errorf("%w: larger than %d bytes", files.ErrLimitExceeded, l.max)
+	}
+
+	if l.budget != nil {
+		if rerr := l.budget.reserve(int64(n), 0); rerr != nil {
+			return n, rerr
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,170 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci/files"
+)
+
+func TestLimitsFromOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     string
+		expected limits
+		err      string
+	}{
+		{
+			name:     "defaults",
+			opts:     `{}`,
+			expected: defaultLimits(),
+		},
+		{
+			name:     "raised",
+			opts:     `{"max_size": 1073741824, "max_files": 5000}`,
+			expected: limits{maxSize: 1 << 30, maxFiles: 5000},
+		},
+		{
+			name: "zero",
+			opts: `{"max_files": 0}`,
+			err:  "max_files: expected a positive integer, got 0",
+		},
+		{
+			name: "fraction",
+			opts: `{"max_size": 1.5}`,
+			err:  "max_size: expected a positive integer, got 1.5",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := limitsFromOptions(ast.MustParseTerm(c.opts).Value.(ast.Object))
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, l)
+		})
+	}
+}
+
+func TestInitBudget(t *testing.T) {
+	b := initBudget()
+	require.Equal(t, defaultMaxTotalSize, b.maxSize)
+	require.Equal(t, defaultMaxTotalFiles, b.maxFiles)
+
+	t.Setenv("EC_OCI_MAX_TOTAL_SIZE", "2048")
+	t.Setenv("EC_OCI_MAX_TOTAL_FILES", "nope")
+	b = initBudget()
+	require.Equal(t, int64(2048), b.maxSize)
+	require.Equal(t, defaultMaxTotalFiles, b.maxFiles)
+}
+
+func TestBudgetExhausted(t *testing.T) {
+	b := &budget{maxSize: 10, maxFiles: 2}
+
+	require.NoError(t, b.reserve(5, 1))
+	require.NoError(t, b.reserve(5, 1))
+	require.ErrorIs(t, b.reserve(1, 0), files.ErrLimitExceeded)
+	// remains exhausted
+	require.ErrorIs(t, b.reserve(0, 0), files.ErrLimitExceeded)
+}
+
+func TestBudgetExceededReported(t *testing.T) {
+	hook := test.NewGlobal()
+	t.Cleanup(func() {
+		log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+	})
+
+	b := &budget{maxSize: 10, maxFiles: 2}
+	require.NoError(t, b.reserve(10, 1))
+	require.Empty(t, hook.AllEntries())
+
+	require.ErrorIs(t, b.reserve(1, 0), files.ErrLimitExceeded)
+	require.ErrorIs(t, b.reserve(1, 0), files.ErrLimitExceeded)
+	// reported once
+	require.Len(t, hook.AllEntries(), 1)
+	require.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+	require.Contains(t, hook.LastEntry().Message, "EC_OCI_MAX_TOTAL_SIZE")
+}
+
+func TestBudgetCharge(t *testing.T) {
+	b := &budget{maxSize: 10, maxFiles: 2}
+
+	require.NoError(t, b.charge("a", 6, 1))
+	// the same content is charged once
+	require.NoError(t, b.charge("a", 6, 1))
+	require.True(t, b.isCharged("a"))
+	require.ErrorIs(t, b.charge("b", 6, 1), files.ErrLimitExceeded)
+	require.False(t, b.isCharged("b"))
+}
+
+func TestWithBudget(t *testing.T) {
+	ctx := context.Background()
+	require.Same(t, runBudget(), budgetFromContext(ctx))
+
+	first := budgetFromContext(WithBudget(ctx))
+	second := budgetFromContext(WithBudget(ctx))
+	require.NotSame(t, runBudget(), first)
+	require.NotSame(t, first, second)
+	require.Equal(t, runBudget().maxSize, first.maxSize)
+	require.Equal(t, runBudget().maxFiles, first.maxFiles)
+
+	// budgets are independent of each other
+	require.NoError(t, first.reserve(first.maxSize, 0))
+	require.ErrorIs(t, first.reserve(1, 0), files.ErrLimitExceeded)
+	require.NoError(t, second.reserve(1, 0))
+}
+
+func TestLimitedReader(t *testing.T) {
+	content := strings.Repeat("x", 100)
+
+	r := &limitedReader{r: strings.NewReader(content), max: 100, budget: &budget{maxSize: 1000, maxFiles: 1}}
+	var out bytes.Buffer
+	_, err := io.Copy(&out, r)
+	require.NoError(t, err)
+	require.Equal(t, content, out.String())
+
+	source := strings.NewReader(content)
+	r = &limitedReader{r: source, max: 10, budget: &budget{maxSize: 1000, maxFiles: 1}}
+	_, err = io.Copy(io.Discard, r)
+	require.ErrorIs(t, err, files.ErrLimitExceeded)
+	// stops reading right after the limit is exceeded
+	require.Equal(t, 89, source.Len())
+
+	r = &limitedReader{r: strings.NewReader(content), max: 100, budget: &budget{maxSize: 50, maxFiles: 1}}
+	_, err = io.Copy(io.Discard, r)
+	require.ErrorIs(t, err, files.ErrLimitExceeded)
+
+	// without a budget only the limit applies
+	r = &limitedReader{r: strings.NewReader(content), max: 100}
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+}
@@ -21,9 +21,10 @@
 package oci
 
 import (
-	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -38,16 +39,25 @@ import (
 
 	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci/files"
 	"github.com/enterprise-contract/ec-cli/internal/image"
+	"github.com/enterprise-contract/ec-cli/internal/rego/cache"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
 )
 
 const (
-	ociBlobName          = "ec.oci.blob"
-	ociDescriptorName    = "ec.oci.descriptor"
-	ociImageManifestName = "ec.oci.image_manifest"
-	ociImageFilesName    = "ec.oci.image_files"
+	ociBlobName            = "ec.oci.blob"
+	ociDescriptorName      = "ec.oci.descriptor"
+	ociFetchBlobName       = "ec.oci.fetch_blob"
+	ociFetchImageFilesName = "ec.oci.fetch_image_files"
+	ociImageManifestName   = "ec.oci.image_manifest"
+	ociImageFilesName      = "ec.oci.image_files"
+	ociImageIndexName      = "ec.oci.image_index"
+	ociImageReferrersName  = "ec.oci.image_referrers"
+	ociImageTagsName       = "ec.oci.image_tags"
 )
 
+// defaultMaxTags is the maximum number of tags of a repository listed by ec.oci.image_tags.
+const defaultMaxTags = 10_000
+
 func registerOCIBlob() {
 	decl := rego.Function{
 		Name: ociBlobName,
@@ -70,52 +80,97 @@ func registerOCIBlob() {
 	// a declaration that does include the description.
 	ast.RegisterBuiltin(&ast.Builtin{
 		Name:             decl.Name,
-		Description:      "Fetch a blob from an OCI registry.",
+		Description:      fmt.Sprintf("Fetch a blob from an OCI registry. No value is returned for blobs larger than %d bytes, use %s to raise the limit.", defaultMaxSize, ociFetchBlobName),
 		Decl:             decl.Decl,
 		Nondeterministic: decl.Nondeterministic,
 	})
 }
 
-func registerOCIDescriptor() {
-	platform := types.NewObject(
-		[]*types.StaticProperty{
-			{Key: "architecture", Value: types.S},
-			{Key: "os", Value: types.S},
-			{Key: "os.version", Value: types.S},
-			{Key: "os.features", Value: types.NewArray([]types.Type{types.S}, nil)},
-			{Key: "variant", Value: types.S},
-			{Key: "features", Value: types.NewArray([]types.Type{types.S}, nil)},
-		},
-		nil,
-	)
+// limitsOptsParameter is the parameter of the functions allowing the default limits to be raised.
+var limitsOptsParameter = types.Named("opts", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).
+	Description(fmt.Sprintf("options, %s (default %d) is the maximum size in bytes, %s (default %d) the maximum number of files", maxSizeAttribute, defaultMaxSize, maxFilesAttribute, defaultMaxFiles))
 
-	// annotations represents the map[string]string rego type
-	annotations := types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))
-	manifest := types.NewObject(
-		[]*types.StaticProperty{
-			// Specifying the properties like this ensure the compiler catches typos when
-			// evaluating rego functions.
-			{Key: "mediaType", Value: types.S},
-			{Key: "size", Value: types.N},
-			{Key: "digest", Value: types.S},
-			{Key: "data", Value: types.S},
-			{Key: "urls", Value: types.NewArray(
-				[]types.Type{types.S}, nil,
-			)},
-			{Key: "annotations", Value: annotations},
-			{Key: "platform", Value: platform},
-			{Key: "artifactType", Value: types.S},
-		},
-		nil,
-	)
+func registerOCIFetchBlob() {
+	result := types.Named(
+		"result",
+		types.NewObject([]*types.StaticProperty{
+			{Key: "success", Value: types.Named("success", types.B).Description("true when the blob is fetched")},
+			{Key: "errors", Value: types.Named("errors", types.NewArray(nil, types.S)).Description("errors, including any limit exceeded")},
+			{Key: "blob", Value: types.Named("blob", types.S).Description("the OCI blob, only present when successful")},
+		}, nil),
+	).Description("the result of the fetch request")
+
+	decl := rego.Function{
+		Name: ociFetchBlobName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI blob reference"),
+				limitsOptsParameter,
+			),
+			result,
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin2(&decl, ociFetchBlob)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      fmt.Sprintf("Fetch a blob from an OCI registry, like %s, reporting any errors. The max_size option raises the maximum size of the blob.", ociBlobName),
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+// platformType is the rego type of the platform of an OCI descriptor.
+var platformType = types.NewObject(
+	[]*types.StaticProperty{
+		{Key: "architecture", Value: types.S},
+		{Key: "os", Value: types.S},
+		{Key: "os.version", Value: types.S},
+		{Key: "os.features", Value: types.NewArray([]types.Type{types.S}, nil)},
+		{Key: "variant", Value: types.S},
+		{Key: "features", Value: types.NewArray([]types.Type{types.S}, nil)},
+	},
+	nil,
+)
+
+// annotationsType represents the map[string]string rego type.
+var annotationsType = types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))
+
+// descriptorType is the rego type of an OCI descriptor.
+var descriptorType = types.NewObject(
+	[]*types.StaticProperty{
+		// Specifying the properties like this ensure the compiler catches typos when
+		// evaluating rego functions.
+		{Key: "mediaType", Value: types.S},
+		{Key: "size", Value: types.N},
+		{Key: "digest", Value: types.S},
+		{Key: "data", Value: types.S},
+		{Key: "urls", Value: types.NewArray(
+			[]types.Type{types.S}, nil,
+		)},
+		{Key: "annotations", Value: annotationsType},
+		{Key: "platform", Value: platformType},
+		{Key: "artifactType", Value: types.S},
+	},
+	nil,
+)
 
+func registerOCIDescriptor() {
 	decl := rego.Function{
 		Name: ociDescriptorName,
 		Decl: types.NewFunction(
 			types.Args(
 				types.Named("ref", types.S).Description("OCI descriptor reference"),
 			),
-			types.Named("object", manifest).Description("the OCI descriptor object"),
+			types.Named("object", descriptorType).Description("the OCI descriptor object"),
 		),
 		// As per the documentation, enable memoization to ensure function evaluation is
 		// deterministic. But also mark it as non-deterministic because it does rely on external
@@ -137,60 +192,101 @@ func registerOCIDescriptor() {
 }
 
 func registerOCIImageManifest() {
-	platform := types.NewObject(
-		[]*types.StaticProperty{
-			{Key: "architecture", Value: types.S},
-			{Key: "os", Value: types.S},
-			{Key: "os.version", Value: types.S},
-			{Key: "os.features", Value: types.NewArray([]types.Type{types.S}, nil)},
-			{Key: "variant", Value: types.S},
-			{Key: "features", Value: types.NewArray([]types.Type{types.S}, nil)},
-		},
-		nil,
-	)
-
-	// annotations represents the map[string]string rego type
-	annotations := types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))
-
-	descriptor := types.NewObject(
+	manifest := types.NewObject(
 		[]*types.StaticProperty{
+			// Specifying the properties like this ensure the compiler catches typos when
+			// evaluating rego functions.
+			{Key: "schemaVersion", Value: types.N},
 			{Key: "mediaType", Value: types.S},
-			{Key: "size", Value: types.N},
-			{Key: "digest", Value: types.S},
-			{Key: "data", Value: types.S},
-			{Key: "urls", Value: types.NewArray(
-				[]types.Type{types.S}, nil,
+			{Key: "config", Value: descriptorType},
+			{Key: "layers", Value: types.NewArray(
+				[]types.Type{descriptorType}, nil,
 			)},
-			{Key: "annotations", Value: annotations},
-			{Key: "platform", Value: platform},
-			{Key: "artifactType", Value: types.S},
+			{Key: "annotations", Value: annotationsType},
+			{Key: "subject", Value: descriptorType},
 		},
 		nil,
 	)
 
-	manifest := types.NewObject(
+	decl := rego.Function{
+		Name: ociImageManifestName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference"),
+			),
+			types.Named("object", manifest).Description("the Image Manifest object"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, ociImageManifest)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Fetch an Image Manifest from an OCI registry.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerOCIImageIndex() {
+	index := types.NewObject(
 		[]*types.StaticProperty{
 			// Specifying the properties like this ensure the compiler catches typos when
 			// evaluating rego functions.
 			{Key: "schemaVersion", Value: types.N},
 			{Key: "mediaType", Value: types.S},
-			{Key: "config", Value: descriptor},
-			{Key: "layers", Value: types.NewArray(
-				[]types.Type{descriptor}, nil,
+			{Key: "manifests", Value: types.NewArray(
+				[]types.Type{descriptorType}, nil,
 			)},
-			{Key: "annotations", Value: annotations},
-			{Key: "subject", Value: descriptor},
+			{Key: "annotations", Value: annotationsType},
+			{Key: "subject", Value: descriptorType},
 		},
 		nil,
 	)
 
 	decl := rego.Function{
-		Name: ociImageManifestName,
+		Name: ociImageIndexName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image index reference"),
+			),
+			types.Named("object", index).Description("the Image Index object"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, ociImageIndex)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Fetch an Image Index from an OCI registry, listing the manifest of each platform.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerOCIImageReferrers() {
+	decl := rego.Function{
+		Name: ociImageReferrersName,
 		Decl: types.NewFunction(
 			types.Args(
 				types.Named("ref", types.S).Description("OCI image reference"),
+				types.Named("artifactType", types.S).Description("the artifact type of the referrers, or an empty string for all of them"),
 			),
-			types.Named("object", manifest).Description("the Image Manifest object"),
+			types.Named("referrers", types.NewArray(nil, descriptorType)).Description("the descriptors of the referring manifests"),
 		),
 		// As per the documentation, enable memoization to ensure function evaluation is
 		// deterministic. But also mark it as non-deterministic because it does rely on external
@@ -199,13 +295,41 @@ func registerOCIImageManifest() {
 		Nondeterministic: true,
 	}
 
-	rego.RegisterBuiltin1(&decl, ociImageManifest)
+	rego.RegisterBuiltin2(&decl, ociImageReferrers)
 	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
 	// the custom function through the call above. As a workaround we re-register the function with
 	// a declaration that does include the description.
 	ast.RegisterBuiltin(&ast.Builtin{
 		Name:             decl.Name,
-		Description:      "Fetch an Image Manifest from an OCI registry.",
+		Description:      "Fetch the OCI 1.1 referrers of an image, e.g. its signatures, attestations and SBOMs, falling back to the referrers tag schema when the registry does not support the referrers API.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerOCIImageTags() {
+	decl := rego.Function{
+		Name: ociImageTagsName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("repository", types.S).Description("OCI repository, or an image reference in the repository"),
+			),
+			types.Named("tags", types.NewArray(nil, types.S)).Description("the tags of the repository"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin1(&decl, ociImageTags)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      fmt.Sprintf("List the tags of an OCI repository. At most the first %d tags are listed.", defaultMaxTags),
 		Decl:             decl.Decl,
 		Nondeterministic: decl.Nondeterministic,
 	})
@@ -222,7 +346,7 @@ func registerOCIImageFiles() {
 
 	decl := rego.Function{
 		Name:        ociImageFilesName,
-		Description: "Fetch structured files (YAML or JSON) from within an image.",
+		Description: fmt.Sprintf("Fetch structured files (YAML or JSON) from within an image. No value is returned when more than %d files, or more than %d bytes, are matched, use %s to raise the limits.", defaultMaxFiles, defaultMaxSize, ociFetchImageFilesName),
 		Decl: types.NewFunction(
 			types.Args(
 				types.Named("ref", types.S).Description("OCI image reference"),
@@ -240,6 +364,45 @@ func registerOCIImageFiles() {
 	rego.RegisterBuiltin2(&decl, ociImageFiles)
 }
 
+func registerOCIFetchImageFiles() {
+	filesObject := types.NewObject(
+		nil,
+		types.NewDynamicProperty(
+			types.Named("path", types.S).Description("the full path of the file within the image"),
+			types.Named("content", types.A).Description("the file contents"),
+		),
+	)
+
+	result := types.Named(
+		"result",
+		types.NewObject([]*types.StaticProperty{
+			{Key: "success", Value: types.Named("success", types.B).Description("true when the files are extracted")},
+			{Key: "errors", Value: types.Named("errors", types.NewArray(nil, types.S)).Description("errors, including any limit exceeded")},
+			{Key: "files", Value: types.Named("files", filesObject).Description("object representing the extracted files, only present when successful")},
+		}, nil),
+	).Description("the result of the extraction request")
+
+	decl := rego.Function{
+		Name:        ociFetchImageFilesName,
+		Description: fmt.Sprintf("Fetch structured files (YAML or JSON) from within an image, like %s, reporting any errors. The max_files and max_size options raise the maximum number and combined size of the files.", ociImageFilesName),
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference"),
+				types.Named("paths", types.NewArray([]types.Type{types.S}, nil)).Description("the list of paths"),
+				limitsOptsParameter,
+			),
+			result,
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin3(&decl, ociFetchImageFiles)
+}
+
 func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	logger := log.WithField("function", ociBlobName)
 
@@ -248,34 +411,100 @@ func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 		logger.Error("input is not a string")
 		return nil, nil
 	}
-	logger = logger.WithField("ref", string(uri))
-	logger.Debug("Starting blob retrieval")
 
-	ref, err := name.NewDigest(string(uri))
+	blob, err := fetchBlob(bctx.Context, string(uri), defaultLimits())
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "new digest",
-			"error":  err,
-		}).Error("failed to create new digest")
+		logger = logger.WithFields(log.Fields{
+			"ref":   string(uri),
+			"error": err,
+		})
+		if errors.Is(err, files.ErrLimitExceeded) {
+			logger.Warnf("blob exceeds the limits, use %s to raise them", ociFetchBlobName)
+			return nil, nil
+		}
+		logger.Error("failed to fetch blob")
 		return nil, nil
 	}
 
-	rawLayer, err := oci.NewClient(bctx.Context).Layer(ref)
+	return blob, nil
+}
+
+func ociFetchBlob(bctx rego.BuiltinContext, refTerm *ast.Term, optsTerm *ast.Term) (*ast.Term, error) {
+	uri, err := builtins.StringOperand(refTerm.Value, 1)
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "fetch layer",
-			"error":  err,
-		}).Error("failed to fetch OCI layer")
-		return nil, nil
+		return blobResult(nil, fmt.Errorf("ref parameter: %w", err))
+	}
+
+	opts, err := builtins.ObjectOperand(optsTerm.Value, 2)
+	if err != nil {
+		return blobResult(nil, fmt.Errorf("opts parameter: %w", err))
+	}
+
+	limits, err := limitsFromOptions(opts)
+	if err != nil {
+		return blobResult(nil, fmt.Errorf("opts parameter: %w", err))
+	}
+
+	return blobResult(fetchBlob(bctx.Context, string(uri), limits))
+}
+
+func blobResult(blob *ast.Term, err error) (*ast.Term, error) {
+	items := [][2]*ast.Term{
+		ast.Item(ast.StringTerm("success"), ast.BooleanTerm(err == nil)),
+	}
+
+	if err != nil {
+		items = append(items, ast.Item(ast.StringTerm("errors"), ast.ArrayTerm(ast.StringTerm(err.Error()))))
+	} else {
+		items = append(items,
+			ast.Item(ast.StringTerm("errors"), ast.ArrayTerm()),
+			ast.Item(ast.StringTerm("blob"), blob),
+		)
+	}
+
+	return ast.ObjectTerm(items...), nil
+}
+
+// fetchBlob fetches the blob with the given digest reference. The blob is streamed from the
+// registry to the cache, verifying its digest and failing as soon as it exceeds the given limits.
+// The blob is charged to the budget once, whether it is fetched or cached.
+func fetchBlob(ctx context.Context, uri string, limits limits) (*ast.Term, error) {
+	logger := log.WithField("function", ociBlobName).WithField("ref", uri)
+	logger.Debug("Starting blob retrieval")
+
+	ref, err := name.NewDigest(uri)
+	if err != nil {
+		return nil, fmt.Errorf("new digest: %w", err)
+	}
+
+	// The repository is part of the key, the content may only be available in some repositories
+	key := cache.Key(ref.Context().Name(), ref.DigestStr())
+	c := cache.FromContext(ctx)
+	if term, ok := c.Get(ctx, ociBlobName, key); ok {
+		// The cached blob is verified like a fetched one, any other blob is fetched again
+		if blob, ok := term.Value.(ast.String); ok && fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(blob))) == ref.DigestStr() {
+			logger.Debug("Using cached blob")
+			// Check the cached blob against the limits so that the result does not depend on the
+			// limits in effect when the blob was first fetched, or on whether it was cached
+			if int64(len(blob)) > limits.maxSize {
+				return nil, fmt.Errorf("%w: larger than %d bytes", files.ErrLimitExceeded, limits.maxSize)
+			}
+			if err := budgetFromContext(ctx).charge(ref.DigestStr(), int64(len(blob)), 0); err != nil {
+				return nil, fmt.Errorf("read blob: %w", err)
+			}
+			return term, nil
+		}
+		logger.Warn("Cached blob does not match its digest, fetching it again")
+	}
+
+	rawLayer, err := oci.NewClient(ctx).Layer(ref)
+	if err != nil {
+		return nil, fmt.Errorf("fetch layer: %w", err)
 	}
 
 	layer, err := rawLayer.Uncompressed()
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "uncompress layer",
-			"error":  err,
-		}).Error("failed to uncompress OCI layer")
-		return nil, nil
+		return nil, fmt.Errorf("uncompress layer: %w", err)
 	}
 	defer layer.Close()
 
@@ -283,34 +512,38 @@ func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	// not complete in the go-containerregistry library, e.g. name.NewDigest throws an error if
 	// sha256 is not used. This is good for now, but may need revisiting later.
 	hasher := sha256.New()
-	reader := io.TeeReader(layer, hasher)
+	budget := budgetFromContext(ctx)
+	reader := &limitedReader{
+		r:   layer,
+		max: limits.maxSize,
+	}
+	if !budget.isCharged(ref.DigestStr()) {
+		reader.budget = budget
+	}
 
-	var blob bytes.Buffer
-	if _, err := io.Copy(&blob, reader); err != nil {
-		logger.WithFields(log.Fields{
-			"action": "copy buffer",
-			"error":  err,
-		}).Error("failed to copy data into buffer")
-		return nil, nil
+	var blob strings.Builder
+	cached := c.NewStringWriter(ociBlobName, key)
+	if _, err := io.Copy(io.MultiWriter(&blob, hasher, cached), reader); err != nil {
+		cached.Discard()
+		return nil, fmt.Errorf("read blob: %w", err)
 	}
 
 	sum := fmt.Sprintf("sha256:%x", hasher.Sum(nil))
-	// io.LimitReader truncates the layer if it exceeds its limit. The condition below catches this
-	// scenario in order to avoid unexpected behavior caused by partial data being returned.
+	// Verifying the digest ensures the content, which was read in full, is the expected one and
+	// not, for example, truncated.
 	if sum != ref.DigestStr() {
-		logger.WithFields(log.Fields{
-			"action":          "verify digest",
-			"computed_digest": sum,
-			"expected_digest": ref.DigestStr(),
-		}).Error("computed digest does not match expected digest")
-		return nil, nil
+		cached.Discard()
+		return nil, fmt.Errorf("computed digest %s does not match expected digest %s", sum, ref.DigestStr())
 	}
+	budget.markCharged(ref.DigestStr())
 
 	logger.WithFields(log.Fields{
 		"action": "complete",
 		"digest": sum,
 	}).Debug("Successfully retrieved blob")
-	return ast.StringTerm(blob.String()), nil
+	term := ast.StringTerm(blob.String())
+	cached.Commit(ctx, term)
+	return term, nil
 }
 
 func ociDescriptor(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
@@ -342,6 +575,14 @@ func ociDescriptor(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 		return nil, nil
 	}
 
+	// The repository is part of the key, the content may only be available in some repositories
+	key := cache.Key(ref.Context().Name(), ref.DigestStr())
+	c := cache.FromContext(bctx.Context)
+	if term, ok := c.Get(bctx.Context, ociDescriptorName, key); ok {
+		logger.Debug("Using cached descriptor")
+		return term, nil
+	}
+
 	descriptor, err := client.Head(ref)
 	if err != nil {
 		logger.WithFields(log.Fields{
@@ -352,7 +593,9 @@ func ociDescriptor(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	}
 
 	logger.Debug("Successfully retrieved descriptor")
-	return newDescriptorTerm(*descriptor), nil
+	term := newDescriptorTerm(*descriptor)
+	c.Put(bctx.Context, ociDescriptorName, key, term)
+	return term, nil
 }
 
 func ociImageManifest(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
@@ -384,6 +627,14 @@ func ociImageManifest(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error)
 		return nil, nil
 	}
 
+	// The repository is part of the key, the content may only be available in some repositories
+	key := cache.Key(ref.Context().Name(), ref.DigestStr())
+	c := cache.FromContext(bctx.Context)
+	if term, ok := c.Get(bctx.Context, ociImageManifestName, key); ok {
+		logger.Debug("Using cached image manifest")
+		return term, nil
+	}
+
 	image, err := client.Image(ref)
 	if err != nil {
 		logger.WithFields(log.Fields{
@@ -425,21 +676,121 @@ func ociImageManifest(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error)
 	}
 
 	logger.Debug("Successfully retrieved image manifest")
-	return ast.ObjectTerm(manifestTerms...), nil
+	term := ast.ObjectTerm(manifestTerms...)
+	c.Put(bctx.Context, ociImageManifestName, key, term)
+	return term, nil
 }
 
-func ociImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.Term) (*ast.Term, error) {
-	logger := log.WithField("function", ociImageFilesName)
+func ociImageIndex(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageIndexName)
 
-	uri, ok := refTerm.Value.(ast.String)
+	uriValue, ok := a.Value.(ast.String)
+	if !ok {
+		logger.Error("input is not a string")
+		return nil, nil
+	}
+	logger = logger.WithField("input_ref", string(uriValue))
+	logger.Debug("Starting image index retrieval")
+
+	client := oci.NewClient(bctx.Context)
+
+	uri, err := resolveIfNeeded(client, string(uriValue))
+	if err != nil {
+		logger.WithField("action", "resolveIfNeeded").Error(err)
+		return nil, nil
+	}
+	logger = logger.WithField("ref", uri)
+
+	ref, err := name.NewDigest(uri)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "new digest",
+			"error":  err,
+		}).Error("failed to create new digest")
+		return nil, nil
+	}
+
+	// The repository is part of the key, the content may only be available in some repositories
+	key := cache.Key(ref.Context().Name(), ref.DigestStr())
+	c := cache.FromContext(bctx.Context)
+	if term, ok := c.Get(bctx.Context, ociImageIndexName, key); ok {
+		logger.Debug("Using cached image index")
+		return term, nil
+	}
+
+	index, err := client.Index(ref)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "fetch index",
+			"error":  err,
+		}).Error("failed to fetch image index")
+		return nil, nil
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "fetch index manifest",
+			"error":  err,
+		}).Error("failed to fetch index manifest")
+		return nil, nil
+	}
+
+	if indexManifest == nil {
+		logger.Error("index manifest is nil")
+		return nil, nil
+	}
+
+	manifests := []*ast.Term{}
+	for _, manifest := range indexManifest.Manifests {
+		manifests = append(manifests, newDescriptorTerm(manifest))
+	}
+
+	indexTerms := [][2]*ast.Term{
+		ast.Item(ast.StringTerm("schemaVersion"), ast.NumberTerm(json.Number(fmt.Sprintf("%d", indexManifest.SchemaVersion)))),
+		ast.Item(ast.StringTerm("mediaType"), ast.StringTerm(string(indexManifest.MediaType))),
+		ast.Item(ast.StringTerm("manifests"), ast.ArrayTerm(manifests...)),
+		ast.Item(ast.StringTerm("annotations"), newAnnotationsTerm(indexManifest.Annotations)),
+	}
+
+	if s := indexManifest.Subject; s != nil {
+		indexTerms = append(indexTerms, ast.Item(ast.StringTerm("subject"), newDescriptorTerm(*s)))
+	}
+
+	logger.Debug("Successfully retrieved image index")
+	term := ast.ObjectTerm(indexTerms...)
+	c.Put(bctx.Context, ociImageIndexName, key, term)
+	return term, nil
+}
+
+func ociImageReferrers(bctx rego.BuiltinContext, refTerm *ast.Term, artifactTypeTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageReferrersName)
+
+	uriValue, ok := refTerm.Value.(ast.String)
 	if !ok {
 		logger.Error("input ref is not a string")
 		return nil, nil
 	}
-	logger = logger.WithField("ref", string(uri))
-	logger.Debug("Starting image files extraction")
+	logger = logger.WithField("input_ref", string(uriValue))
+
+	artifactType, ok := artifactTypeTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("input artifact type is not a string")
+		return nil, nil
+	}
+	logger = logger.WithField("artifact_type", string(artifactType))
+	logger.Debug("Starting image referrers retrieval")
+
+	client := oci.NewClient(bctx.Context)
+
+	uri, err := resolveIfNeeded(client, string(uriValue))
+	if err != nil {
+		logger.WithField("action", "resolveIfNeeded").Error(err)
+		return nil, nil
+	}
+	logger = logger.WithField("ref", uri)
 
-	ref, err := name.NewDigest(string(uri))
+	ref, err := name.NewDigest(uri)
 	if err != nil {
 		logger.WithFields(log.Fields{
 			"action": "new digest",
@@ -448,15 +799,171 @@ func ociImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.T
 		return nil, nil
 	}
 
-	pathsArray, err := builtins.ArrayOperand(pathsTerm.Value, 1)
+	index, err := client.Referrers(ref, string(artifactType))
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "fetch referrers",
+			"error":  err,
+		}).Error("failed to fetch referrers")
+		return nil, nil
+	}
+
+	indexManifest, err := index.IndexManifest()
 	if err != nil {
 		logger.WithFields(log.Fields{
-			"action": "convert paths",
+			"action": "fetch referrers manifest",
 			"error":  err,
-		}).Error("failed to convert paths to array operand")
+		}).Error("failed to fetch referrers index manifest")
 		return nil, nil
 	}
 
+	referrers := []*ast.Term{}
+	if indexManifest != nil {
+		for _, manifest := range indexManifest.Manifests {
+			referrers = append(referrers, newDescriptorTerm(manifest))
+		}
+	}
+
+	logger.WithField("count", len(referrers)).Debug("Successfully retrieved image referrers")
+	return ast.ArrayTerm(referrers...), nil
+}
+
+func ociImageTags(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageTagsName)
+
+	uri, ok := a.Value.(ast.String)
+	if !ok {
+		logger.Error("input is not a string")
+		return nil, nil
+	}
+	logger = logger.WithField("input_ref", string(uri))
+
+	tags, truncated, err := listImageTags(bctx.Context, string(uri), defaultMaxTags)
+	if err != nil {
+		logger.WithField("error", err).Error("failed to list tags")
+		return nil, nil
+	}
+
+	if truncated {
+		logger.Warnf("repository has more than %d tags, only the first ones are listed", defaultMaxTags)
+	}
+
+	return tagsTerm(tags), nil
+}
+
+func tagsTerm(tags []string) *ast.Term {
+	terms := make([]*ast.Term, 0, len(tags))
+	for _, tag := range tags {
+		terms = append(terms, ast.StringTerm(tag))
+	}
+
+	return ast.ArrayTerm(terms...)
+}
+
+// listImageTags lists at most limit tags of the repository, which can also be given as an image
+// reference in the repository, returning whether the repository has more tags.
+func listImageTags(ctx context.Context, uri string, limit int) ([]string, bool, error) {
+	logger := log.WithField("function", ociImageTagsName).WithField("input_ref", uri)
+	logger.Debug("Starting image tags listing")
+
+	// Image references, with a tag or a digest, are accepted for convenience
+	ref, err := name.ParseReference(uri)
+	if err != nil {
+		return nil, false, fmt.Errorf("parse reference: %w", err)
+	}
+	repo := ref.Context()
+
+	tags, truncated, err := oci.NewClient(ctx).ListTags(repo, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("list tags: %w", err)
+	}
+
+	logger.WithFields(log.Fields{
+		"repository": repo.Name(),
+		"count":      len(tags),
+		"truncated":  truncated,
+	}).Debug("Successfully listed image tags")
+	return tags, truncated, nil
+}
+
+func ociImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageFilesName)
+
+	uri, ok := refTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("input ref is not a string")
+		return nil, nil
+	}
+
+	extracted, err := fetchImageFiles(bctx.Context, string(uri), pathsTerm, defaultLimits())
+	if err != nil {
+		logger = logger.WithFields(log.Fields{
+			"ref":   string(uri),
+			"error": err,
+		})
+		if errors.Is(err, files.ErrLimitExceeded) {
+			logger.Warnf("image files exceed the limits, use %s to raise them", ociFetchImageFilesName)
+			return nil, nil
+		}
+		logger.Error("failed to extract image files")
+		return nil, nil
+	}
+
+	return extracted, nil
+}
+
+func ociFetchImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.Term, optsTerm *ast.Term) (*ast.Term, error) {
+	uri, err := builtins.StringOperand(refTerm.Value, 1)
+	if err != nil {
+		return filesResult(nil, fmt.Errorf("ref parameter: %w", err))
+	}
+
+	opts, err := builtins.ObjectOperand(optsTerm.Value, 3)
+	if err != nil {
+		return filesResult(nil, fmt.Errorf("opts parameter: %w", err))
+	}
+
+	limits, err := limitsFromOptions(opts)
+	if err != nil {
+		return filesResult(nil, fmt.Errorf("opts parameter: %w", err))
+	}
+
+	return filesResult(fetchImageFiles(bctx.Context, string(uri), pathsTerm, limits))
+}
+
+func filesResult(files *ast.Term, err error) (*ast.Term, error) {
+	items := [][2]*ast.Term{
+		ast.Item(ast.StringTerm("success"), ast.BooleanTerm(err == nil)),
+	}
+
+	if err != nil {
+		items = append(items, ast.Item(ast.StringTerm("errors"), ast.ArrayTerm(ast.StringTerm(err.Error()))))
+	} else {
+		items = append(items,
+			ast.Item(ast.StringTerm("errors"), ast.ArrayTerm()),
+			ast.Item(ast.StringTerm("files"), files),
+		)
+	}
+
+	return ast.ObjectTerm(items...), nil
+}
+
+// fetchImageFiles extracts the files at the given paths from the image with the given digest
+// reference, failing as soon as the files exceed the given limits.
+func fetchImageFiles(ctx context.Context, uri string, pathsTerm *ast.Term, limits limits) (*ast.Term, error) {
+	logger := log.WithField("function", ociImageFilesName).WithField("ref", uri)
+	logger.Debug("Starting image files extraction")
+
+	ref, err := name.NewDigest(uri)
+	if err != nil {
+		return nil, fmt.Errorf("new digest: %w", err)
+	}
+
+	pathsArray, err := builtins.ArrayOperand(pathsTerm.Value, 2)
+	if err != nil {
+		return nil, fmt.Errorf("paths parameter: %w", err)
+	}
+
 	var extractors []files.Extractor
 	err = pathsArray.Iter(func(pathTerm *ast.Term) error {
 		pathString, ok := pathTerm.Value.(ast.String)
@@ -467,33 +974,66 @@ func ociImageFiles(bctx rego.BuiltinContext, refTerm *ast.Term, pathsTerm *ast.T
 		return nil
 	})
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "iterate paths",
-			"error":  err,
-		}).Error("failed iterating paths")
-		return nil, nil
+		return nil, fmt.Errorf("paths parameter: %w", err)
+	}
+
+	// The same image may be queried for different paths, so those are part of the key, along with
+	// the repository. So are the limits, the result depends on them and it cannot be checked
+	// against different limits once the files have been extracted.
+	key := cache.Key(ref.Context().Name(), ref.DigestStr(), pathsArray.String(), fmt.Sprintf("%d/%d", limits.maxSize, limits.maxFiles))
+	c := cache.FromContext(ctx)
+	budget := budgetFromContext(ctx)
+	if term, ok := c.Get(ctx, ociImageFilesName, key); ok {
+		logger.Debug("Using cached image files")
+		// The cached files count towards the budget as if they were extracted, unless they were
+		// already charged for
+		if !budget.isCharged(key) {
+			if err := reserveFiles(budget, term); err != nil {
+				return nil, fmt.Errorf("extract files: %w", err)
+			}
+			budget.markCharged(key)
+		}
+		return term, nil
 	}
 
-	files, err := files.ImageFiles(bctx.Context, ref, extractors)
+	charged := budget.isCharged(key)
+	extracted, err := files.ImageFilesWithLimits(ctx, ref, extractors, files.Limits{
+		MaxSize:  limits.maxSize,
+		MaxFiles: limits.maxFiles,
+		Reserve: func(size int64) error {
+			if charged {
+				return nil
+			}
+			return budget.reserve(size, 1)
+		},
+	})
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "extract files",
-			"error":  err,
-		}).Error("failed to extract image files")
-		return nil, nil
+		return nil, fmt.Errorf("extract files: %w", err)
 	}
+	budget.markCharged(key)
 
-	filesValue, err := ast.InterfaceToValue(files)
+	filesValue, err := ast.InterfaceToValue(extracted)
 	if err != nil {
-		logger.WithFields(log.Fields{
-			"action": "convert files",
-			"error":  err,
-		}).Error("failed to convert files object to value")
-		return nil, nil
+		return nil, fmt.Errorf("convert files: %w", err)
 	}
 
 	logger.Debug("Successfully extracted image files")
-	return ast.NewTerm(filesValue), nil
+	term := ast.NewTerm(filesValue)
+	c.Put(ctx, ociImageFilesName, key, term)
+	return term, nil
+}
+
+// reserveFiles reserves the files of a cached ec.oci.image_files result from the budget, using the
+// size of their content.
+func reserveFiles(budget *budget, term *ast.Term) error {
+	obj, ok := term.Value.(ast.Object)
+	if !ok {
+		return nil
+	}
+
+	return obj.Iter(func(_, content *ast.Term) error {
+		return budget.reserve(int64(len(content.String())), 1)
+	})
 }
 
 func newPlatformTerm(p v1.Platform) *ast.Term {
@@ -570,6 +1110,11 @@ func resolveIfNeeded(client oci.Client, uri string) (string, error) {
 func init() {
 	registerOCIBlob()
 	registerOCIDescriptor()
+	registerOCIFetchBlob()
+	registerOCIFetchImageFiles()
 	registerOCIImageFiles()
+	registerOCIImageIndex()
 	registerOCIImageManifest()
+	registerOCIImageReferrers()
+	registerOCIImageTags()
 }
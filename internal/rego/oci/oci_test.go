@@ -25,6 +25,7 @@ import (
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	v1fake "github.com/google/go-containerregistry/pkg/v1/fake"
 	"github.com/google/go-containerregistry/pkg/v1/static"
@@ -34,6 +35,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/enterprise-contract/ec-cli/internal/rego/cache"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci/fake"
 )
@@ -93,7 +95,7 @@ func TestOCIBlob(t *testing.T) {
 				layer := static.NewLayer([]byte(c.data), types.OCIUncompressedLayer)
 				client.On("Layer", mock.Anything, mock.Anything).Return(layer, nil)
 			}
-			ctx := oci.WithClient(context.Background(), &client)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			blob, err := ociBlob(bctx, c.uri)
@@ -110,6 +112,54 @@ func TestOCIBlob(t *testing.T) {
 	}
 }
 
+func TestOCIBlobCached(t *testing.T) {
+	data := `{"spam": "maps"}`
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+
+	client := fake.FakeClient{}
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(data), types.OCIUncompressedLayer), nil).Once()
+
+	dir := t.TempDir()
+	ctx := cache.WithCache(oci.WithClient(context.Background(), &client), cache.New(dir))
+
+	blob, err := ociBlob(rego.BuiltinContext{Context: ctx}, uri)
+	require.NoError(t, err)
+	require.Equal(t, ast.StringTerm(data), blob)
+
+	// a new cache in the same directory simulates a subsequent run
+	ctx = cache.WithCache(oci.WithClient(context.Background(), &client), cache.New(dir))
+
+	blob, err = ociBlob(rego.BuiltinContext{Context: ctx}, uri)
+	require.NoError(t, err)
+	require.Equal(t, ast.StringTerm(data), blob)
+
+	client.AssertNumberOfCalls(t, "Layer", 1)
+}
+
+func TestOCIBlobCachedVerified(t *testing.T) {
+	data := `{"spam": "maps"}`
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+
+	client := fake.FakeClient{}
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(data), types.OCIUncompressedLayer), nil)
+
+	c := cache.New(t.TempDir())
+	ctx := cache.WithCache(oci.WithClient(context.Background(), &client), c)
+	c.Put(ctx, ociBlobName, cache.Key("registry.local/spam", "sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"), ast.StringTerm(`{"spam": "eggs"}`))
+
+	// the cached blob does not match the digest, it is fetched again
+	blob, err := ociBlob(rego.BuiltinContext{Context: ctx}, uri)
+	require.NoError(t, err)
+	require.Equal(t, ast.StringTerm(data), blob)
+	client.AssertNumberOfCalls(t, "Layer", 1)
+
+	// the same blob in another repository is fetched from that repository
+	blob, err = ociBlob(rego.BuiltinContext{Context: ctx}, ast.StringTerm("registry.local/ham@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"))
+	require.NoError(t, err)
+	require.Equal(t, ast.StringTerm(data), blob)
+	client.AssertNumberOfCalls(t, "Layer", 2)
+}
+
 func TestOCIDescriptorManifest(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -205,7 +255,7 @@ func TestOCIDescriptorManifest(t *testing.T) {
 			} else if c.resolvedDigest != "" {
 				client.On("ResolveDigest", mock.Anything).Return(c.resolvedDigest, nil)
 			}
-			ctx := oci.WithClient(context.Background(), &client)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			got, err := ociDescriptor(bctx, c.ref)
@@ -243,7 +293,7 @@ func TestOCIDescriptorErrors(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			client := fake.FakeClient{}
 			client.On("Head", mock.Anything, mock.Anything).Return(nil, errors.New("expected"))
-			ctx := oci.WithClient(context.Background(), &client)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			got, err := ociDescriptor(bctx, c.ref)
@@ -450,7 +500,7 @@ func TestOCIImageManifest(t *testing.T) {
 			} else if c.resolvedDigest != "" {
 				client.On("ResolveDigest", mock.Anything).Return(c.resolvedDigest, nil)
 			}
-			ctx := oci.WithClient(context.Background(), &client)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			got, err := ociImageManifest(bctx, c.ref)
@@ -464,6 +514,313 @@ func TestOCIImageManifest(t *testing.T) {
 		})
 	}
 }
+func TestOCIImageIndex(t *testing.T) {
+	cases := []struct {
+		name           string
+		ref            *ast.Term
+		index          *v1.IndexManifest
+		resolvedDigest string
+		resolveErr     error
+		indexErr       error
+		manifestErr    error
+		wantErr        bool
+	}{
+		{
+			name: "complete image index",
+			ref:  ast.StringTerm("registry.local/spam:latest@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			index: &v1.IndexManifest{
+				SchemaVersion: 2,
+				MediaType:     types.OCIImageIndex,
+				Manifests: []v1.Descriptor{
+					{
+						MediaType: types.OCIManifestSchema1,
+						Size:      123,
+						Digest: v1.Hash{
+							Algorithm: "sha256",
+							Hex:       "4e388ab32b10dc8dbc7e28144f552830adc74787c1e2c0824032078a79f227fb",
+						},
+						Platform: &v1.Platform{
+							Architecture: "amd64",
+							OS:           "linux",
+						},
+					},
+					{
+						MediaType: types.OCIManifestSchema1,
+						Size:      456,
+						Digest: v1.Hash{
+							Algorithm: "sha256",
+							Hex:       "325392e8dd2826a53a9a35b7a7f8d71683cd27ebc2c73fee85dab673bc909b67",
+						},
+						Annotations: map[string]string{
+							"manifest.annotation": "manifest.annotation.value",
+						},
+						Platform: &v1.Platform{
+							Architecture: "arm64",
+							OS:           "linux",
+							Variant:      "v8",
+						},
+					},
+				},
+				Annotations: map[string]string{
+					"index.annotation": "index.annotation.value",
+				},
+				Subject: &v1.Descriptor{
+					MediaType: types.OCIManifestSchema1,
+					Size:      8888,
+					Digest: v1.Hash{
+						Algorithm: "sha256",
+						Hex:       "d9298a10d1b0735837dc4bd85dac641b0f3cef27a47e5d53a54f2f3f5b2fcffa",
+					},
+				},
+			},
+		},
+		{
+			name:           "missing digest",
+			ref:            ast.StringTerm("registry.local/spam:latest"),
+			resolvedDigest: "sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b",
+			index: &v1.IndexManifest{
+				SchemaVersion: 2,
+				MediaType:     types.OCIImageIndex,
+				Manifests:     []v1.Descriptor{},
+			},
+		},
+		{
+			name:    "bad image ref",
+			ref:     ast.StringTerm("......registry.local/spam:latest@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid ref type",
+			ref:     ast.IntNumberTerm(42),
+			wantErr: true,
+		},
+		{
+			name:     "index error",
+			ref:      ast.StringTerm("registry.local/spam:latest@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			indexErr: errors.New("kaboom!"),
+			wantErr:  true,
+		},
+		{
+			name:        "index manifest error",
+			ref:         ast.StringTerm("registry.local/spam:latest@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			manifestErr: errors.New("kaboom!"),
+			wantErr:     true,
+		},
+		{
+			name:       "resolve error",
+			ref:        ast.StringTerm("registry.local/spam:latest"),
+			resolveErr: errors.New("kaboom!"),
+			wantErr:    true,
+		},
+		{
+			name:    "nil index manifest",
+			ref:     ast.StringTerm("registry.local/spam:latest@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			if c.indexErr != nil {
+				client.On("Index", mock.Anything).Return(nil, c.indexErr)
+			} else {
+				index := v1fake.FakeImageIndex{}
+				index.IndexManifestReturns(c.index, c.manifestErr)
+				client.On("Index", mock.Anything).Return(&index, nil)
+			}
+			if c.resolveErr != nil {
+				client.On("ResolveDigest", mock.Anything).Return("", c.resolveErr)
+			} else if c.resolvedDigest != "" {
+				client.On("ResolveDigest", mock.Anything).Return(c.resolvedDigest, nil)
+			}
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			got, err := ociImageIndex(bctx, c.ref)
+			require.NoError(t, err)
+			if c.wantErr {
+				require.Nil(t, got)
+			} else {
+				require.NotNil(t, got)
+				snaps.MatchJSON(t, got)
+			}
+		})
+	}
+}
+
+func TestOCIImageReferrers(t *testing.T) {
+	digest := "registry.local/spam@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"
+	referrers := &v1.IndexManifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIImageIndex,
+		Manifests: []v1.Descriptor{
+			{
+				MediaType:    types.OCIManifestSchema1,
+				Size:         123,
+				ArtifactType: "application/spdx+json",
+				Digest: v1.Hash{
+					Algorithm: "sha256",
+					Hex:       "4e388ab32b10dc8dbc7e28144f552830adc74787c1e2c0824032078a79f227fb",
+				},
+				Annotations: map[string]string{
+					"org.opencontainers.image.created": "2024-01-01T00:00:00Z",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		ref            *ast.Term
+		artifactType   *ast.Term
+		referrers      *v1.IndexManifest
+		resolvedDigest string
+		referrersErr   error
+		expected       string
+	}{
+		{
+			name:         "referrers",
+			ref:          ast.StringTerm(digest),
+			artifactType: ast.StringTerm("application/spdx+json"),
+			referrers:    referrers,
+			expected: `[{
+				"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"size": 123,
+				"digest": "sha256:4e388ab32b10dc8dbc7e28144f552830adc74787c1e2c0824032078a79f227fb",
+				"data": "",
+				"urls": [],
+				"annotations": {"org.opencontainers.image.created": "2024-01-01T00:00:00Z"},
+				"artifactType": "application/spdx+json"
+			}]`,
+		},
+		{
+			name:           "resolved digest",
+			ref:            ast.StringTerm("registry.local/spam:latest"),
+			artifactType:   ast.StringTerm(""),
+			resolvedDigest: "sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b",
+			referrers:      &v1.IndexManifest{SchemaVersion: 2, MediaType: types.OCIImageIndex},
+			expected:       `[]`,
+		},
+		{
+			name:         "invalid ref type",
+			ref:          ast.IntNumberTerm(42),
+			artifactType: ast.StringTerm(""),
+		},
+		{
+			name:         "invalid artifact type",
+			ref:          ast.StringTerm(digest),
+			artifactType: ast.IntNumberTerm(42),
+		},
+		{
+			name:         "referrers error",
+			ref:          ast.StringTerm(digest),
+			artifactType: ast.StringTerm(""),
+			referrersErr: errors.New("kaboom!"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			if c.referrersErr != nil {
+				client.On("Referrers", mock.Anything, mock.Anything).Return(nil, c.referrersErr)
+			} else {
+				index := v1fake.FakeImageIndex{}
+				index.IndexManifestReturns(c.referrers, nil)
+				client.On("Referrers", mock.Anything, mock.Anything).Return(&index, nil)
+			}
+			if c.resolvedDigest != "" {
+				client.On("ResolveDigest", mock.Anything).Return(c.resolvedDigest, nil)
+			}
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			got, err := ociImageReferrers(bctx, c.ref, c.artifactType)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			require.JSONEq(t, c.expected, got.String())
+			if artifactType, ok := c.artifactType.Value.(ast.String); ok {
+				client.AssertCalled(t, "Referrers", mock.Anything, string(artifactType))
+			}
+		})
+	}
+}
+
+func TestOCIImageTags(t *testing.T) {
+	cases := []struct {
+		name      string
+		ref       *ast.Term
+		tags      []string
+		truncated bool
+		listErr   error
+		expected  string
+	}{
+		{
+			name:     "repository",
+			ref:      ast.StringTerm("registry.local/spam"),
+			tags:     []string{"v1.0", "v1.1", "latest"},
+			expected: `["v1.0", "v1.1", "latest"]`,
+		},
+		{
+			name:     "image reference",
+			ref:      ast.StringTerm("registry.local/spam:v1.0@sha256:01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"),
+			tags:     []string{"v1.0"},
+			expected: `["v1.0"]`,
+		},
+		{
+			name:     "no tags",
+			ref:      ast.StringTerm("registry.local/spam"),
+			expected: `[]`,
+		},
+		{
+			name: "invalid ref type",
+			ref:  ast.IntNumberTerm(42),
+		},
+		{
+			name: "invalid repository",
+			ref:  ast.StringTerm("registry.local/SPAM"),
+		},
+		{
+			name:    "list error",
+			ref:     ast.StringTerm("registry.local/spam"),
+			listErr: errors.New("kaboom!"),
+		},
+		{
+			name:      "too many tags",
+			ref:       ast.StringTerm("registry.local/spam"),
+			tags:      []string{"v1.0"},
+			truncated: true,
+			expected:  `["v1.0"]`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			repository := mock.MatchedBy(func(r name.Repository) bool { return r.Name() == "registry.local/spam" })
+			client.On("ListTags", repository, defaultMaxTags).Return(c.tags, c.truncated, c.listErr)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			got, err := ociImageTags(bctx, c.ref)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			require.JSONEq(t, c.expected, got.String())
+		})
+	}
+}
+
 func TestOCIImageFiles(t *testing.T) {
 
 	image, err := crane.Image(map[string][]byte{
@@ -525,7 +882,7 @@ func TestOCIImageFiles(t *testing.T) {
 				client.On("Image", mock.Anything).Return(image, nil)
 			}
 
-			ctx := oci.WithClient(context.Background(), &client)
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			files, err := ociImageFiles(bctx, c.uri, c.paths)
@@ -540,12 +897,211 @@ func TestOCIImageFiles(t *testing.T) {
 	}
 }
 
+func TestOCIFetchBlob(t *testing.T) {
+	data := `{"spam": "maps"}`
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+
+	cases := []struct {
+		name     string
+		opts     *ast.Term
+		budget   *budget
+		expected string
+	}{
+		{
+			name:     "default limits",
+			opts:     ast.MustParseTerm(`{}`),
+			expected: `{"success": true, "errors": [], "blob": "{\"spam\": \"maps\"}"}`,
+		},
+		{
+			name:     "within raised limit",
+			opts:     ast.MustParseTerm(`{"max_size": 16}`),
+			expected: `{"success": true, "errors": [], "blob": "{\"spam\": \"maps\"}"}`,
+		},
+		{
+			name:     "exceeds limit",
+			opts:     ast.MustParseTerm(`{"max_size": 15}`),
+			expected: `{"success": false, "errors": ["read blob: limit exceeded: larger than 15 bytes"]}`,
+		},
+		{
+			name:     "exceeds run limit",
+			opts:     ast.MustParseTerm(`{}`),
+			budget:   &budget{maxSize: 10, maxFiles: 10},
+			expected: `{"success": false, "errors": ["read blob: limit exceeded: more than 10 bytes fetched in total"]}`,
+		},
+		{
+			name:     "invalid limit",
+			opts:     ast.MustParseTerm(`{"max_size": -1}`),
+			expected: `{"success": false, "errors": ["opts parameter: max_size: expected a positive integer, got -1"]}`,
+		},
+		{
+			name:     "invalid options",
+			opts:     ast.StringTerm("max_size"),
+			expected: `{"success": false, "errors": ["opts parameter: operand 2 must be object but got string"]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(data), types.OCIUncompressedLayer), nil)
+
+			b := c.budget
+			if b == nil {
+				b = &budget{maxSize: defaultMaxTotalSize, maxFiles: defaultMaxTotalFiles}
+			}
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
+			ctx = context.WithValue(ctx, budgetContextKey{}, b)
+
+			result, err := ociFetchBlob(rego.BuiltinContext{Context: ctx}, uri, c.opts)
+			require.NoError(t, err)
+			require.JSONEq(t, c.expected, result.String())
+		})
+	}
+}
+
+func TestOCIFetchBlobCachedLimits(t *testing.T) {
+	data := `{"spam": "maps"}`
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+
+	client := fake.FakeClient{}
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(data), types.OCIUncompressedLayer), nil).Once()
+
+	ctx := cache.WithCache(oci.WithClient(context.Background(), &client), cache.New(t.TempDir()))
+	ctx = context.WithValue(ctx, budgetContextKey{}, &budget{maxSize: 20, maxFiles: defaultMaxTotalFiles})
+	bctx := rego.BuiltinContext{Context: ctx}
+
+	result, err := ociFetchBlob(bctx, uri, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, ast.BooleanTerm(true), result.Get(ast.StringTerm("success")))
+
+	// the limits apply even though the blob has been cached
+	result, err = ociFetchBlob(bctx, uri, ast.MustParseTerm(`{"max_size": 15}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"success": false, "errors": ["limit exceeded: larger than 15 bytes"]}`, result.String())
+
+	// the same blob is charged to the budget once
+	result, err = ociFetchBlob(bctx, uri, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, ast.BooleanTerm(true), result.Get(ast.StringTerm("success")))
+
+	// the cached blob is charged to any other budget
+	bctx.Context = context.WithValue(ctx, budgetContextKey{}, &budget{maxSize: 10, maxFiles: defaultMaxTotalFiles})
+	result, err = ociFetchBlob(bctx, uri, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"success": false, "errors": ["read blob: limit exceeded: more than 10 bytes fetched in total"]}`, result.String())
+
+	client.AssertNumberOfCalls(t, "Layer", 1)
+}
+
+func TestOCIFetchImageFiles(t *testing.T) {
+	image, err := crane.Image(map[string][]byte{
+		"manifests/a.json": []byte(`{"a":1}`),
+		"manifests/b.yaml": []byte(`b: 2`),
+	})
+	require.NoError(t, err)
+
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+	paths := ast.ArrayTerm(ast.StringTerm("manifests"))
+
+	cases := []struct {
+		name     string
+		opts     *ast.Term
+		budget   *budget
+		expected string
+	}{
+		{
+			name:     "default limits",
+			opts:     ast.MustParseTerm(`{}`),
+			expected: `{"success": true, "errors": [], "files": {"manifests/a.json": {"a": 1}, "manifests/b.yaml": {"b": 2}}}`,
+		},
+		{
+			name:     "too many files",
+			opts:     ast.MustParseTerm(`{"max_files": 1}`),
+			expected: `{"success": false, "errors": ["extract files: limit exceeded: more than 1 files"]}`,
+		},
+		{
+			name:     "too large",
+			opts:     ast.MustParseTerm(`{"max_size": 10}`),
+			expected: `{"success": false, "errors": ["extract files: limit exceeded: files larger than 10 bytes"]}`,
+		},
+		{
+			name:     "exceeds run limit",
+			opts:     ast.MustParseTerm(`{}`),
+			budget:   &budget{maxSize: defaultMaxTotalSize, maxFiles: 1},
+			expected: `{"success": false, "errors": ["extract files: limit exceeded: more than 1 files fetched in total"]}`,
+		},
+		{
+			name:     "invalid limit",
+			opts:     ast.MustParseTerm(`{"max_files": "many"}`),
+			expected: `{"success": false, "errors": ["opts parameter: max_files: expected a number, got string"]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("Image", mock.Anything).Return(image, nil)
+
+			b := c.budget
+			if b == nil {
+				b = &budget{maxSize: defaultMaxTotalSize, maxFiles: defaultMaxTotalFiles}
+			}
+			ctx := cache.WithCache(oci.WithClient(context.Background(), &client), nil)
+			ctx = context.WithValue(ctx, budgetContextKey{}, b)
+
+			result, err := ociFetchImageFiles(rego.BuiltinContext{Context: ctx}, uri, paths, c.opts)
+			require.NoError(t, err)
+			require.JSONEq(t, c.expected, result.String())
+		})
+	}
+}
+
+func TestOCIFetchImageFilesCachedBudget(t *testing.T) {
+	image, err := crane.Image(map[string][]byte{
+		"manifests/a.json": []byte(`{"a":1}`),
+		"manifests/b.yaml": []byte(`b: 2`),
+	})
+	require.NoError(t, err)
+
+	uri := ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+	paths := ast.ArrayTerm(ast.StringTerm("manifests"))
+
+	client := fake.FakeClient{}
+	client.On("Image", mock.Anything).Return(image, nil).Once()
+
+	ctx := cache.WithCache(oci.WithClient(context.Background(), &client), cache.New(t.TempDir()))
+	ctx = context.WithValue(ctx, budgetContextKey{}, &budget{maxSize: defaultMaxTotalSize, maxFiles: 3})
+	bctx := rego.BuiltinContext{Context: ctx}
+
+	result, err := ociFetchImageFiles(bctx, uri, paths, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, ast.BooleanTerm(true), result.Get(ast.StringTerm("success")))
+
+	// the same files are charged to the budget once
+	result, err = ociFetchImageFiles(bctx, uri, paths, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, ast.BooleanTerm(true), result.Get(ast.StringTerm("success")))
+
+	// the cached files are charged to any other budget
+	bctx.Context = context.WithValue(ctx, budgetContextKey{}, &budget{maxSize: defaultMaxTotalSize, maxFiles: 1})
+	result, err = ociFetchImageFiles(bctx, uri, paths, ast.MustParseTerm(`{}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"success": false, "errors": ["extract files: limit exceeded: more than 1 files fetched in total"]}`, result.String())
+
+	client.AssertNumberOfCalls(t, "Image", 1)
+}
+
 func TestFunctionsRegistered(t *testing.T) {
 	names := []string{
 		ociBlobName,
 		ociDescriptorName,
+		ociFetchBlobName,
+		ociFetchImageFilesName,
 		ociImageFilesName,
+		ociImageIndexName,
 		ociImageManifestName,
+		ociImageReferrersName,
+		ociImageTagsName,
 	}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
@@ -0,0 +1,758 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rego
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// compareFunc returns -1, 0 or 1 when the version a is respectively lower than, equal to or
+// greater than the version b.
+type compareFunc func(a, b string) (int, error)
+
+// schemes maps the names of the versioning schemes, and the vers/purl types using them, to the
+// function implementing their ordering.
+var schemes = map[string]compareFunc{
+	"rpm":    compareRPM,
+	"deb":    compareDebian,
+	"debian": compareDebian,
+	"semver": compareSemver,
+	"npm":    compareSemver,
+	"cargo":  compareSemver,
+	"golang": compareSemver,
+	"pep440": comparePEP440,
+	"pypi":   comparePEP440,
+	"maven":  compareMaven,
+}
+
+func compareVersions(scheme, a, b string) (int, error) {
+	compare, ok := schemes[strings.ToLower(scheme)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported versioning scheme %q", scheme)
+	}
+
+	return compare(a, b)
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// compareNumeric compares two strings of digits of arbitrary length.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// splitEVR splits [epoch:]version[-release] as used by RPM and Debian. The version is split at the
+// last "-", as the release cannot contain one.
+func splitEVR(evr string) (epoch, version, release string) {
+	if i := strings.Index(evr, ":"); i >= 0 {
+		epoch, evr = evr[:i], evr[i+1:]
+	}
+	version = evr
+	if i := strings.LastIndex(evr, "-"); i >= 0 {
+		version, release = evr[:i], evr[i+1:]
+	}
+	return
+}
+
+func parseEpoch(epoch string) (int, error) {
+	if epoch == "" {
+		return 0, nil
+	}
+	e, err := strconv.Atoi(epoch)
+	if err != nil || e < 0 {
+		return 0, fmt.Errorf("invalid epoch %q", epoch)
+	}
+	return e, nil
+}
+
+// compareRPM compares [epoch:]version[-release] the way rpm does. The releases are only compared
+// when both are provided, so "3.0.7" matches any release of 3.0.7.
+func compareRPM(a, b string) (int, error) {
+	ae, av, ar := splitEVR(a)
+	be, bv, br := splitEVR(b)
+
+	aEpoch, err := parseEpoch(ae)
+	if err != nil {
+		return 0, err
+	}
+	bEpoch, err := parseEpoch(be)
+	if err != nil {
+		return 0, err
+	}
+
+	if c := sign(aEpoch - bEpoch); c != 0 {
+		return c, nil
+	}
+
+	if c := rpmvercmp(av, bv); c != 0 || ar == "" || br == "" {
+		return c, nil
+	}
+
+	return rpmvercmp(ar, br), nil
+}
+
+// rpmvercmp is a port of the function of the same name from rpm.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isSeparator := func(c byte) bool {
+		return !isDigit(c) && !isAlpha(c) && c != '~' && c != '^'
+	}
+
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && isSeparator(a[0]) {
+			a = a[1:]
+		}
+		for len(b) > 0 && isSeparator(b[0]) {
+			b = b[1:]
+		}
+
+		// A tilde sorts before anything, even the end of the version
+		if (len(a) > 0 && a[0] == '~') || (len(b) > 0 && b[0] == '~') {
+			if len(a) == 0 || a[0] != '~' {
+				return 1
+			}
+			if len(b) == 0 || b[0] != '~' {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// A caret sorts after the end of the version, but before anything else
+		if (len(a) > 0 && a[0] == '^') || (len(b) > 0 && b[0] == '^') {
+			if len(a) == 0 {
+				return -1
+			}
+			if len(b) == 0 {
+				return 1
+			}
+			if a[0] != '^' {
+				return 1
+			}
+			if b[0] != '^' {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if len(a) == 0 || len(b) == 0 {
+			break
+		}
+
+		class := isAlpha
+		numeric := isDigit(a[0])
+		if numeric {
+			class = isDigit
+		}
+
+		i := 0
+		for i < len(a) && class(a[i]) {
+			i++
+		}
+		j := 0
+		for j < len(b) && class(b[j]) {
+			j++
+		}
+
+		// Numeric segments are always newer than alpha segments
+		if j == 0 {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		if numeric {
+			c = compareNumeric(a[:i], b[:j])
+		} else {
+			c = strings.Compare(a[:i], b[:j])
+		}
+		if c != 0 {
+			return c
+		}
+
+		a, b = a[i:], b[j:]
+	}
+
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	}
+	return 1
+}
+
+// compareDebian compares [epoch:]upstream_version[-debian_revision] the way dpkg does.
+func compareDebian(a, b string) (int, error) {
+	ae, av, ar := splitEVR(a)
+	be, bv, br := splitEVR(b)
+
+	aEpoch, err := parseEpoch(ae)
+	if err != nil {
+		return 0, err
+	}
+	bEpoch, err := parseEpoch(be)
+	if err != nil {
+		return 0, err
+	}
+
+	if c := sign(aEpoch - bEpoch); c != 0 {
+		return c, nil
+	}
+
+	if c := verrevcmp(av, bv); c != 0 {
+		return c, nil
+	}
+
+	return verrevcmp(ar, br), nil
+}
+
+// verrevcmp is a port of the function of the same name from dpkg.
+func verrevcmp(a, b string) int {
+	order := func(s string, i int) int {
+		if i >= len(s) {
+			return 0
+		}
+		switch c := s[i]; {
+		case isDigit(c):
+			return 0
+		case isAlpha(c):
+			return int(c)
+		case c == '~':
+			return -1
+		default:
+			return int(c) + 256
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			if ac, bc := order(a, i), order(b, j); ac != bc {
+				return sign(ac - bc)
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+
+	return 0
+}
+
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// compareSemver compares versions following the precedence rules of Semantic Versioning 2.0.0.
+// Build metadata is ignored, and a leading "v" is accepted as used by Go modules.
+func compareSemver(a, b string) (int, error) {
+	am := semverRegexp.FindStringSubmatch(a)
+	if am == nil {
+		return 0, fmt.Errorf("invalid semantic version %q", a)
+	}
+	bm := semverRegexp.FindStringSubmatch(b)
+	if bm == nil {
+		return 0, fmt.Errorf("invalid semantic version %q", b)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if c := compareNumeric(am[i], bm[i]); c != 0 {
+			return c, nil
+		}
+	}
+
+	// A pre-release version has a lower precedence than the normal version
+	switch {
+	case am[4] == bm[4]:
+		return 0, nil
+	case am[4] == "":
+		return 1, nil
+	case bm[4] == "":
+		return -1, nil
+	}
+
+	ap := strings.Split(am[4], ".")
+	bp := strings.Split(bm[4], ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		aNumeric := strings.Trim(ap[i], "0123456789") == ""
+		bNumeric := strings.Trim(bp[i], "0123456789") == ""
+
+		var c int
+		switch {
+		case aNumeric && bNumeric:
+			c = compareNumeric(ap[i], bp[i])
+		case aNumeric:
+			// Numeric identifiers have a lower precedence than alphanumeric ones
+			c = -1
+		case bNumeric:
+			c = 1
+		default:
+			c = strings.Compare(ap[i], bp[i])
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	return sign(len(ap) - len(bp)), nil
+}
+
+// The regular expression from PEP 440, Appendix B.
+var pep440Regexp = regexp.MustCompile(`(?i)^v?(?:(?:([0-9]+)!)?([0-9]+(?:\.[0-9]+)*)` +
+	`([-_.]?(alpha|a|beta|b|preview|pre|c|rc)[-_.]?([0-9]+)?)?` +
+	`((?:-([0-9]+))|(?:[-_.]?(post|rev|r)[-_.]?([0-9]+)?))?` +
+	`([-_.]?(dev)[-_.]?([0-9]+)?)?)` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440Version holds the parts of a PEP 440 version used for ordering. The numbers are kept as
+// big.Int as PEP 440 does not limit their size, e.g. dates are commonly used.
+type pep440Version struct {
+	epoch   *big.Int
+	release []*big.Int
+	// pre is ordered by preRank first, so that dev releases without a pre-release sort before
+	// any pre-release, and final releases sort after.
+	preRank int
+	preNum  *big.Int
+	postNum *big.Int // nil when there is no post-release
+	devNum  *big.Int // nil when there is no dev release
+	local   []string
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	if i == nil {
+		i = new(big.Int)
+	}
+	return i
+}
+
+func parsePEP440(v string) (pep440Version, error) {
+	m := pep440Regexp.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return pep440Version{}, fmt.Errorf("invalid PEP 440 version %q", v)
+	}
+
+	p := pep440Version{epoch: bigInt(m[1]), preNum: new(big.Int)}
+
+	for _, r := range strings.Split(m[2], ".") {
+		p.release = append(p.release, bigInt(r))
+	}
+	// Trailing zeros are not significant, i.e. 1.0 == 1.0.0
+	for len(p.release) > 1 && p.release[len(p.release)-1].Sign() == 0 {
+		p.release = p.release[:len(p.release)-1]
+	}
+
+	switch strings.ToLower(m[4]) {
+	case "":
+		p.preRank = 4
+		if m[6] == "" && m[10] != "" {
+			// 1.0.dev0 sorts before 1.0a0
+			p.preRank = 0
+		}
+	case "a", "alpha":
+		p.preRank = 1
+	case "b", "beta":
+		p.preRank = 2
+	default: // c, rc, pre, preview
+		p.preRank = 3
+	}
+	p.preNum = bigInt(m[5])
+
+	switch {
+	case m[7] != "":
+		p.postNum = bigInt(m[7])
+	case m[8] != "":
+		p.postNum = bigInt(m[9])
+	}
+
+	if m[10] != "" {
+		p.devNum = bigInt(m[12])
+	}
+
+	if m[13] != "" {
+		p.local = strings.FieldsFunc(strings.ToLower(m[13]), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return p, nil
+}
+
+// compareOptional compares numbers which sort before any number when missing, if missingFirst is
+// true, and after any number otherwise.
+func compareOptional(a, b *big.Int, missingFirst bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		if missingFirst {
+			return -1
+		}
+		return 1
+	case b == nil:
+		if missingFirst {
+			return 1
+		}
+		return -1
+	}
+	return a.Cmp(b)
+}
+
+// comparePEP440 compares Python package versions as specified by PEP 440.
+func comparePEP440(a, b string) (int, error) {
+	av, err := parsePEP440(a)
+	if err != nil {
+		return 0, err
+	}
+	bv, err := parsePEP440(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if c := av.epoch.Cmp(bv.epoch); c != 0 {
+		return c, nil
+	}
+
+	for i := 0; i < len(av.release) || i < len(bv.release); i++ {
+		ar, br := new(big.Int), new(big.Int)
+		if i < len(av.release) {
+			ar = av.release[i]
+		}
+		if i < len(bv.release) {
+			br = bv.release[i]
+		}
+		if c := ar.Cmp(br); c != 0 {
+			return c, nil
+		}
+	}
+
+	if c := sign(av.preRank - bv.preRank); c != 0 {
+		return c, nil
+	}
+	if c := av.preNum.Cmp(bv.preNum); c != 0 {
+		return c, nil
+	}
+	if c := compareOptional(av.postNum, bv.postNum, true); c != 0 {
+		return c, nil
+	}
+	if c := compareOptional(av.devNum, bv.devNum, false); c != 0 {
+		return c, nil
+	}
+
+	// Numeric segments of local versions sort after alphanumeric ones
+	for i := 0; i < len(av.local) && i < len(bv.local); i++ {
+		al, bl := av.local[i], bv.local[i]
+		aNumeric := strings.Trim(al, "0123456789") == ""
+		bNumeric := strings.Trim(bl, "0123456789") == ""
+
+		var c int
+		switch {
+		case aNumeric && bNumeric:
+			c = compareNumeric(al, bl)
+		case aNumeric:
+			c = 1
+		case bNumeric:
+			c = -1
+		default:
+			c = strings.Compare(al, bl)
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	return sign(len(av.local) - len(bv.local)), nil
+}
+
+// mavenItem is one of the items of a version as parsed by Maven's ComparableVersion: either an
+// integer, a qualifier or a list of items.
+type mavenItem interface {
+	// compare compares the item to another, nil standing for a missing item.
+	compare(other mavenItem) int
+	isNull() bool
+}
+
+type mavenInt string
+
+type mavenString string
+
+type mavenList []mavenItem
+
+// mavenQualifiers are the well known qualifiers in their order, "" being the release.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenReleaseIndex = strconv.Itoa(indexOf(mavenQualifiers, ""))
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func newMavenString(s string, followedByDigit bool) mavenString {
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+
+	switch s {
+	case "ga", "final", "release":
+		s = ""
+	case "cr":
+		s = "rc"
+	}
+
+	return mavenString(s)
+}
+
+func (i mavenInt) isNull() bool {
+	return i == "0"
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		return compareNumeric(string(i), string(o))
+	}
+	// Integers are greater than qualifiers and lists
+	return 1
+}
+
+// comparable returns the qualifier in a form where the lexical order matches the order of the
+// qualifiers. Unknown qualifiers come after the known ones, in lexical order.
+func (s mavenString) comparable() string {
+	if i := indexOf(mavenQualifiers, string(s)); i >= 0 {
+		return strconv.Itoa(i)
+	}
+	return fmt.Sprintf("%d-%s", len(mavenQualifiers), s)
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), mavenReleaseIndex)
+	case mavenString:
+		return strings.Compare(s.comparable(), o.comparable())
+	case mavenInt:
+		return -1
+	}
+	// Qualifiers are lower than lists
+	return -1
+}
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+func (l mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case mavenList:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var c int
+			switch {
+			case i >= len(l):
+				c = -o[i].compare(nil)
+			case i >= len(o):
+				c = l[i].compare(nil)
+			default:
+				c = l[i].compare(o[i])
+			}
+			if c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// normalize removes the trailing null items, e.g. 1.0.0 is the same as 1.
+func (l mavenList) normalize() mavenList {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].isNull() {
+			l = append(l[:i], l[i+1:]...)
+		} else if _, ok := l[i].(mavenList); !ok {
+			break
+		}
+	}
+	return l
+}
+
+// parseMaven is a port of Maven's ComparableVersion.parseVersion. Each "-", and each transition
+// between digits and letters, starts a new sub-list.
+func parseMaven(version string) mavenItem {
+	version = strings.ToLower(version)
+
+	// The lists are built bottom up, the sub-lists being appended to their parent once complete
+	stack := []mavenList{{}}
+	push := func() {
+		stack = append(stack, mavenList{})
+	}
+	add := func(item mavenItem) {
+		stack[len(stack)-1] = append(stack[len(stack)-1], item)
+	}
+	parseItem := func(s string) mavenItem {
+		if isDigit(s[0]) {
+			s = strings.TrimLeft(s, "0")
+			if s == "" {
+				s = "0"
+			}
+			return mavenInt(s)
+		}
+		return newMavenString(s, false)
+	}
+
+	digit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				add(mavenInt("0"))
+			} else {
+				add(parseItem(version[start:i]))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				add(mavenInt("0"))
+			} else {
+				add(parseItem(version[start:i]))
+			}
+			start = i + 1
+			push()
+		case isDigit(c):
+			if !digit && i > start {
+				add(newMavenString(version[start:i], true))
+				start = i
+				push()
+			}
+			digit = true
+		default:
+			if digit && i > start {
+				add(parseItem(version[start:i]))
+				start = i
+				push()
+			}
+			digit = false
+		}
+	}
+	if len(version) > start {
+		// A trailing qualifier is treated as if it followed a "-", i.e. 1.0.0.X1 < 1.0.0-X2
+		if !digit && len(stack[len(stack)-1]) > 0 {
+			push()
+		}
+		add(parseItem(version[start:]))
+	}
+
+	for len(stack) > 1 {
+		list := stack[len(stack)-1].normalize()
+		stack = stack[:len(stack)-1]
+		add(list)
+	}
+
+	return stack[0].normalize()
+}
+
+// compareMaven compares versions the way Maven's ComparableVersion does.
+func compareMaven(a, b string) (int, error) {
+	return sign(parseMaven(a).compare(parseMaven(b))), nil
+}
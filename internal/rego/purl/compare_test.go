@@ -0,0 +1,171 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rego
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		scheme   string
+		a        string
+		b        string
+		expected int
+	}{
+		// Test cases from rpm's rpmvercmp.at
+		{"rpm", "1.0", "1.0", 0},
+		{"rpm", "1.0", "2.0", -1},
+		{"rpm", "2.0.1", "2.0", 1},
+		{"rpm", "2.0.1a", "2.0.1", 1},
+		{"rpm", "5.5p2", "5.5p1", 1},
+		{"rpm", "5.5p10", "5.5p2", 1},
+		{"rpm", "10xyz", "10.1xyz", -1},
+		{"rpm", "xyz10", "xyz10.1", -1},
+		{"rpm", "1.0aa", "1.0a", 1},
+		{"rpm", "10a2", "10b2", -1},
+		{"rpm", "1.0010", "1.9", 1},
+		{"rpm", "1.05", "1.5", 0},
+		{"rpm", "a+", "a_", 0},
+		{"rpm", "1.0~rc1", "1.0", -1},
+		{"rpm", "1.0~rc1", "1.0~rc2", -1},
+		{"rpm", "1.0~rc1~git123", "1.0~rc1", -1},
+		{"rpm", "1.0^", "1.0", 1},
+		{"rpm", "1.0^git1", "1.01", -1},
+		{"rpm", "1.0^20160101", "1.0.1", -1},
+		{"rpm", "1.0~rc1^git1", "1.0~rc1", 1},
+		{"rpm", "1.0^git1~pre", "1.0^git1", -1},
+		{"rpm", "3.0.7-18.el9", "3.0.7-2.el9", 1},
+		{"rpm", "3.0.7-18.el9", "3.0.7", 0},
+		{"rpm", "1:1.0-1", "2.0-1", 1},
+		{"rpm", "0:1.0-1", "1.0-1", 0},
+		// Test cases from dpkg's t-version.c
+		{"deb", "1.0", "1.0", 0},
+		{"deb", "1.0-0", "1.0", 0},
+		{"deb", "1.0", "1.0-1", -1},
+		{"deb", "1:0.1", "2.0", 1},
+		{"deb", "1.0~rc1", "1.0", -1},
+		{"deb", "1.0~~", "1.0~", -1},
+		{"deb", "1.0+b1", "1.0", 1},
+		{"deb", "1.0a", "1.0", 1},
+		{"deb", "1.0a", "1.0+", -1},
+		{"deb", "0002-0002", "2-2", 0},
+		{"debian", "2.30-1ubuntu1", "2.30-1", 1},
+		// Test cases from semver.org
+		{"semver", "1.0.0", "2.0.0", -1},
+		{"semver", "2.1.1", "2.1.0", 1},
+		{"semver", "1.0.0-alpha", "1.0.0", -1},
+		{"semver", "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"semver", "1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"semver", "1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"semver", "1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"semver", "1.0.0-rc.1", "1.0.0", -1},
+		{"semver", "1.2.3+build.1", "v1.2.3", 0},
+		{"golang", "v0.10.0", "v0.9.0", 1},
+		// Test cases from PEP 440
+		{"pep440", "1.0.dev456", "1.0a1", -1},
+		{"pep440", "1.0a2.dev456", "1.0a12.dev456", -1},
+		{"pep440", "1.0a12.dev456", "1.0a12", -1},
+		{"pep440", "1.0b2.post345.dev456", "1.0b2.post345", -1},
+		{"pep440", "1.0b2.post345", "1.0rc1.dev456", -1},
+		{"pep440", "1.0rc1", "1.0", -1},
+		{"pep440", "1.0", "1.0+abc.5", -1},
+		{"pep440", "1.0+abc.7", "1.0+5", -1},
+		{"pep440", "1.0+5", "1.0.post456.dev34", -1},
+		{"pep440", "1.0.post456.dev34", "1.0.post456", -1},
+		{"pep440", "1.0.post456", "1.1.dev1", -1},
+		{"pep440", "1.0", "1.0.0", 0},
+		{"pep440", "1.0-1", "1.0.post1", 0},
+		{"pep440", "1.0RC1", "1.0c1", 0},
+		{"pypi", "1!0.1", "2.0", 1},
+		// Test cases from Maven's ComparableVersionTest
+		{"maven", "1", "1.0.0", 0},
+		{"maven", "1ga", "1.final", 0},
+		{"maven", "1-release", "1", 0},
+		{"maven", "1a1", "1-alpha-1", 0},
+		{"maven", "1-cr1", "1-rc1", 0},
+		{"maven", "1-alpha2", "1-beta-2", -1},
+		{"maven", "1-m11", "1-rc", -1},
+		{"maven", "1-SNAPSHOT", "1", -1},
+		{"maven", "1", "1-sp", -1},
+		{"maven", "1-sp123", "1-abc", -1},
+		{"maven", "1-abc", "1-1", -1},
+		{"maven", "1-1", "1-123", -1},
+		{"maven", "2.0", "2.0.a", -1},
+		{"maven", "2.0.a", "2-1", -1},
+		{"maven", "2.1-1", "2.1.0.1", -1},
+		{"maven", "11.m11", "11", -1},
+		{"maven", "11", "11.a", -1},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %s %s", c.scheme, c.a, c.b), func(t *testing.T) {
+			result, err := compareVersions(c.scheme, c.a, c.b)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+
+			result, err = compareVersions(c.scheme, c.b, c.a)
+			require.NoError(t, err)
+			require.Equal(t, -c.expected, result)
+		})
+	}
+}
+
+func TestCompareVersionsErrors(t *testing.T) {
+	cases := []struct {
+		scheme string
+		a      string
+		b      string
+		err    string
+	}{
+		{"spam", "1", "2", `unsupported versioning scheme "spam"`},
+		{"rpm", "x:1", "2", `invalid epoch "x"`},
+		{"deb", "1", "-1:2", `invalid epoch "-1"`},
+		{"semver", "1.2", "1.2.0", `invalid semantic version "1.2"`},
+		{"semver", "1.2.0", "01.2.0", `invalid semantic version "01.2.0"`},
+		{"pep440", "1.0", "spam", `invalid PEP 440 version "spam"`},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %s %s", c.scheme, c.a, c.b), func(t *testing.T) {
+			_, err := compareVersions(c.scheme, c.a, c.b)
+			require.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestCompareMavenOrder(t *testing.T) {
+	// The ordered versions from Maven's ComparableVersionTest
+	versions := []string{
+		"1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc", "1-cr2",
+		"1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def", "1-pom-1",
+		"1-1-snapshot", "1-1", "1-2", "1-123",
+	}
+
+	for i := 0; i < len(versions)-1; i++ {
+		for j := i + 1; j < len(versions); j++ {
+			result, err := compareMaven(versions[i], versions[j])
+			require.NoError(t, err)
+			require.Equal(t, -1, result, "%s < %s", versions[i], versions[j])
+		}
+	}
+}
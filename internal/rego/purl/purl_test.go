@@ -107,6 +107,8 @@ func TestFunctionsRegistered(t *testing.T) {
 	names := []string{
 		purlIsValidName,
 		purlParseName,
+		versionCompareName,
+		versionInRangeName,
 	}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
@@ -0,0 +1,169 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rego
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// versConstraint is one of the constraints of a vers range, e.g. ">=3.0.7".
+type versConstraint struct {
+	comparator string
+	version    string
+}
+
+// versRange is a version range as specified by the vers specification of the purl project, e.g.
+// "vers:rpm/>=3.0.7-18.el9|<4".
+type versRange struct {
+	scheme      string
+	constraints []versConstraint
+	// wildcard is set for "vers:<scheme>/*", matching any version
+	wildcard bool
+}
+
+// versComparators are the comparators of vers, the longest first so that they can be matched as
+// prefixes.
+var versComparators = []string{"<=", ">=", "!=", "<", ">", "="}
+
+func parseVers(vers string) (versRange, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(vers), "vers:")
+	if !ok {
+		return versRange{}, fmt.Errorf("%q is not a vers range, it must start with \"vers:\"", vers)
+	}
+
+	scheme, constraints, ok := strings.Cut(spec, "/")
+	if !ok || scheme == "" {
+		return versRange{}, fmt.Errorf("%q has no versioning scheme", vers)
+	}
+
+	r := versRange{scheme: strings.ToLower(scheme)}
+	if _, ok := schemes[r.scheme]; !ok {
+		return versRange{}, fmt.Errorf("unsupported versioning scheme %q", scheme)
+	}
+
+	constraints = strings.Trim(strings.ReplaceAll(constraints, " ", ""), "|")
+	if constraints == "*" {
+		r.wildcard = true
+		return r, nil
+	}
+	if constraints == "" {
+		return versRange{}, fmt.Errorf("%q has no constraints", vers)
+	}
+
+	for _, c := range strings.Split(constraints, "|") {
+		constraint := versConstraint{comparator: "="}
+		for _, comparator := range versComparators {
+			if v, ok := strings.CutPrefix(c, comparator); ok {
+				constraint.comparator, c = comparator, v
+				break
+			}
+		}
+
+		version, err := url.PathUnescape(c)
+		if err != nil || version == "" {
+			return versRange{}, fmt.Errorf("invalid constraint %q in %q", c, vers)
+		}
+		constraint.version = version
+
+		r.constraints = append(r.constraints, constraint)
+	}
+
+	return r, nil
+}
+
+// contains evaluates whether the version is within the range following the algorithm of the vers
+// specification.
+func (r versRange) contains(version string) (bool, error) {
+	if r.wildcard {
+		return true, nil
+	}
+
+	compare := schemes[r.scheme]
+
+	var ranges []versConstraint
+	for _, c := range r.constraints {
+		cmp, err := compare(version, c.version)
+		if err != nil {
+			return false, err
+		}
+
+		switch c.comparator {
+		case "=":
+			if cmp == 0 {
+				return true, nil
+			}
+		case "!=":
+			if cmp == 0 {
+				return false, nil
+			}
+		default:
+			ranges = append(ranges, c)
+		}
+	}
+
+	if len(ranges) == 0 {
+		return false, nil
+	}
+
+	// The constraints are validated above, the versions can be compared without errors
+	sort.SliceStable(ranges, func(i, j int) bool {
+		c, _ := compare(ranges[i].version, ranges[j].version)
+		return c < 0
+	})
+
+	satisfies := func(c versConstraint) bool {
+		cmp, _ := compare(version, c.version)
+		switch c.comparator {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		}
+		return cmp >= 0
+	}
+
+	isLower := func(c versConstraint) bool {
+		return c.comparator == ">" || c.comparator == ">="
+	}
+
+	if len(ranges) == 1 {
+		return satisfies(ranges[0]), nil
+	}
+
+	for i := 0; i < len(ranges)-1; i++ {
+		current, next := ranges[i], ranges[i+1]
+
+		if i == 0 && !isLower(current) && satisfies(current) {
+			return true, nil
+		}
+
+		if i == len(ranges)-2 && isLower(next) && satisfies(next) {
+			return true, nil
+		}
+
+		if isLower(current) && !isLower(next) && satisfies(current) && satisfies(next) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
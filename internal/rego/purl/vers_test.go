@@ -0,0 +1,90 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rego
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersRangeContains(t *testing.T) {
+	cases := []struct {
+		vers     string
+		version  string
+		expected bool
+	}{
+		{"vers:rpm/>=3.0.7-18.el9", "3.0.7-18.el9", true},
+		{"vers:rpm/>=3.0.7-18.el9", "3.0.7-27.el9", true},
+		{"vers:rpm/>=3.0.7-18.el9", "3.0.7-2.el9", false},
+		{"vers:rpm/>=3.0.7-18.el9|<4", "3.5.0-1.el9", true},
+		{"vers:rpm/>=3.0.7-18.el9|<4", "4.0.0-1.el9", false},
+		{"vers:semver/*", "1.2.3", true},
+		{"vers:semver/1.2.3", "1.2.3", true},
+		{"vers:semver/1.2.3", "1.2.4", false},
+		{"vers:npm/1.2.3|>=2.0.0|<5.0.0", "1.2.3", true},
+		{"vers:npm/1.2.3|>=2.0.0|<5.0.0", "1.5.0", false},
+		{"vers:npm/1.2.3|>=2.0.0|<5.0.0", "3.0.0", true},
+		{"vers:npm/>=2.0.0|<5.0.0|!=3.0.0", "3.0.0", false},
+		// Unordered and disjoint ranges
+		{"vers:pypi/>=2.0|<1.0|>=3.0|<2.5", "0.5", true},
+		{"vers:pypi/>=2.0|<1.0|>=3.0|<2.5", "1.5", false},
+		{"vers:pypi/>=2.0|<1.0|>=3.0|<2.5", "2.2", true},
+		{"vers:pypi/>=2.0|<1.0|>=3.0|<2.5", "2.7", false},
+		{"vers:pypi/>=2.0|<1.0|>=3.0|<2.5", "4.0", true},
+		{"vers:deb/<=1:1.0", "1.0", true},
+		{"vers:deb/>1:1.0", "1.0", false},
+		{"vers:maven/>=1.0|<=2.0", "1.0-SNAPSHOT", false},
+		{"vers:maven/>=1.0|<=2.0", "2.0.final", true},
+		{"vers:golang/>v1.0.0", "v1.0.1", true},
+		{"vers:semver/>1.0.0%2Bbuild.1", "1.0.1", true},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %s", c.vers, c.version), func(t *testing.T) {
+			r, err := parseVers(c.vers)
+			require.NoError(t, err)
+
+			contains, err := r.contains(c.version)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, contains)
+		})
+	}
+}
+
+func TestParseVersErrors(t *testing.T) {
+	cases := []struct {
+		vers string
+		err  string
+	}{
+		{"rpm/>=1.0", `"rpm/>=1.0" is not a vers range, it must start with "vers:"`},
+		{"vers:>=1.0", `"vers:>=1.0" has no versioning scheme`},
+		{"vers:spam/>=1.0", `unsupported versioning scheme "spam"`},
+		{"vers:rpm/", `"vers:rpm/" has no constraints`},
+		{"vers:rpm/>=1.0|<", `invalid constraint "" in "vers:rpm/>=1.0|<"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.vers, func(t *testing.T) {
+			_, err := parseVers(c.vers)
+			require.EqualError(t, err, c.err)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package rego
+
+import (
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	"github.com/package-url/packageurl-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	versionCompareName = "ec.version.compare"
+	versionInRangeName = "ec.version.in_range"
+)
+
+func registerVersionCompare() {
+	decl := rego.Function{
+		Name: versionCompareName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("scheme", types.S).Description("the versioning scheme: rpm, deb, semver, pep440 or maven, or a purl type using one of them, e.g. pypi or npm"),
+				types.Named("a", types.S).Description("the first version"),
+				types.Named("b", types.S).Description("the second version"),
+			),
+			types.Named("result", types.N).Description("-1, 0 or 1 when a is respectively lower than, equal to or greater than b"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin3(&decl, versionCompare)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Compare two versions according to the ordering of a versioning scheme.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func registerVersionInRange() {
+	decl := rego.Function{
+		Name: versionInRangeName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("vers_range", types.S).Description(`the version range in the vers format, e.g. "vers:rpm/>=3.0.7-18.el9|<4"`),
+				types.Named("purl_or_version", types.S).Description("the version, or a PURL including the version and, for rpm and deb, the epoch qualifier"),
+			),
+			types.Named("result", types.B).Description("true when the version is within the range"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin2(&decl, versionInRange)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Determine whether or not a version is within a vers range.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func versionCompare(bctx rego.BuiltinContext, schemeTerm, aTerm, bTerm *ast.Term) (*ast.Term, error) {
+	scheme, ok := schemeTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+	a, ok := aTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+	b, ok := bTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+
+	result, err := compareVersions(string(scheme), string(a), string(b))
+	if err != nil {
+		log.Errorf("Comparing %s versions %s and %s failed: %s", scheme, a, b, err)
+		return nil, nil
+	}
+
+	return ast.IntNumberTerm(result), nil
+}
+
+func versionInRange(bctx rego.BuiltinContext, versTerm, versionTerm *ast.Term) (*ast.Term, error) {
+	vers, ok := versTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+	version, ok := versionTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+
+	r, err := parseVers(string(vers))
+	if err != nil {
+		log.Errorf("Parsing vers range %s failed: %s", vers, err)
+		return nil, nil
+	}
+
+	v := string(version)
+	if strings.HasPrefix(v, "pkg:") {
+		instance, err := packageurl.FromString(v)
+		if err != nil {
+			log.Errorf("Parsing PURL %s failed: %s", v, err)
+			return nil, nil
+		}
+		if instance.Version == "" {
+			log.Errorf("PURL %s has no version", v)
+			return nil, nil
+		}
+		v = instance.Version
+		// rpm and deb PURLs hold the epoch in a qualifier rather than in the version
+		if instance.Type == packageurl.TypeRPM || instance.Type == packageurl.TypeDebian {
+			if epoch := instance.Qualifiers.Map()["epoch"]; epoch != "" && !strings.Contains(v, ":") {
+				v = epoch + ":" + v
+			}
+		}
+	}
+
+	contains, err := r.contains(v)
+	if err != nil {
+		log.Errorf("Checking version %s is in range %s failed: %s", v, vers, err)
+		return nil, nil
+	}
+
+	return ast.BooleanTerm(contains), nil
+}
+
+func init() {
+	registerVersionCompare()
+	registerVersionInRange()
+}
@@ -0,0 +1,174 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rego
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCompare(t *testing.T) {
+	cases := []struct {
+		name     string
+		scheme   *ast.Term
+		a        *ast.Term
+		b        *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "lower",
+			scheme:   ast.StringTerm("rpm"),
+			a:        ast.StringTerm("3.0.7-2.el9"),
+			b:        ast.StringTerm("3.0.7-18.el9"),
+			expected: ast.IntNumberTerm(-1),
+		},
+		{
+			name:     "equal",
+			scheme:   ast.StringTerm("semver"),
+			a:        ast.StringTerm("1.2.3"),
+			b:        ast.StringTerm("v1.2.3"),
+			expected: ast.IntNumberTerm(0),
+		},
+		{
+			name:     "greater",
+			scheme:   ast.StringTerm("maven"),
+			a:        ast.StringTerm("1.0"),
+			b:        ast.StringTerm("1.0-SNAPSHOT"),
+			expected: ast.IntNumberTerm(1),
+		},
+		{
+			name:   "unsupported scheme",
+			scheme: ast.StringTerm("spam"),
+			a:      ast.StringTerm("1"),
+			b:      ast.StringTerm("2"),
+		},
+		{
+			name:   "invalid version",
+			scheme: ast.StringTerm("semver"),
+			a:      ast.StringTerm("1"),
+			b:      ast.StringTerm("2"),
+		},
+		{
+			name:   "unexpected scheme type",
+			scheme: ast.IntNumberTerm(42),
+			a:      ast.StringTerm("1"),
+			b:      ast.StringTerm("2"),
+		},
+		{
+			name:   "unexpected version type",
+			scheme: ast.StringTerm("rpm"),
+			a:      ast.StringTerm("1"),
+			b:      ast.IntNumberTerm(2),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := versionCompare(bctx, c.scheme, c.a, c.b)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
+func TestVersionInRange(t *testing.T) {
+	cases := []struct {
+		name     string
+		vers     *ast.Term
+		version  *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "version in range",
+			vers:     ast.StringTerm("vers:rpm/>=3.0.7-18.el9"),
+			version:  ast.StringTerm("3.0.7-27.el9"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "version not in range",
+			vers:     ast.StringTerm("vers:rpm/>=3.0.7-18.el9"),
+			version:  ast.StringTerm("3.0.7-2.el9"),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:     "purl in range",
+			vers:     ast.StringTerm("vers:rpm/>=3.0.7-18.el9"),
+			version:  ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-27.el9?arch=x86_64"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "purl with epoch in range",
+			vers:     ast.StringTerm("vers:rpm/>=1:3.0.7-18.el9"),
+			version:  ast.StringTerm("pkg:rpm/redhat/openssl@3.0.7-18.el9?epoch=1"),
+			expected: ast.BooleanTerm(true),
+		},
+		{
+			name:     "purl with epoch not in range",
+			vers:     ast.StringTerm("vers:deb/<1:1.0"),
+			version:  ast.StringTerm("pkg:deb/debian/curl@1.0?epoch=1"),
+			expected: ast.BooleanTerm(false),
+		},
+		{
+			name:    "purl without version",
+			vers:    ast.StringTerm("vers:rpm/>=3.0.7-18.el9"),
+			version: ast.StringTerm("pkg:rpm/redhat/openssl"),
+		},
+		{
+			name:    "malformed purl",
+			vers:    ast.StringTerm("vers:rpm/>=3.0.7-18.el9"),
+			version: ast.StringTerm("pkg::rpm//redhat/openssl"),
+		},
+		{
+			name:    "malformed range",
+			vers:    ast.StringTerm(">=3.0.7-18.el9"),
+			version: ast.StringTerm("3.0.7-27.el9"),
+		},
+		{
+			name:    "invalid version",
+			vers:    ast.StringTerm("vers:semver/>=1.0.0"),
+			version: ast.StringTerm("1"),
+		},
+		{
+			name:    "unexpected range type",
+			vers:    ast.IntNumberTerm(42),
+			version: ast.StringTerm("1"),
+		},
+		{
+			name:    "unexpected version type",
+			vers:    ast.StringTerm("vers:rpm/>=1"),
+			version: ast.IntNumberTerm(42),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			result, err := versionInRange(bctx, c.vers, c.version)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	log "github.com/sirupsen/logrus"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tagvalue"
+)
+
+const sbomParseName = "ec.sbom.parse"
+
+const (
+	formatCycloneDX = "cyclonedx"
+	formatSPDX      = "spdx"
+)
+
+// component is the format agnostic representation of a component, or package, listed in an SBOM.
+type component struct {
+	Ref          string            `json:"ref"`
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	PURL         string            `json:"purl"`
+	Licenses     []string          `json:"licenses"`
+	Hashes       map[string]string `json:"hashes"`
+	Dependencies []string          `json:"dependencies"`
+}
+
+type document struct {
+	Format      string      `json:"format"`
+	SpecVersion string      `json:"spec_version"`
+	Components  []component `json:"components"`
+}
+
+func registerSBOMParse() {
+	componentType := types.NewObject(
+		[]*types.StaticProperty{
+			// Specifying the properties like this ensure the compiler catches typos when
+			// evaluating rego functions.
+			{Key: "ref", Value: types.S},
+			{Key: "name", Value: types.S},
+			{Key: "version", Value: types.S},
+			{Key: "purl", Value: types.S},
+			{Key: "licenses", Value: types.NewArray(nil, types.S)},
+			{Key: "hashes", Value: types.NewObject(nil, types.NewDynamicProperty(types.S, types.S))},
+			{Key: "dependencies", Value: types.NewArray(nil, types.S)},
+		},
+		nil,
+	)
+
+	decl := rego.Function{
+		Name: sbomParseName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("doc", types.NewAny(types.S, types.NewObject(nil, types.NewDynamicProperty(types.S, types.A)))).
+					Description("the SBOM, either as the content of a CycloneDX (JSON or XML) or SPDX (JSON or tag-value) document, or as an object, e.g. an attestation predicate"),
+			),
+			types.Named("sbom", types.NewObject(
+				[]*types.StaticProperty{
+					{Key: "format", Value: types.Named("format", types.S).Description(`either "cyclonedx" or "spdx"`)},
+					{Key: "spec_version", Value: types.Named("spec_version", types.S).Description("version of the specification of the document")},
+					{Key: "components", Value: types.Named("components", types.NewArray(nil, componentType)).Description("components of the SBOM, dependencies are listed by ref")},
+				},
+				nil,
+			)).Description("the normalized SBOM"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	rego.RegisterBuiltin1(&decl, sbomParse)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Parse a CycloneDX or SPDX SBOM into a format agnostic list of components.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func sbomParse(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", sbomParseName)
+
+	var data []byte
+	switch v := a.Value.(type) {
+	case ast.String:
+		data = []byte(v)
+	case ast.Object:
+		obj, err := ast.JSON(v)
+		if err == nil {
+			data, err = json.Marshal(obj)
+		}
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"action": "marshal object",
+				"error":  err,
+			}).Error("failed to marshal SBOM object")
+			return nil, nil
+		}
+	default:
+		logger.Error("input is neither a string nor an object")
+		return nil, nil
+	}
+
+	doc, err := parse(data)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "parse",
+			"error":  err,
+		}).Error("failed to parse SBOM")
+		return nil, nil
+	}
+
+	value, err := ast.InterfaceToValue(doc)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "convert",
+			"error":  err,
+		}).Error("failed to convert SBOM to value")
+		return nil, nil
+	}
+
+	return ast.NewTerm(value), nil
+}
+
+// parse detects the format and encoding of the SBOM and normalizes it.
+func parse(data []byte) (*document, error) {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case len(data) == 0:
+		return nil, errors.New("empty document")
+	case data[0] == '{':
+		var probe struct {
+			BOMFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("unmarshalling JSON document: %w", err)
+		}
+
+		switch {
+		case probe.BOMFormat == "CycloneDX":
+			return parseCycloneDX(data, cyclonedx.BOMFileFormatJSON)
+		case probe.SPDXVersion != "":
+			doc, err := spdxjson.Read(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("reading SPDX JSON document: %w", err)
+			}
+			return fromSPDX(doc), nil
+		}
+	case data[0] == '<':
+		return parseCycloneDX(data, cyclonedx.BOMFileFormatXML)
+	case bytes.HasPrefix(data, []byte("SPDXVersion:")):
+		doc, err := tagvalue.Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("reading SPDX tag-value document: %w", err)
+		}
+		return fromSPDX(doc), nil
+	}
+
+	return nil, errors.New("unrecognized SBOM format")
+}
+
+func parseCycloneDX(data []byte, format cyclonedx.BOMFileFormat) (*document, error) {
+	var bom cyclonedx.BOM
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), format).Decode(&bom); err != nil {
+		return nil, fmt.Errorf("decoding CycloneDX document: %w", err)
+	}
+
+	dependencies := map[string][]string{}
+	if bom.Dependencies != nil {
+		for _, d := range *bom.Dependencies {
+			if d.Dependencies != nil {
+				dependencies[d.Ref] = append(dependencies[d.Ref], *d.Dependencies...)
+			}
+		}
+	}
+
+	doc := document{
+		Format:      formatCycloneDX,
+		SpecVersion: bom.SpecVersion.String(),
+		Components:  []component{},
+	}
+
+	// Components may be nested, flatten them in document order
+	var collect func(components *[]cyclonedx.Component)
+	collect = func(components *[]cyclonedx.Component) {
+		if components == nil {
+			return
+		}
+		for _, c := range *components {
+			comp := component{
+				Ref:          c.BOMRef,
+				Name:         c.Name,
+				Version:      c.Version,
+				PURL:         c.PackageURL,
+				Licenses:     []string{},
+				Hashes:       map[string]string{},
+				Dependencies: []string{},
+			}
+
+			if c.Licenses != nil {
+				for _, l := range *c.Licenses {
+					switch {
+					case l.Expression != "":
+						comp.Licenses = append(comp.Licenses, l.Expression)
+					case l.License != nil && l.License.ID != "":
+						comp.Licenses = append(comp.Licenses, l.License.ID)
+					case l.License != nil && l.License.Name != "":
+						comp.Licenses = append(comp.Licenses, l.License.Name)
+					}
+				}
+			}
+
+			if c.Hashes != nil {
+				for _, h := range *c.Hashes {
+					comp.Hashes[hashAlgorithm(string(h.Algorithm))] = h.Value
+				}
+			}
+
+			if d, ok := dependencies[c.BOMRef]; ok && c.BOMRef != "" {
+				comp.Dependencies = append(comp.Dependencies, d...)
+			}
+
+			doc.Components = append(doc.Components, comp)
+			collect(c.Components)
+		}
+	}
+	collect(bom.Components)
+
+	return &doc, nil
+}
+
+func fromSPDX(sbom *spdx.Document) *document {
+	dependencies := map[string][]string{}
+	for _, r := range sbom.Relationships {
+		if r == nil {
+			continue
+		}
+		a, b := string(r.RefA.ElementRefID), string(r.RefB.ElementRefID)
+		if a == "" || b == "" {
+			// NONE or NOASSERTION, or a reference to another document
+			continue
+		}
+		switch r.Relationship {
+		case spdx.RelationshipDependsOn:
+			dependencies[a] = append(dependencies[a], b)
+		case spdx.RelationshipDependencyOf:
+			dependencies[b] = append(dependencies[b], a)
+		}
+	}
+
+	doc := document{
+		Format:      formatSPDX,
+		SpecVersion: sbom.SPDXVersion,
+		Components:  []component{},
+	}
+
+	for _, p := range sbom.Packages {
+		if p == nil {
+			continue
+		}
+
+		ref := string(p.PackageSPDXIdentifier)
+		comp := component{
+			Ref:          ref,
+			Name:         p.PackageName,
+			Version:      p.PackageVersion,
+			Licenses:     []string{},
+			Hashes:       map[string]string{},
+			Dependencies: []string{},
+		}
+
+		// The concluded license takes precedence as it is the result of the analysis of the
+		// package, the declared license is what the package author claims.
+		for _, l := range []string{p.PackageLicenseConcluded, p.PackageLicenseDeclared} {
+			if l != "" && l != "NOASSERTION" && l != "NONE" {
+				comp.Licenses = append(comp.Licenses, l)
+				break
+			}
+		}
+
+		for _, c := range p.PackageChecksums {
+			comp.Hashes[hashAlgorithm(string(c.Algorithm))] = c.Value
+		}
+
+		for _, e := range p.PackageExternalReferences {
+			if e != nil && e.RefType == spdx.PackageManagerPURL {
+				comp.PURL = e.Locator
+				break
+			}
+		}
+
+		if d, ok := dependencies[ref]; ok {
+			comp.Dependencies = append(comp.Dependencies, d...)
+		}
+
+		doc.Components = append(doc.Components, comp)
+	}
+
+	return &doc
+}
+
+// hashAlgorithm normalizes the names of the hash algorithms used by CycloneDX, e.g. SHA-256 and
+// SHA3-256, and by SPDX, e.g. SHA256 and SHA3_256, into sha256 and sha3-256.
+func hashAlgorithm(algorithm string) string {
+	algorithm = strings.ReplaceAll(strings.ToLower(algorithm), "_", "-")
+	if strings.HasPrefix(algorithm, "sha-") {
+		algorithm = "sha" + strings.TrimPrefix(algorithm, "sha-")
+	}
+	return algorithm
+}
+
+func init() {
+	registerSBOMParse()
+}
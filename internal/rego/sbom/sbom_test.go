@@ -0,0 +1,266 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package sbom
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/require"
+)
+
+const cycloneDXJSON = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {
+      "bom-ref": "app",
+      "type": "application",
+      "name": "app",
+      "version": "1.0",
+      "purl": "pkg:golang/example.com/app@1.0",
+      "licenses": [{"expression": "Apache-2.0 OR MIT"}],
+      "components": [
+        {
+          "bom-ref": "lib",
+          "type": "library",
+          "name": "lib",
+          "version": "2.0",
+          "purl": "pkg:golang/example.com/lib@2.0",
+          "licenses": [{"license": {"id": "MIT"}}],
+          "hashes": [{"alg": "SHA-256", "content": "abc"}, {"alg": "SHA-1", "content": "def"}]
+        }
+      ]
+    }
+  ],
+  "dependencies": [{"ref": "app", "dependsOn": ["lib"]}, {"ref": "lib"}]
+}`
+
+const cycloneDXXML = `<?xml version="1.0" encoding="UTF-8"?>
+<bom xmlns="http://cyclonedx.org/schema/bom/1.5" version="1">
+  <components>
+    <component type="application" bom-ref="app">
+      <name>app</name>
+      <version>1.0</version>
+      <licenses><expression>Apache-2.0 OR MIT</expression></licenses>
+      <purl>pkg:golang/example.com/app@1.0</purl>
+    </component>
+    <component type="library" bom-ref="lib">
+      <name>lib</name>
+      <version>2.0</version>
+      <hashes>
+        <hash alg="SHA-256">abc</hash>
+        <hash alg="SHA-1">def</hash>
+      </hashes>
+      <licenses><license><id>MIT</id></license></licenses>
+      <purl>pkg:golang/example.com/lib@2.0</purl>
+    </component>
+  </components>
+  <dependencies>
+    <dependency ref="app"><dependency ref="lib"/></dependency>
+  </dependencies>
+</bom>`
+
+const spdxJSON = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "app",
+  "documentNamespace": "https://example.com/app",
+  "creationInfo": {"created": "2024-01-01T00:00:00Z", "creators": ["Tool: test"]},
+  "packages": [
+    {
+      "SPDXID": "SPDXRef-app",
+      "name": "app",
+      "versionInfo": "1.0",
+      "downloadLocation": "NOASSERTION",
+      "licenseConcluded": "NOASSERTION",
+      "licenseDeclared": "Apache-2.0 OR MIT",
+      "externalRefs": [
+        {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:golang/example.com/app@1.0"}
+      ]
+    },
+    {
+      "SPDXID": "SPDXRef-lib",
+      "name": "lib",
+      "versionInfo": "2.0",
+      "downloadLocation": "NOASSERTION",
+      "licenseConcluded": "MIT",
+      "checksums": [{"algorithm": "SHA256", "checksumValue": "abc"}, {"algorithm": "SHA1", "checksumValue": "def"}],
+      "externalRefs": [
+        {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:golang/example.com/lib@2.0"}
+      ]
+    }
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-lib", "relationshipType": "DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-app"}
+  ]
+}`
+
+const spdxTagValue = `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: app
+DocumentNamespace: https://example.com/app
+Creator: Tool: test
+Created: 2024-01-01T00:00:00Z
+
+PackageName: app
+SPDXID: SPDXRef-app
+PackageVersion: 1.0
+PackageDownloadLocation: NOASSERTION
+PackageLicenseConcluded: NOASSERTION
+PackageLicenseDeclared: Apache-2.0 OR MIT
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/example.com/app@1.0
+
+PackageName: lib
+SPDXID: SPDXRef-lib
+PackageVersion: 2.0
+PackageDownloadLocation: NOASSERTION
+PackageChecksum: SHA256: abc
+PackageChecksum: SHA1: def
+PackageLicenseConcluded: MIT
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/example.com/lib@2.0
+
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-app
+Relationship: SPDXRef-app DEPENDS_ON SPDXRef-lib
+`
+
+const cycloneDXExpected = `{
+  "format": "cyclonedx",
+  "spec_version": "1.5",
+  "components": [
+    {
+      "ref": "app",
+      "name": "app",
+      "version": "1.0",
+      "purl": "pkg:golang/example.com/app@1.0",
+      "licenses": ["Apache-2.0 OR MIT"],
+      "hashes": {},
+      "dependencies": ["lib"]
+    },
+    {
+      "ref": "lib",
+      "name": "lib",
+      "version": "2.0",
+      "purl": "pkg:golang/example.com/lib@2.0",
+      "licenses": ["MIT"],
+      "hashes": {"sha256": "abc", "sha1": "def"},
+      "dependencies": []
+    }
+  ]
+}`
+
+const spdxExpected = `{
+  "format": "spdx",
+  "spec_version": "SPDX-2.3",
+  "components": [
+    {
+      "ref": "app",
+      "name": "app",
+      "version": "1.0",
+      "purl": "pkg:golang/example.com/app@1.0",
+      "licenses": ["Apache-2.0 OR MIT"],
+      "hashes": {},
+      "dependencies": ["lib"]
+    },
+    {
+      "ref": "lib",
+      "name": "lib",
+      "version": "2.0",
+      "purl": "pkg:golang/example.com/lib@2.0",
+      "licenses": ["MIT"],
+      "hashes": {"sha256": "abc", "sha1": "def"},
+      "dependencies": []
+    }
+  ]
+}`
+
+func TestSBOMParse(t *testing.T) {
+	predicate, err := ast.ParseTerm(spdxJSON)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		doc      *ast.Term
+		expected string
+	}{
+		{name: "CycloneDX JSON", doc: ast.StringTerm(cycloneDXJSON), expected: cycloneDXExpected},
+		{name: "CycloneDX XML", doc: ast.StringTerm(cycloneDXXML), expected: cycloneDXExpected},
+		{name: "SPDX JSON", doc: ast.StringTerm(spdxJSON), expected: spdxExpected},
+		{name: "SPDX tag-value", doc: ast.StringTerm(spdxTagValue), expected: spdxExpected},
+		{name: "SPDX predicate", doc: predicate, expected: spdxExpected},
+		{name: "unexpected type", doc: ast.IntNumberTerm(42)},
+		{name: "empty", doc: ast.StringTerm("  ")},
+		{name: "unknown JSON document", doc: ast.StringTerm(`{"spam": "eggs"}`)},
+		{name: "unknown format", doc: ast.StringTerm("spam")},
+		{name: "malformed JSON", doc: ast.StringTerm(`{"bomFormat": `)},
+		{name: "malformed XML", doc: ast.StringTerm(`<bom`)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bctx := rego.BuiltinContext{Context: context.Background()}
+
+			sbom, err := sbomParse(bctx, c.doc)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, sbom)
+				return
+			}
+
+			require.NotNil(t, sbom)
+			require.JSONEq(t, c.expected, sbom.String())
+		})
+	}
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	for algorithm, expected := range map[string]string{
+		"SHA-1":       "sha1",
+		"SHA-256":     "sha256",
+		"SHA256":      "sha256",
+		"SHA3-512":    "sha3-512",
+		"SHA3_512":    "sha3-512",
+		"BLAKE2b-256": "blake2b-256",
+		"BLAKE2b_256": "blake2b-256",
+		"MD5":         "md5",
+	} {
+		require.Equal(t, expected, hashAlgorithm(algorithm), algorithm)
+	}
+}
+
+func TestFunctionsRegistered(t *testing.T) {
+	names := []string{
+		sbomParseName,
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, builtin := range ast.Builtins {
+				if builtin.Name == name {
+					return
+				}
+			}
+			t.Fatalf("%s builtin not registered", name)
+		})
+	}
+}
@@ -22,6 +22,9 @@ package sigstore
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
@@ -31,10 +34,13 @@ import (
 	"github.com/open-policy-agent/opa/topdown/builtins"
 	"github.com/open-policy-agent/opa/types"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/v2/pkg/oci"
+	"github.com/sigstore/cosign/v2/pkg/oci/static"
 
 	"github.com/enterprise-contract/ec-cli/internal/attestation"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/rego/cache"
 	"github.com/enterprise-contract/ec-cli/internal/signature"
 	ecoci "github.com/enterprise-contract/ec-cli/internal/utils/oci"
 )
@@ -42,9 +48,13 @@ import (
 const (
 	sigstoreVerifyImageName       = "ec.sigstore.verify_image"
 	sigstoreVerifyAttestationName = "ec.sigstore.verify_attestation"
+	sigstoreVerifyBlobName        = "ec.sigstore.verify_blob"
 )
 
 const (
+	bundleAttribute                      = "bundle"
+	certificateAttribute                 = "certificate"
+	certificateChainAttribute            = "certificate_chain"
 	certificateIdentityAttribute         = "certificate_identity"
 	certificateIdentityRegExpAttribute   = "certificate_identity_regexp"
 	certificateOIDCIssuerAttribute       = "certificate_oidc_issuer"
@@ -56,19 +66,29 @@ const (
 
 var ociImageReferenceParameter = types.Named("ref", types.S).Description("OCI image reference")
 
+var sigstoreOptsProperties = []*types.StaticProperty{
+	{Key: certificateIdentityAttribute, Value: types.S},
+	{Key: certificateIdentityRegExpAttribute, Value: types.S},
+	{Key: certificateOIDCIssuerAttribute, Value: types.S},
+	{Key: certificateOIDCIssuerRegExpAttribute, Value: types.S},
+	{Key: ignoreRekorAttribute, Value: types.B},
+	{Key: publicKeyAttribute, Value: types.S},
+	{Key: rekorURLAttribute, Value: types.S},
+}
+
 var sigstoreOptsParameter = types.Named("opts",
-	types.NewObject(
-		[]*types.StaticProperty{
-			{Key: certificateIdentityAttribute, Value: types.S},
-			{Key: certificateIdentityRegExpAttribute, Value: types.S},
-			{Key: certificateOIDCIssuerAttribute, Value: types.S},
-			{Key: certificateOIDCIssuerRegExpAttribute, Value: types.S},
-			{Key: ignoreRekorAttribute, Value: types.B},
-			{Key: publicKeyAttribute, Value: types.S},
-			{Key: rekorURLAttribute, Value: types.S},
-		},
-		nil,
-	)).Description("Sigstore verification options")
+	types.NewObject(sigstoreOptsProperties, nil),
+).Description("Sigstore verification options")
+
+// Blobs are not stored in an OCI registry alongside their signatures, so the certificate, its
+// chain and the Rekor bundle, as produced by `cosign sign-blob`, are provided as options instead.
+var sigstoreBlobOptsParameter = types.Named("opts",
+	types.NewObject(append([]*types.StaticProperty{
+		{Key: bundleAttribute, Value: types.S},
+		{Key: certificateAttribute, Value: types.S},
+		{Key: certificateChainAttribute, Value: types.S},
+	}, sigstoreOptsProperties...), nil),
+).Description("Sigstore verification options, including the PEM encoded certificate and certificate chain, and the JSON encoded bundle of the signature")
 
 func registerSigstoreVerifyImage() {
 	result := types.Named(
@@ -106,18 +126,21 @@ func sigstoreVerifyImage(bctx rego.BuiltinContext, refTerm *ast.Term, optsTerm *
 		return signatureFailedResult(fmt.Errorf("new digest: %w", err))
 	}
 
-	checkOpts, err := parseCheckOpts(ctx, optsTerm)
-	if err != nil {
-		return signatureFailedResult(fmt.Errorf("opts parameter: %w", err))
-	}
-	checkOpts.ClaimVerifier = cosign.SimpleClaimVerifier
+	key := cache.Key(ref.String(), optionsFromTerm(optsTerm).toTerm().String())
+	return verifyCached(ctx, sigstoreVerifyImageName, key, func() (*ast.Term, error) {
+		checkOpts, err := parseCheckOpts(ctx, optsTerm)
+		if err != nil {
+			return signatureFailedResult(fmt.Errorf("opts parameter: %w", err))
+		}
+		checkOpts.ClaimVerifier = cosign.SimpleClaimVerifier
 
-	signatures, _, err := ecoci.NewClient(ctx).VerifyImageSignatures(ref, checkOpts)
-	if err != nil {
-		return signatureFailedResult(fmt.Errorf("verify image signature: %w", err))
-	}
+		signatures, _, err := ecoci.NewClient(ctx).VerifyImageSignatures(ref, checkOpts)
+		if err != nil {
+			return signatureFailedResult(fmt.Errorf("verify image signature: %w", err))
+		}
 
-	return signatureResult(signatures, nil)
+		return signatureResult(signatures, nil)
+	})
 }
 
 func registerSigstoreVerifyAttestation() {
@@ -167,18 +190,197 @@ func sigstoreVerifyAttestation(bctx rego.BuiltinContext, refTerm *ast.Term, opts
 		return attestationFailedResult(fmt.Errorf("new digest: %w", err))
 	}
 
-	checkOpts, err := parseCheckOpts(ctx, optsTerm)
+	key := cache.Key(ref.String(), optionsFromTerm(optsTerm).toTerm().String())
+	return verifyCached(ctx, sigstoreVerifyAttestationName, key, func() (*ast.Term, error) {
+		checkOpts, err := parseCheckOpts(ctx, optsTerm)
+		if err != nil {
+			return attestationFailedResult(fmt.Errorf("opts parameter: %w", err))
+		}
+		checkOpts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+
+		attestations, _, err := ecoci.NewClient(ctx).VerifyImageAttestations(ref, checkOpts)
+		if err != nil {
+			return attestationFailedResult(fmt.Errorf("verify image attestation signature: %w", err))
+		}
+
+		return attestationResult(attestations, nil)
+	})
+}
+
+func registerSigstoreVerifyBlob() {
+	result := types.Named(
+		"result",
+		types.NewObject([]*types.StaticProperty{
+			{Key: "success", Value: types.Named("success", types.B).Description("true when verification is successful")},
+			{Key: "errors", Value: types.Named("errors", types.NewArray(nil, types.S)).Description("verification errors")},
+			{Key: "signatures", Value: types.Named("signatures", types.NewArray(nil, signatureType)).Description("matching signatures")},
+		}, nil),
+	).Description("the result of the verification request")
+
+	decl := rego.Function{
+		Name:        sigstoreVerifyBlobName,
+		Description: "Use sigstore to verify the signature of a blob, e.g. a file retrieved with ec.oci.blob.",
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("content", types.S).Description("the signed content"),
+				types.Named("signature", types.S).Description("the base64 encoded signature, may be empty when provided by the bundle"),
+				sigstoreBlobOptsParameter,
+			),
+			result,
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. Rekor. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+	rego.RegisterBuiltin3(&decl, sigstoreVerifyBlob)
+}
+
+func sigstoreVerifyBlob(bctx rego.BuiltinContext, contentTerm *ast.Term, signatureTerm *ast.Term, optsTerm *ast.Term) (*ast.Term, error) {
+	ctx := bctx.Context
+
+	content, err := builtins.StringOperand(contentTerm.Value, 0)
 	if err != nil {
-		return attestationFailedResult(fmt.Errorf("opts parameter: %w", err))
+		return signatureFailedResult(fmt.Errorf("content parameter: %w", err))
 	}
-	checkOpts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
 
-	attestations, _, err := ecoci.NewClient(ctx).VerifyImageAttestations(ref, checkOpts)
+	b64sig, err := builtins.StringOperand(signatureTerm.Value, 1)
 	if err != nil {
-		return attestationFailedResult(fmt.Errorf("verify image attestation signature: %w", err))
+		return signatureFailedResult(fmt.Errorf("signature parameter: %w", err))
 	}
 
-	return attestationResult(attestations, nil)
+	opts := optionsFromTerm(optsTerm)
+
+	key := cache.Key(string(content), string(b64sig), opts.toTerm().String())
+	return verifyCached(ctx, sigstoreVerifyBlobName, key, func() (*ast.Term, error) {
+		checkOpts, err := parseCheckOpts(ctx, optsTerm)
+		if err != nil {
+			return signatureFailedResult(fmt.Errorf("opts parameter: %w", err))
+		}
+
+		sig, err := blobSignature([]byte(content), string(b64sig), opts)
+		if err != nil {
+			return signatureFailedResult(fmt.Errorf("signature: %w", err))
+		}
+
+		if _, err := cosign.VerifyBlobSignature(ctx, sig, checkOpts); err != nil {
+			return signatureFailedResult(fmt.Errorf("verify blob signature: %w", err))
+		}
+
+		return signatureResult([]oci.Signature{sig}, nil)
+	})
+}
+
+// verifyCached returns the result of a previous successful verification with the same key within
+// this run, otherwise it performs the verification. Only successful results are remembered,
+// failures may be caused by transient errors, e.g. an unavailable registry. The results are not
+// persisted across runs: the options may refer to keys that change, e.g. in a Kubernetes secret,
+// and the outcome depends on the trust root, the transparency log and the signatures found via
+// mutable tags.
+func verifyCached(ctx context.Context, function, key string, verify func() (*ast.Term, error)) (*ast.Term, error) {
+	c := cache.FromContext(ctx)
+	if term, ok := c.Recall(ctx, function, key); ok {
+		return term, nil
+	}
+
+	term, err := verify()
+	if err == nil && term != nil && ast.BooleanTerm(true).Equal(term.Get(ast.StringTerm("success"))) {
+		c.Remember(ctx, function, key, term)
+	}
+
+	return term, err
+}
+
+// blobSignature assembles the signature of the blob from the given signature, certificate and
+// certificate chain. Any of the signature, certificate and Rekor bundle missing from the options
+// is taken from the bundle when one is provided. The Rekor bundle must record the digest of the
+// content.
+func blobSignature(content []byte, b64sig string, opts options) (oci.Signature, error) {
+	cert := []byte(opts.certificate)
+	var staticOpts []static.Option
+
+	if opts.bundle != "" {
+		var payload cosign.LocalSignedPayload
+		if err := json.Unmarshal([]byte(opts.bundle), &payload); err != nil {
+			return nil, fmt.Errorf("parsing bundle: %w", err)
+		}
+
+		if b64sig == "" {
+			b64sig = payload.Base64Signature
+		}
+
+		if len(cert) == 0 && payload.Cert != "" {
+			// cosign stores the PEM encoded certificate base64 encoded in the bundle
+			decoded, err := base64.StdEncoding.DecodeString(payload.Cert)
+			if err != nil {
+				return nil, fmt.Errorf("decoding bundle certificate: %w", err)
+			}
+			cert = decoded
+		}
+
+		if payload.Bundle != nil {
+			if err := checkBundleDigest(payload.Bundle, content); err != nil {
+				return nil, err
+			}
+			staticOpts = append(staticOpts, static.WithBundle(payload.Bundle))
+		}
+	}
+
+	if b64sig == "" {
+		return nil, fmt.Errorf("no signature provided")
+	}
+
+	if len(cert) > 0 {
+		staticOpts = append(staticOpts, static.WithCertChain(cert, []byte(opts.certificateChain)))
+	}
+
+	return static.NewSignature(content, b64sig, staticOpts...)
+}
+
+// checkBundleDigest checks that the digest recorded in the Rekor entry of the bundle is the digest
+// of the content. The signature verification only checks it when the transparency log is used.
+func checkBundleDigest(b *bundle.RekorBundle, content []byte) error {
+	body, ok := b.Payload.Body.(string)
+	if !ok {
+		return fmt.Errorf("unexpected bundle body of type %T", b.Payload.Body)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return fmt.Errorf("decoding bundle body: %w", err)
+	}
+
+	// Both the hashedrekord and the rekord entries record the digest of the signed content
+	var entry struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Data struct {
+				Hash struct {
+					Algorithm string `json:"algorithm"`
+					Value     string `json:"value"`
+				} `json:"hash"`
+			} `json:"data"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(decoded, &entry); err != nil {
+		return fmt.Errorf("parsing bundle body: %w", err)
+	}
+
+	if entry.Kind != "hashedrekord" && entry.Kind != "rekord" {
+		return fmt.Errorf("unsupported bundle entry kind %q", entry.Kind)
+	}
+
+	if entry.Spec.Data.Hash.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported bundle digest algorithm %q", entry.Spec.Data.Hash.Algorithm)
+	}
+
+	digest := sha256.Sum256(content)
+	if expected := hex.EncodeToString(digest[:]); entry.Spec.Data.Hash.Value != expected {
+		return fmt.Errorf("the bundle digest %s does not match the content digest %s", entry.Spec.Data.Hash.Value, expected)
+	}
+
+	return nil
 }
 
 func parseCheckOpts(ctx context.Context, optsTerm *ast.Term) (*cosign.CheckOpts, error) {
@@ -211,6 +413,9 @@ func parseCheckOpts(ctx context.Context, optsTerm *ast.Term) (*cosign.CheckOpts,
 }
 
 type options struct {
+	bundle                      string
+	certificate                 string
+	certificateChain            string
 	certificateIdentity         string
 	certificateIdentityRegExp   string
 	certificateOIDCIssuer       string
@@ -222,6 +427,9 @@ type options struct {
 
 func (o options) toTerm() *ast.Term {
 	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm(bundleAttribute), ast.StringTerm(o.bundle)),
+		ast.Item(ast.StringTerm(certificateAttribute), ast.StringTerm(o.certificate)),
+		ast.Item(ast.StringTerm(certificateChainAttribute), ast.StringTerm(o.certificateChain)),
 		ast.Item(ast.StringTerm(certificateIdentityAttribute), ast.StringTerm(o.certificateIdentity)),
 		ast.Item(ast.StringTerm(certificateIdentityRegExpAttribute), ast.StringTerm(o.certificateIdentityRegExp)),
 		ast.Item(ast.StringTerm(certificateOIDCIssuerAttribute), ast.StringTerm(o.certificateOIDCIssuer)),
@@ -235,31 +443,52 @@ func (o options) toTerm() *ast.Term {
 func optionsFromTerm(term *ast.Term) options {
 	opts := options{}
 
-	if v, ok := term.Get(ast.StringTerm(certificateIdentityAttribute)).Value.(ast.String); ok {
+	// Options are usually derived from data.config.default_sigstore_opts, which is not type
+	// checked, so any of the attributes may be missing.
+	get := func(attribute string) ast.Value {
+		if v := term.Get(ast.StringTerm(attribute)); v != nil {
+			return v.Value
+		}
+		return nil
+	}
+
+	if v, ok := get(bundleAttribute).(ast.String); ok {
+		opts.bundle = string(v)
+	}
+
+	if v, ok := get(certificateAttribute).(ast.String); ok {
+		opts.certificate = string(v)
+	}
+
+	if v, ok := get(certificateChainAttribute).(ast.String); ok {
+		opts.certificateChain = string(v)
+	}
+
+	if v, ok := get(certificateIdentityAttribute).(ast.String); ok {
 		opts.certificateIdentity = string(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(certificateIdentityRegExpAttribute)).Value.(ast.String); ok {
+	if v, ok := get(certificateIdentityRegExpAttribute).(ast.String); ok {
 		opts.certificateIdentityRegExp = string(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(certificateOIDCIssuerAttribute)).Value.(ast.String); ok {
+	if v, ok := get(certificateOIDCIssuerAttribute).(ast.String); ok {
 		opts.certificateOIDCIssuer = string(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(certificateOIDCIssuerRegExpAttribute)).Value.(ast.String); ok {
+	if v, ok := get(certificateOIDCIssuerRegExpAttribute).(ast.String); ok {
 		opts.certificateOIDCIssuerRegExp = string(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(ignoreRekorAttribute)).Value.(ast.Boolean); ok {
+	if v, ok := get(ignoreRekorAttribute).(ast.Boolean); ok {
 		opts.ignoreRekor = bool(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(publicKeyAttribute)).Value.(ast.String); ok {
+	if v, ok := get(publicKeyAttribute).(ast.String); ok {
 		opts.publicKey = string(v)
 	}
 
-	if v, ok := term.Get(ast.StringTerm(rekorURLAttribute)).Value.(ast.String); ok {
+	if v, ok := get(rekorURLAttribute).(ast.String); ok {
 		opts.rekorURL = string(v)
 	}
 
@@ -351,4 +580,5 @@ func attestationResult(attestations []oci.Signature, err error) (*ast.Term, erro
 func init() {
 	registerSigstoreVerifyImage()
 	registerSigstoreVerifyAttestation()
+	registerSigstoreVerifyBlob()
 }
@@ -20,7 +20,12 @@ package sigstore
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -30,12 +35,15 @@ import (
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
+	cosignBundle "github.com/sigstore/cosign/v2/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/v2/pkg/oci"
 	"github.com/sigstore/cosign/v2/pkg/oci/static"
 	cosignTypes "github.com/sigstore/cosign/v2/pkg/types"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/enterprise-contract/ec-cli/internal/rego/cache"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 	o "github.com/enterprise-contract/ec-cli/internal/utils/oci"
 	"github.com/enterprise-contract/ec-cli/internal/utils/oci/fake"
@@ -165,7 +173,7 @@ func TestSigstoreVerifyImage(t *testing.T) {
 			utils.SetTestCTLogPublicKey(t)
 
 			c := fake.FakeClient{}
-			ctx := o.WithClient(context.Background(), &c)
+			ctx := cache.WithCache(o.WithClient(context.Background(), &c), nil)
 
 			sig, err := static.NewSignature(
 				[]byte(`image`),
@@ -346,7 +354,7 @@ func TestSigstoreVerifyAttestation(t *testing.T) {
 			utils.SetTestCTLogPublicKey(t)
 
 			c := fake.FakeClient{}
-			ctx := o.WithClient(context.Background(), &c)
+			ctx := cache.WithCache(o.WithClient(context.Background(), &c), nil)
 
 			verifyCall := c.On(
 				"VerifyImageAttestations", goodImage, mock.Anything,
@@ -366,3 +374,214 @@ func TestSigstoreVerifyAttestation(t *testing.T) {
 		})
 	}
 }
+
+func TestSigstoreVerifyBlob(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := cryptoutils.MarshalPublicKeyToPEM(key.Public())
+	require.NoError(t, err)
+
+	content := "spam and eggs"
+	digest := sha256.Sum256([]byte(content))
+	rawSig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	goodSig := base64.StdEncoding.EncodeToString(rawSig)
+
+	bundle, err := json.Marshal(cosign.LocalSignedPayload{Base64Signature: goodSig})
+	require.NoError(t, err)
+
+	rekorBundle := func(digest [32]byte) string {
+		body := fmt.Sprintf(`{"apiVersion": "0.0.1", "kind": "hashedrekord", "spec": {"data": {"hash": {"algorithm": "sha256", "value": "%x"}}}}`, digest)
+		b, err := json.Marshal(cosign.LocalSignedPayload{
+			Base64Signature: goodSig,
+			Bundle:          &cosignBundle.RekorBundle{Payload: cosignBundle.RekorPayload{Body: base64.StdEncoding.EncodeToString([]byte(body))}},
+		})
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	cases := []struct {
+		name       string
+		success    *ast.Term
+		errors     *ast.Term
+		content    *ast.Term
+		signature  *ast.Term
+		opts       options
+		signatures int
+	}{
+		{
+			name:       "long lived key",
+			success:    ast.BooleanTerm(true),
+			errors:     ast.ArrayTerm(),
+			content:    ast.StringTerm(content),
+			signature:  ast.StringTerm(goodSig),
+			opts:       options{ignoreRekor: true, publicKey: string(publicKey)},
+			signatures: 1,
+		},
+		{
+			name:       "signature from bundle",
+			success:    ast.BooleanTerm(true),
+			errors:     ast.ArrayTerm(),
+			content:    ast.StringTerm(content),
+			signature:  ast.StringTerm(""),
+			opts:       options{ignoreRekor: true, publicKey: string(publicKey), bundle: string(bundle)},
+			signatures: 1,
+		},
+		{
+			name:       "rekor bundle",
+			success:    ast.BooleanTerm(true),
+			errors:     ast.ArrayTerm(),
+			content:    ast.StringTerm(content),
+			signature:  ast.StringTerm(""),
+			opts:       options{ignoreRekor: true, publicKey: string(publicKey), bundle: rekorBundle(digest)},
+			signatures: 1,
+		},
+		{
+			name:    "rekor bundle of other content",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm(fmt.Sprintf("signature: the bundle digest %x does not match the content digest %x", sha256.Sum256([]byte("spam and ham")), digest)),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(""),
+			opts:      options{ignoreRekor: true, publicKey: string(publicKey), bundle: rekorBundle(sha256.Sum256([]byte("spam and ham")))},
+		},
+		{
+			name:    "tampered content",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("verify blob signature: invalid signature when validating ASN.1 encoded signature"),
+			),
+			content:   ast.StringTerm("spam and ham"),
+			signature: ast.StringTerm(goodSig),
+			opts:      options{ignoreRekor: true, publicKey: string(publicKey)},
+		},
+		{
+			name:    "wrong key",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("verify blob signature: invalid signature when validating ASN.1 encoded signature"),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(goodSig),
+			opts:      options{ignoreRekor: true, publicKey: utils.TestPublicKey},
+		},
+		{
+			name:    "missing signature",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("signature: no signature provided"),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(""),
+			opts:      options{ignoreRekor: true, publicKey: string(publicKey)},
+		},
+		{
+			name:    "bad bundle",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("signature: parsing bundle: invalid character 's' looking for beginning of value"),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(goodSig),
+			opts:      options{ignoreRekor: true, publicKey: string(publicKey), bundle: "spam"},
+		},
+		{
+			name:    "keyless without certificate",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("verify blob signature: no certificate found on signature"),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(goodSig),
+			opts: options{
+				certificateIdentity:   "subject",
+				certificateOIDCIssuer: "issuer",
+				ignoreRekor:           true,
+			},
+		},
+		{
+			name:    "insufficient options",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("opts parameter: new policy: certificate OIDC issuer must be provided for keyless workflow\ncertificate identity must be provided for keyless workflow"),
+			),
+			content:   ast.StringTerm(content),
+			signature: ast.StringTerm(goodSig),
+		},
+		{
+			name:    "bad content",
+			success: ast.BooleanTerm(false),
+			errors: ast.ArrayTerm(
+				ast.StringTerm("content parameter: operand 0 must be string but got number"),
+			),
+			content:   ast.NumberTerm("42"),
+			signature: ast.StringTerm(goodSig),
+			opts:      options{ignoreRekor: true, publicKey: string(publicKey)},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetTestRekorPublicKey(t)
+			utils.SetTestFulcioRoots(t)
+			utils.SetTestCTLogPublicKey(t)
+
+			bctx := rego.BuiltinContext{Context: cache.WithCache(context.Background(), nil)}
+
+			result, err := sigstoreVerifyBlob(bctx, tt.content, tt.signature, tt.opts.toTerm())
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			require.Equal(t, tt.errors, result.Get(ast.StringTerm("errors")))
+			require.Equal(t, tt.success, result.Get(ast.StringTerm("success")))
+			require.Equal(t, tt.signatures, result.Get(ast.StringTerm("signatures")).Value.(*ast.Array).Len())
+		})
+	}
+}
+
+func TestVerifyCached(t *testing.T) {
+	dir := t.TempDir()
+	ctx := cache.WithCache(context.Background(), cache.New(dir))
+
+	calls := 0
+	verify := func(result *ast.Term) func() (*ast.Term, error) {
+		return func() (*ast.Term, error) {
+			calls++
+			return result, nil
+		}
+	}
+
+	success := ast.MustParseTerm(`{"success": true, "errors": [], "signatures": []}`)
+	failure := ast.MustParseTerm(`{"success": false, "errors": ["spam"], "signatures": []}`)
+
+	// failures are not remembered
+	for range 2 {
+		got, err := verifyCached(ctx, "ec.test", "failure", verify(failure))
+		require.NoError(t, err)
+		require.Equal(t, failure, got)
+	}
+	require.Equal(t, 2, calls)
+
+	calls = 0
+	for range 2 {
+		got, err := verifyCached(ctx, "ec.test", "success", verify(success))
+		require.NoError(t, err)
+		require.Equal(t, success, got)
+	}
+	require.Equal(t, 1, calls)
+
+	// a new cache in the same directory simulates a subsequent run
+	ctx = cache.WithCache(context.Background(), cache.New(dir))
+	_, err := verifyCached(ctx, "ec.test", "success", verify(success))
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestOptionsFromPartialTerm(t *testing.T) {
+	opts := optionsFromTerm(ast.ObjectTerm(
+		ast.Item(ast.StringTerm(publicKeyAttribute), ast.StringTerm("the-key")),
+		ast.Item(ast.StringTerm(ignoreRekorAttribute), ast.BooleanTerm(true)),
+	))
+
+	require.Equal(t, options{publicKey: "the-key", ignoreRekor: true}, opts)
+}
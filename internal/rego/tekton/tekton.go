@@ -0,0 +1,162 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: The rego functions in this file never return an error. Instead, they return no value
+// when an error is encountered. If they did return an error, opa would exit abruptly and it would
+// not produce a report of which policy rules succeeded/failed.
+
+package tekton
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/tracker"
+)
+
+const tektonBundleObjectName = "ec.tekton.bundle_object"
+
+// bundleKinds are the kinds of the objects a Tekton bundle can hold, as used in the
+// dev.tekton.image.kind annotation of its layers.
+var bundleKinds = map[string]bool{
+	"pipeline":   true,
+	"stepaction": true,
+	"task":       true,
+}
+
+func registerTektonBundleObject() {
+	decl := rego.Function{
+		Name: tektonBundleObjectName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("Tekton bundle image reference"),
+				types.Named("kind", types.S).Description("kind of the object: task, pipeline or stepaction"),
+				types.Named("name", types.S).Description("name of the object"),
+			),
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(
+				types.Named("attribute", types.S).Description("the top level attributes of the object, e.g. spec"),
+				types.Named("value", types.A).Description("their values"),
+			))).Description("the Tekton object"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	rego.RegisterBuiltin3(&decl, tektonBundleObject)
+	// Due to https://github.com/open-policy-agent/opa/issues/6449, we cannot set a description for
+	// the custom function through the call above. As a workaround we re-register the function with
+	// a declaration that does include the description.
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name:             decl.Name,
+		Description:      "Fetch a Task, Pipeline or StepAction from a Tekton bundle.",
+		Decl:             decl.Decl,
+		Nondeterministic: decl.Nondeterministic,
+	})
+}
+
+func tektonBundleObject(bctx rego.BuiltinContext, refTerm, kindTerm, nameTerm *ast.Term) (*ast.Term, error) {
+	logger := log.WithField("function", tektonBundleObjectName)
+
+	uri, ok := refTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("ref is not a string")
+		return nil, nil
+	}
+	kindValue, ok := kindTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("kind is not a string")
+		return nil, nil
+	}
+	nameValue, ok := nameTerm.Value.(ast.String)
+	if !ok {
+		logger.Error("name is not a string")
+		return nil, nil
+	}
+	logger = logger.WithFields(log.Fields{
+		"ref":  string(uri),
+		"kind": string(kindValue),
+		"name": string(nameValue),
+	})
+
+	// Tekton bundles use lower case kinds, accept the kinds as they appear in the objects too,
+	// e.g. StepAction.
+	kind := strings.ToLower(string(kindValue))
+	if !bundleKinds[kind] {
+		logger.Error("unsupported kind")
+		return nil, nil
+	}
+
+	if _, err := name.ParseReference(string(uri)); err != nil {
+		logger.WithFields(log.Fields{
+			"action": "parse reference",
+			"error":  err,
+		}).Error("failed to parse reference")
+		return nil, nil
+	}
+
+	obj, err := tracker.NewClient(bctx.Context).GetTektonObject(bctx.Context, string(uri), kind, string(nameValue))
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "fetch object",
+			"error":  err,
+		}).Error("failed to fetch Tekton object")
+		return nil, nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "marshal object",
+			"error":  err,
+		}).Error("failed to marshal Tekton object")
+		return nil, nil
+	}
+
+	var object any
+	if err := json.Unmarshal(data, &object); err != nil {
+		logger.WithFields(log.Fields{
+			"action": "unmarshal object",
+			"error":  err,
+		}).Error("failed to unmarshal Tekton object")
+		return nil, nil
+	}
+
+	value, err := ast.InterfaceToValue(object)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"action": "convert object",
+			"error":  err,
+		}).Error("failed to convert Tekton object to value")
+		return nil, nil
+	}
+
+	logger.Debug("Successfully fetched Tekton object")
+
+	return ast.NewTerm(value), nil
+}
+
+func init() {
+	registerTektonBundleObject()
+}
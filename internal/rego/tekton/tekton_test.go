@@ -0,0 +1,175 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package tekton
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/require"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/enterprise-contract/ec-cli/internal/tracker"
+)
+
+const bundle = "registry.local/tasks:latest"
+
+type fakeClient struct {
+	objects map[string]runtime.Object
+}
+
+func (c fakeClient) GetTektonObject(_ context.Context, ref, kind, name string) (runtime.Object, error) {
+	if o, ok := c.objects[fmt.Sprintf("%s/%s/%s", ref, kind, name)]; ok {
+		return o, nil
+	}
+	return nil, fmt.Errorf("could not find object in image with kind: %s and name: %s", kind, name)
+}
+
+func (fakeClient) GetImage(_ context.Context, _ name.Reference) (v1.Image, error) {
+	return nil, nil
+}
+
+func TestTektonBundleObject(t *testing.T) {
+	task := &pipelinev1.Task{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "Task"},
+		ObjectMeta: metav1.ObjectMeta{Name: "buildah"},
+		Spec: pipelinev1.TaskSpec{
+			Params: []pipelinev1.ParamSpec{{Name: "IMAGE", Type: pipelinev1.ParamTypeString}},
+			Steps:  []pipelinev1.Step{{Name: "build", Image: "registry.local/buildah:latest"}},
+		},
+	}
+
+	client := fakeClient{objects: map[string]runtime.Object{
+		bundle + "/task/buildah": task,
+	}}
+
+	cases := []struct {
+		name     string
+		ref      *ast.Term
+		kind     *ast.Term
+		objName  *ast.Term
+		expected string
+	}{
+		{
+			name:    "task",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.StringTerm("task"),
+			objName: ast.StringTerm("buildah"),
+			expected: `{
+				"apiVersion": "tekton.dev/v1",
+				"kind": "Task",
+				"metadata": {"creationTimestamp": null, "name": "buildah"},
+				"spec": {
+					"params": [{"name": "IMAGE", "type": "string"}],
+					"steps": [{"name": "build", "image": "registry.local/buildah:latest", "computeResources": {}}]
+				}
+			}`,
+		},
+		{
+			name:    "kind as in the object",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.StringTerm("Task"),
+			objName: ast.StringTerm("buildah"),
+			expected: `{
+				"apiVersion": "tekton.dev/v1",
+				"kind": "Task",
+				"metadata": {"creationTimestamp": null, "name": "buildah"},
+				"spec": {
+					"params": [{"name": "IMAGE", "type": "string"}],
+					"steps": [{"name": "build", "image": "registry.local/buildah:latest", "computeResources": {}}]
+				}
+			}`,
+		},
+		{
+			name:    "missing object",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.StringTerm("pipeline"),
+			objName: ast.StringTerm("buildah"),
+		},
+		{
+			name:    "unsupported kind",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.StringTerm("pod"),
+			objName: ast.StringTerm("buildah"),
+		},
+		{
+			name:    "invalid reference",
+			ref:     ast.StringTerm("registry.local/SPAM"),
+			kind:    ast.StringTerm("task"),
+			objName: ast.StringTerm("buildah"),
+		},
+		{
+			name:    "unexpected ref type",
+			ref:     ast.IntNumberTerm(42),
+			kind:    ast.StringTerm("task"),
+			objName: ast.StringTerm("buildah"),
+		},
+		{
+			name:    "unexpected kind type",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.IntNumberTerm(42),
+			objName: ast.StringTerm("buildah"),
+		},
+		{
+			name:    "unexpected name type",
+			ref:     ast.StringTerm(bundle),
+			kind:    ast.StringTerm("task"),
+			objName: ast.IntNumberTerm(42),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := tracker.WithClient(context.Background(), client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := tektonBundleObject(bctx, c.ref, c.kind, c.objName)
+			require.NoError(t, err)
+			if c.expected == "" {
+				require.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			require.JSONEq(t, c.expected, result.String())
+		})
+	}
+}
+
+func TestFunctionsRegistered(t *testing.T) {
+	names := []string{
+		tektonBundleObjectName,
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, builtin := range ast.Builtins {
+				if builtin.Name == name {
+					return
+				}
+			}
+			t.Fatalf("%s builtin not registered", name)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// ManifestFile is the name of the file describing the bundle, relative to the
+// bundle directory.
+const ManifestFile = "replay.json"
+
+// Component holds the policy input of a validated component, relative to the
+// bundle directory, and the recorded outcome of its evaluation. Components
+// that were not evaluated against the policy, e.g. because their image was
+// not accessible, have no input.
+type Component struct {
+	app.SnapshotComponent
+	Input      string             `json:"input,omitempty"`
+	Success    bool               `json:"success"`
+	Violations []evaluator.Result `json:"violations,omitempty"`
+	Warnings   []evaluator.Result `json:"warnings,omitempty"`
+}
+
+// Manifest describes the contents of a bundle: the resolved policy
+// configuration, with the source URLs pinned, the effective time and the
+// components. The policy and data sources are stored in the bundle under
+// sources/<source index>/<policy or data>/<url index>.
+type Manifest struct {
+	EcVersion     string                           `json:"ec-version"`
+	EffectiveTime time.Time                        `json:"effective-time"`
+	Policy        ecc.EnterpriseContractPolicySpec `json:"policy"`
+	Components    []Component                      `json:"components"`
+}
+
+func sourceDir(dir string, sourceIndex int, kind source.PolicyType, urlIndex int) string {
+	return filepath.Join(dir, "sources", strconv.Itoa(sourceIndex), string(kind), strconv.Itoa(urlIndex))
+}
+
+// Write stores the bundle needed to replay the evaluation of the components
+// of the report in the given directory. The policy and data sources are
+// fetched using the pinned URLs of the report's policy, the already
+// downloaded sources are reused.
+func Write(ctx context.Context, dir string, report applicationsnapshot.Report) error {
+	fs := utils.FS(ctx)
+
+	if err := fs.MkdirAll(filepath.Join(dir, "inputs"), 0755); err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		EcVersion:     report.EcVersion,
+		EffectiveTime: report.EffectiveTime,
+		Policy:        report.Policy,
+		Components:    make([]Component, 0, len(report.Components)),
+	}
+
+	for i, c := range report.Components {
+		rc := Component{
+			SnapshotComponent: c.SnapshotComponent,
+			Success:           c.Success,
+			Violations:        append([]evaluator.Result{}, c.Violations...),
+		}
+
+		// The evaluation produced the baselined warnings as violations
+		for _, w := range c.Warnings {
+			if evaluator.IsBaselined(w) {
+				rc.Violations = append(rc.Violations, w)
+			} else {
+				rc.Warnings = append(rc.Warnings, w)
+			}
+		}
+
+		if i < len(report.PolicyInput) && len(report.PolicyInput[i]) > 0 {
+			rc.Input = filepath.Join("inputs", fmt.Sprintf("%d.json", i))
+			if err := afero.WriteFile(fs, filepath.Join(dir, rc.Input), report.PolicyInput[i], 0644); err != nil {
+				return err
+			}
+		} else {
+			log.Debugf("No policy input for component %q, it will not be replayed", c.Name)
+		}
+
+		manifest.Components = append(manifest.Components, rc)
+	}
+
+	// The work dir is not removed, the download cache refers to it for any
+	// subsequent fetch of the same sources
+	workDir, err := utils.CreateWorkDir(fs)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range report.Policy.Sources {
+		for _, kind := range []source.PolicyType{source.PolicyKind, source.DataKind} {
+			urls := s.Policy
+			if kind == source.DataKind {
+				urls = s.Data
+			}
+
+			for j, url := range urls {
+				src := source.PolicyUrl{Url: url, Kind: kind}
+				fetched, err := src.GetPolicy(ctx, workDir, false)
+				if err != nil {
+					return fmt.Errorf("unable to fetch the %s source %q: %w", kind, url, err)
+				}
+
+				if err := copyTree(fs, fetched, sourceDir(dir, i, kind, j)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, filepath.Join(dir, ManifestFile), data, 0644)
+}
+
+// Read loads the manifest of the bundle in the given directory.
+func Read(ctx context.Context, dir string) (Manifest, error) {
+	var manifest Manifest
+
+	data, err := afero.ReadFile(utils.FS(ctx), filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return manifest, fmt.Errorf("unable to read the replay bundle: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("unable to parse %s: %w", ManifestFile, err)
+	}
+
+	return manifest, nil
+}
+
+// vcsDirs are the directories holding the metadata of version control
+// systems, not needed for the evaluation and not copied to the bundle.
+var vcsDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// copyTree copies the files of the src directory to the dst directory, except
+// for the version control metadata. The src directory may be a symlink, as
+// created by the download cache.
+func copyTree(fs afero.Fs, src, dst string) error {
+	if l, ok := fs.(afero.LinkReader); ok {
+		if target, err := l.ReadlinkIfPossible(src); err == nil {
+			src = target
+		}
+	}
+
+	return afero.Walk(fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			if vcsDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return fs.MkdirAll(target, 0755)
+		}
+
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(fs, target, data, 0644)
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils/oci"
+)
+
+// errOffline is returned for any request made while replaying a bundle
+var errOffline = errors.New("network access is not allowed when replaying")
+
+// offlineTransport fails all requests, the outcome of the replay is to
+// depend solely on the contents of the bundle.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), errOffline)
+}
+
+// offline returns the context with an OCI client failing all requests, as
+// used by the ec.oci.* and the ec.sigstore.* built-in functions, and replaces
+// the default HTTP transport, as used by other clients, e.g. for fetching the
+// Sigstore trust root, until the returned function is called.
+func offline(ctx context.Context) (context.Context, func()) {
+	ctx = oci.WithClient(ctx, oci.NewClient(ctx, remote.WithTransport(offlineTransport{}), remote.WithContext(ctx)))
+
+	transport := http.DefaultTransport
+	http.DefaultTransport = offlineTransport{}
+
+	return ctx, func() {
+		http.DefaultTransport = transport
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/output"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+)
+
+// localPolicy returns the policy configuration of the manifest with the URLs
+// of the policy and data sources replaced by the paths of their copies in the
+// bundle directory.
+func (m Manifest) localPolicy(dir string) ecc.EnterpriseContractPolicySpec {
+	spec := *m.Policy.DeepCopy()
+	for i := range spec.Sources {
+		for j := range spec.Sources[i].Policy {
+			spec.Sources[i].Policy[j] = "file::" + sourceDir(dir, i, source.PolicyKind, j)
+		}
+		for j := range spec.Sources[i].Data {
+			spec.Sources[i].Data[j] = "file::" + sourceDir(dir, i, source.DataKind, j)
+		}
+	}
+	return spec
+}
+
+// Replay evaluates the policy inputs of the bundle in the given directory
+// against the policy and data sources stored in the bundle, using the
+// recorded effective time, and returns the difference between the recorded
+// and the replayed outcome. Only the results of the policy rules are
+// compared, the results of the built-in checks, e.g. of the image signature,
+// require access to the registry and cannot be replayed. For the same reason
+// the network is not accessible while replaying, the policy rules relying on
+// it are evaluated as if the requests failed.
+func Replay(ctx context.Context, dir string) (applicationsnapshot.ReportDiff, error) {
+	ctx, restore := offline(ctx)
+	defer restore()
+
+	m, err := Read(ctx, dir)
+	if err != nil {
+		return applicationsnapshot.ReportDiff{}, err
+	}
+
+	// The file sources need to be absolute to be distinguished from remote
+	// sources
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return applicationsnapshot.ReportDiff{}, err
+	}
+
+	spec, err := json.Marshal(m.localPolicy(dir))
+	if err != nil {
+		return applicationsnapshot.ReportDiff{}, err
+	}
+
+	p, err := policy.NewInputPolicy(ctx, string(spec), m.EffectiveTime.Format(time.RFC3339))
+	if err != nil {
+		return applicationsnapshot.ReportDiff{}, err
+	}
+
+	var evaluators []evaluator.Evaluator
+	for _, sourceGroup := range p.Spec().Sources {
+		e, err := evaluator.NewConftestEvaluator(ctx, source.PolicySourcesFrom(sourceGroup), p, sourceGroup)
+		if err != nil {
+			return applicationsnapshot.ReportDiff{}, err
+		}
+		defer e.Destroy()
+		evaluators = append(evaluators, e)
+	}
+
+	recorded := applicationsnapshot.Report{}
+	replayed := applicationsnapshot.Report{}
+	for _, c := range m.Components {
+		if c.Input == "" {
+			log.Debugf("Component %q has no policy input, skipping", c.Name)
+			continue
+		}
+
+		var outcomes []evaluator.Outcome
+		target := evaluator.EvaluationTarget{
+			Inputs: []string{filepath.Join(dir, c.Input)},
+			Target: c.ContainerImage,
+		}
+		for _, e := range evaluators {
+			results, err := e.Evaluate(ctx, target)
+			if err != nil {
+				return applicationsnapshot.ReportDiff{}, fmt.Errorf("evaluating policy for component %q: %w", c.Name, err)
+			}
+			outcomes = append(outcomes, results...)
+		}
+
+		out := output.Output{}
+		out.SetPolicyCheck(outcomes)
+
+		recorded.Components = append(recorded.Components, normalized(applicationsnapshot.Component{
+			SnapshotComponent: c.SnapshotComponent,
+			Violations:        c.Violations,
+			Warnings:          c.Warnings,
+		}))
+		replayed.Components = append(replayed.Components, normalized(applicationsnapshot.Component{
+			SnapshotComponent: c.SnapshotComponent,
+			Violations:        out.Violations(),
+			Warnings:          out.Warnings(),
+		}))
+	}
+
+	recorded.Success = allSucceeded(recorded.Components)
+	replayed.Success = allSucceeded(replayed.Components)
+
+	return applicationsnapshot.Diff(recorded, replayed), nil
+}
+
+// Matches returns true if the replayed outcome does not differ from the
+// recorded one.
+func Matches(d applicationsnapshot.ReportDiff) bool {
+	if d.OldSuccess != d.NewSuccess {
+		return false
+	}
+
+	for _, c := range d.Components {
+		if c.Status != applicationsnapshot.ComponentUnchanged {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalized returns the component with only the results of the policy rules
+// and the success determined by the violations.
+func normalized(c applicationsnapshot.Component) applicationsnapshot.Component {
+	c.Violations = policyResults(c.Violations)
+	c.Warnings = policyResults(c.Warnings)
+	c.Success = len(c.Violations) == 0
+	return c
+}
+
+func policyResults(results []evaluator.Result) []evaluator.Result {
+	filtered := make([]evaluator.Result, 0, len(results))
+	for _, r := range results {
+		if strings.HasPrefix(evaluator.ExtractStringFromMetadata(r, "code"), "builtin.") {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+func allSucceeded(components []applicationsnapshot.Component) bool {
+	for _, c := range components {
+		if !c.Success {
+			return false
+		}
+	}
+	return true
+}
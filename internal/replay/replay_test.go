@@ -0,0 +1,211 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/konflux-ci/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	_ "github.com/enterprise-contract/ec-cli/internal/rego"
+)
+
+const rules = `package main
+
+import rego.v1
+
+# METADATA
+# custom:
+#   short_name: failure
+deny contains result if {
+	input.fail
+	result := {"code": "main.failure", "msg": "Failure!"}
+}
+
+# METADATA
+# custom:
+#   short_name: warning
+warn contains result if {
+	result := {"code": "main.warning", "msg": "Warning!"}
+}
+`
+
+func testReport(t *testing.T) applicationsnapshot.Report {
+	policyDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "main.rego"), []byte(rules), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(policyDir, ".git", "objects"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0600))
+
+	warning := evaluator.Result{Message: "Warning!", Metadata: map[string]any{"code": "main.warning"}}
+
+	return applicationsnapshot.Report{
+		EffectiveTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Policy: ecc.EnterpriseContractPolicySpec{
+			Sources: []ecc.Source{{Policy: []string{"file::" + policyDir}}},
+		},
+		Components: []applicationsnapshot.Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: "registry.io/one@sha256:abc"},
+				Violations: []evaluator.Result{
+					{Message: "No image signatures found", Metadata: map[string]any{"code": "builtin.image.signature_check"}},
+				},
+				Warnings: []evaluator.Result{
+					warning,
+					{Message: "Failure!", Metadata: map[string]any{"code": "main.failure", evaluator.BaselinedMetadata: true}},
+				},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "two", ContainerImage: "registry.io/two@sha256:def"},
+				Success:           true,
+				Warnings:          []evaluator.Result{warning},
+			},
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "three", ContainerImage: "registry.io/three@sha256:fed"},
+				Violations: []evaluator.Result{
+					{Message: "Image URL is not accessible", Metadata: map[string]any{"code": "builtin.image.accessible"}},
+				},
+			},
+		},
+		PolicyInput: [][]byte{[]byte(`{"fail": true}`), []byte(`{"fail": false}`), nil},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	require.NoError(t, Write(ctx, dir, testReport(t)))
+
+	assert.FileExists(t, filepath.Join(dir, ManifestFile))
+	assert.FileExists(t, filepath.Join(dir, "sources", "0", "policy", "0", "main.rego"))
+	// The version control metadata is not needed for the evaluation
+	assert.NoDirExists(t, filepath.Join(dir, "sources", "0", "policy", "0", ".git"))
+
+	input, err := os.ReadFile(filepath.Join(dir, "inputs", "1.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fail": false}`, string(input))
+
+	m, err := Read(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), m.EffectiveTime)
+	require.Len(t, m.Components, 3)
+
+	// The baselined warning is recorded as the violation it was evaluated as
+	assert.Equal(t, "inputs/0.json", m.Components[0].Input)
+	assert.Len(t, m.Components[0].Violations, 2)
+	assert.Len(t, m.Components[0].Warnings, 1)
+
+	// Components not evaluated against the policy have no input
+	assert.Empty(t, m.Components[2].Input)
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	report := testReport(t)
+	dir := t.TempDir()
+
+	require.NoError(t, Write(ctx, dir, report))
+
+	diff, err := Replay(ctx, dir)
+	require.NoError(t, err)
+	assert.True(t, Matches(diff))
+	require.Len(t, diff.Components, 2)
+	assert.Equal(t, "one", diff.Components[0].Name)
+	assert.Equal(t, "two", diff.Components[1].Name)
+
+	// A tampered input leads to a different outcome
+	tampered := t.TempDir()
+	require.NoError(t, Write(ctx, tampered, report))
+	require.NoError(t, os.WriteFile(filepath.Join(tampered, "inputs", "1.json"), []byte(`{"fail": true}`), 0600))
+
+	diff, err = Replay(ctx, tampered)
+	require.NoError(t, err)
+	assert.False(t, Matches(diff))
+	assert.Equal(t, applicationsnapshot.ComponentChanged, diff.Components[1].Status)
+	assert.Equal(t, []applicationsnapshot.ResultChange{{Code: "main.failure", Message: "Failure!"}}, diff.Components[1].Violations.New)
+}
+
+func TestReplayMissingBundle(t *testing.T) {
+	_, err := Replay(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "unable to read the replay bundle")
+}
+
+func TestReplayOffline(t *testing.T) {
+	var requests atomic.Int32
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(registry.Close)
+
+	policyDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "main.rego"), []byte(`package main
+
+import rego.v1
+
+# METADATA
+# custom:
+#   short_name: manifest
+deny contains result if {
+	not ec.oci.image_manifest(input.image)
+	result := {"code": "main.manifest", "msg": "No manifest"}
+}
+`), 0600))
+
+	image := fmt.Sprintf("%s/repository@sha256:%s", strings.TrimPrefix(registry.URL, "http://"), strings.Repeat("0", 64))
+	report := applicationsnapshot.Report{
+		Policy: ecc.EnterpriseContractPolicySpec{
+			Sources: []ecc.Source{{Policy: []string{"file::" + policyDir}}},
+		},
+		Components: []applicationsnapshot.Component{
+			{
+				SnapshotComponent: app.SnapshotComponent{Name: "one", ContainerImage: image},
+				Violations: []evaluator.Result{
+					{Message: "No manifest", Metadata: map[string]any{"code": "main.manifest"}},
+				},
+			},
+		},
+		PolicyInput: [][]byte{[]byte(fmt.Sprintf(`{"image": %q}`, image))},
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, Write(ctx, dir, report))
+
+	diff, err := Replay(ctx, dir)
+	require.NoError(t, err)
+	assert.True(t, Matches(diff))
+	assert.Zero(t, requests.Load())
+	// The default transport is restored
+	assert.IsType(t, &http.Transport{}, http.DefaultTransport)
+}
@@ -151,3 +151,14 @@ func addCertificateMetadataTo(where *map[string]string, cer *x509.Certificate) e
 
 	return nil
 }
+
+// CertificateMetadata returns the attributes and known extensions of the certificate, keyed by
+// their descriptive names, e.g. "Subject" or "Fulcio Issuer".
+func CertificateMetadata(cer *x509.Certificate) (map[string]string, error) {
+	metadata := map[string]string{}
+	if err := addCertificateMetadataTo(&metadata, cer); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
@@ -56,6 +56,18 @@ func TestAddCertificateMetadata(t *testing.T) {
 	}
 }
 
+func TestCertificateMetadata(t *testing.T) {
+	cer := ParseChainguardReleaseCert()
+
+	expected := map[string]string{}
+	require.NoError(t, addCertificateMetadataTo(&expected, cer))
+
+	metadata, err := CertificateMetadata(cer)
+	require.NoError(t, err)
+	assert.Equal(t, expected, metadata)
+	assert.Equal(t, "https://token.actions.githubusercontent.com", metadata["Fulcio Issuer"])
+}
+
 func TestNewEntitySignature(t *testing.T) {
 	signature, err := static.NewSignature(
 		[]byte(`image`),
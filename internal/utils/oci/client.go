@@ -99,6 +99,8 @@ type Client interface {
 	Image(name.Reference) (v1.Image, error)
 	Layer(name.Digest) (v1.Layer, error)
 	Index(name.Reference) (v1.ImageIndex, error)
+	Referrers(name.Digest, string) (v1.ImageIndex, error)
+	ListTags(name.Repository, int) ([]string, bool, error)
 }
 
 func WithClient(ctx context.Context, client Client) context.Context {
@@ -250,3 +252,60 @@ func (c *defaultClient) Index(ref name.Reference) (v1.ImageIndex, error) {
 
 	return index, nil
 }
+
+// Referrers returns the index of the manifests referring to the given digest,
+// using the OCI 1.1 referrers API or the referrers tag schema when the registry
+// does not support it. When artifactType is provided only the manifests of
+// that artifact type are included.
+func (c *defaultClient) Referrers(ref name.Digest, artifactType string) (v1.ImageIndex, error) {
+	if trace.IsEnabled() {
+		region := trace.StartRegion(c.ctx, "ec:oci-fetch-referrers")
+		defer region.End()
+		trace.Logf(c.ctx, "", "image=%q", ref)
+	}
+
+	opts := c.opts
+	if artifactType != "" {
+		opts = append(append([]remote.Option{}, c.opts...), remote.WithFilter("artifactType", artifactType))
+	}
+
+	index, err := remote.Referrers(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrers: %w", err)
+	}
+	return index, nil
+}
+
+// ListTags returns at most limit tags of the repository, fetching them a page
+// at a time, and whether the repository has more tags than were returned.
+func (c *defaultClient) ListTags(repo name.Repository, limit int) ([]string, bool, error) {
+	if trace.IsEnabled() {
+		region := trace.StartRegion(c.ctx, "ec:oci-list-tags")
+		defer region.End()
+		trace.Logf(c.ctx, "", "repository=%q", repo)
+	}
+
+	puller, err := remote.NewPuller(c.opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	lister, err := puller.Lister(c.ctx, repo)
+	if err != nil {
+		return nil, false, fmt.Errorf("listing tags: %w", err)
+	}
+
+	var tags []string
+	for lister.HasNext() {
+		page, err := lister.Next(c.ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("listing tags: %w", err)
+		}
+		tags = append(tags, page.Tags...)
+		if len(tags) > limit {
+			return tags[:limit], true, nil
+		}
+	}
+
+	return tags, false, nil
+}
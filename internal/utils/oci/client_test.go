@@ -21,17 +21,23 @@ package oci
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/types"
@@ -175,6 +181,110 @@ func TestLayer(t *testing.T) {
 	assert.Equal(t, fetchCount, blobDownloadCount)
 }
 
+func TestReferrers(t *testing.T) {
+	for _, referrersSupport := range []bool{true, false} {
+		t.Run(fmt.Sprintf("referrers API %t", referrersSupport), func(t *testing.T) {
+			l := &bytes.Buffer{}
+			registry := httptest.NewServer(registry.New(registry.Logger(log.New(l, "", 0)), registry.WithReferrersSupport(referrersSupport)))
+			t.Cleanup(registry.Close)
+
+			u, err := url.Parse(registry.URL)
+			require.NoError(t, err)
+
+			img, err := random.Image(1024, 1)
+			require.NoError(t, err)
+			ref, err := name.ParseReference(fmt.Sprintf("localhost:%s/repository/image:tag", u.Port()))
+			require.NoError(t, err)
+			require.NoError(t, remote.Push(ref, img))
+
+			desc, err := remote.Head(ref)
+			require.NoError(t, err)
+			subject := ref.Context().Digest(desc.Digest.String())
+
+			for _, artifactType := range []string{"application/vnd.spam", "application/vnd.bacon"} {
+				artifact := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.MediaType(artifactType))
+				artifact = mutate.Subject(artifact, *desc).(v1.Image)
+				digest, err := artifact.Digest()
+				require.NoError(t, err)
+				require.NoError(t, remote.Write(ref.Context().Digest(digest.String()), artifact))
+			}
+
+			client := defaultClient{}
+
+			index, err := client.Referrers(subject, "")
+			require.NoError(t, err)
+			manifest, err := index.IndexManifest()
+			require.NoError(t, err)
+			assert.Len(t, manifest.Manifests, 2)
+
+			index, err = client.Referrers(subject, "application/vnd.spam")
+			require.NoError(t, err)
+			manifest, err = index.IndexManifest()
+			require.NoError(t, err)
+			require.Len(t, manifest.Manifests, 1)
+			assert.Equal(t, "application/vnd.spam", manifest.Manifests[0].ArtifactType)
+
+			fallback := strings.Contains(l.String(), "GET /v2/repository/image/manifests/sha256-")
+			assert.Equal(t, !referrersSupport, fallback)
+		})
+	}
+}
+
+func TestListTags(t *testing.T) {
+	l := &bytes.Buffer{}
+	handler := registry.New(registry.Logger(log.New(l, "", 0)))
+	// The registry does not link to the next page of tags, add the Link header
+	// for full pages as a registry supporting pagination would
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/tags/list") || r.URL.Query().Get("n") == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		if n, _ := strconv.Atoi(r.URL.Query().Get("n")); len(page.Tags) == n {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`, r.URL.Path, n, page.Tags[n-1]))
+		}
+		w.Header().Set("Content-Type", rec.Header().Get("Content-Type"))
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(rec.Body.Bytes())
+	}))
+	t.Cleanup(registry.Close)
+
+	u, err := url.Parse(registry.URL)
+	require.NoError(t, err)
+
+	repo, err := name.NewRepository(fmt.Sprintf("localhost:%s/repository/image", u.Port()))
+	require.NoError(t, err)
+
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	expected := []string{"v1.0", "v1.1", "v1.2", "v2.0", "v2.1"}
+	for _, tag := range expected {
+		require.NoError(t, remote.Write(repo.Tag(tag), img))
+	}
+
+	// A small page size to exercise the pagination
+	client := defaultClient{ctx: context.Background(), opts: []remote.Option{remote.WithPageSize(2)}}
+
+	tags, truncated, err := client.ListTags(repo, 10)
+	require.NoError(t, err)
+	assert.Equal(t, expected, tags)
+	assert.False(t, truncated)
+	assert.Equal(t, 3, strings.Count(l.String(), "GET /v2/repository/image/tags/list"))
+
+	tags, truncated, err = client.ListTags(repo, 3)
+	require.NoError(t, err)
+	assert.Equal(t, expected[:3], tags)
+	assert.True(t, truncated)
+}
+
 func TestScopedAuth(t *testing.T) {
 	cases := []struct {
 		repository string
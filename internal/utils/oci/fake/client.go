@@ -143,3 +143,21 @@ func (m *FakeClient) Index(ref name.Reference) (v1.ImageIndex, error) {
 	}
 	return index, args.Error(1)
 }
+
+func (m *FakeClient) Referrers(ref name.Digest, artifactType string) (v1.ImageIndex, error) {
+	args := m.Called(ref, artifactType)
+	var index v1.ImageIndex
+	if maybeIndex, ok := args.Get(0).(v1.ImageIndex); ok {
+		index = maybeIndex
+	}
+	return index, args.Error(1)
+}
+
+func (m *FakeClient) ListTags(repo name.Repository, limit int) ([]string, bool, error) {
+	args := m.Called(repo, limit)
+	var tags []string
+	if maybeTags, ok := args.Get(0).([]string); ok {
+		tags = maybeTags
+	}
+	return tags, args.Bool(1), args.Error(2)
+}
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	htmltemplate "html/template"
 	"strings"
 	"text/template"
 
@@ -77,6 +78,40 @@ func RenderFromTemplatesWithGlob(input any, main string, glob []string, efs embe
 	return buf.Bytes(), nil
 }
 
+// RenderFromTemplateText renders the given template text, e.g. a template
+// supplied by the user, with the helper functions available to it
+func RenderFromTemplateText(input any, name string, text string) ([]byte, error) {
+	t, err := template.New(name).Funcs(templateHelpers).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderHTMLFromTemplatesWithGlob is like RenderFromTemplatesWithGlob but uses
+// html/template so that the values rendered are escaped contextually
+func RenderHTMLFromTemplatesWithGlob(input any, main string, glob []string, efs embed.FS) ([]byte, error) {
+	t, err := htmltemplate.New(defaultMainTemplate).Funcs(htmltemplate.FuncMap(templateHelpers)).ParseFS(efs, glob...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = t.ExecuteTemplate(&buf, main, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // Helper funcs for use in templates
 
 func passWarnFailChooser(color string, choices []string) string {
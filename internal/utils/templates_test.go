@@ -67,3 +67,18 @@ func TestSetupTemplate(t *testing.T) {
 		assert.Equal(t, tt.expected, buf.String())
 	}
 }
+
+func TestRenderHTMLFromTemplatesWithGlob(t *testing.T) {
+	out, err := RenderHTMLFromTemplatesWithGlob(map[string]string{"name": "<b>friend</b>"}, "main.tmpl", []string{"test_templates/*.tmpl"}, testTemplatesFS)
+	assert.NoError(t, err)
+	assert.Equal(t, "✓ Hello and greetings, &lt;b&gt;friend&lt;/b&gt;.\n\n", string(out))
+}
+
+func TestRenderFromTemplateText(t *testing.T) {
+	out, err := RenderFromTemplateText(map[string]string{"name": "friend"}, "test", `{{ indicator "green" }} {{ indent 2 .name }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "✓   friend", string(out))
+
+	_, err = RenderFromTemplateText(nil, "test", `{{ unknown }}`)
+	assert.Error(t, err)
+}